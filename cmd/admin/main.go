@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/milanvthakor/task-manager-api/internal/database"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+const usage = `Usage: admin <command> <email>
+
+Commands:
+  promote  Give the admin role to the user with the email
+  demote   Take the admin role away from the user with the email
+`
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var role models.UserRole
+	switch os.Args[1] {
+	case "promote":
+		role = models.UserRoleAdmin
+	case "demote":
+		role = models.UserRoleUser
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables from the .env file
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Couldn't load .env file %v\n", err)
+	}
+
+	// Initialize the configuration.
+	cfg := config.New()
+	if cfg.DatabaseDriver == database.DriverMemory {
+		log.Fatalf("The %q database driver doesn't support managing admins from the command line", cfg.DatabaseDriver)
+	}
+
+	// Initialize the database.
+	db, err := database.Init(cfg.DatabaseDriver, cfg.DatabaseDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize the database: %v", err)
+	}
+	defer database.Close()
+
+	users := models.NewUserRepository(db, database.Dialect(cfg.DatabaseDriver))
+	email := os.Args[2]
+	user, err := users.GetUserByEmail(email)
+	if err != nil {
+		log.Fatalf("Failed to get the user: %v", err)
+	}
+	if user == nil {
+		log.Fatalf("No user with the email %q", email)
+	}
+
+	if err := users.UpdateUserRole(user.ID, role); err != nil {
+		log.Fatalf("Failed to update the role: %v", err)
+	}
+	log.Printf("User %d (%s) now has the %q role", user.ID, user.Email, role)
+}
@@ -1,16 +1,29 @@
 package main
 
 import (
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/milanvthakor/task-manager-api/internal/admin"
 	"github.com/milanvthakor/task-manager-api/internal/auth"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
 	"github.com/milanvthakor/task-manager-api/internal/database"
+	"github.com/milanvthakor/task-manager-api/internal/export"
+	"github.com/milanvthakor/task-manager-api/internal/label"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
 	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/password"
+	"github.com/milanvthakor/task-manager-api/internal/project"
+	"github.com/milanvthakor/task-manager-api/internal/signing"
 	"github.com/milanvthakor/task-manager-api/internal/task"
 	"github.com/milanvthakor/task-manager-api/internal/utils"
+	"github.com/milanvthakor/task-manager-api/internal/workspace"
 	"github.com/milanvthakor/task-manager-api/pkg/api"
 	"github.com/milanvthakor/task-manager-api/pkg/config"
 )
@@ -24,35 +37,270 @@ func main() {
 	// Initialize the configuration.
 	cfg := config.New()
 
-	// Initialize the database.
-	db, err := database.Init(cfg.DatabaseDSN)
+	// Initialize the new instance of the Application struct containing dependencies
+	app := &config.Application{Config: cfg}
+
+	// Initialize the keys tokens are signed with.
+	if cfg.SigningKeys != "" {
+		keys, err := signing.LoadKeySet(cfg.SigningKeys)
+		if err != nil {
+			log.Fatalf("Failed to load the signing keys: %v", err)
+		}
+		app.Keys = keys
+	} else {
+		if cfg.SecretKey == "" || (cfg.SecretKey == config.DefaultSecretKey && !cfg.DevMode) {
+			log.Fatal("Refusing to sign tokens with the default SecretKey. Set SecretKey or SigningKeys, or DevMode=true for local development")
+		}
+		app.Keys = signing.NewHMACKeySet(cfg.SecretKey)
+	}
+
+	// Check what the users who haven't verified their email can do.
+	switch cfg.UnverifiedLogin {
+	case config.UnverifiedLoginAllow, config.UnverifiedLoginRestrict, config.UnverifiedLoginDeny:
+	default:
+		log.Fatalf("Unsupported UnverifiedLogin %q", cfg.UnverifiedLogin)
+	}
+
+	// Initialize the password policy, and the hasher passwords are hashed with.
+	if cfg.PasswordMinLength > cfg.PasswordMaxLength {
+		log.Fatal("PasswordMinLength can't be greater than PasswordMaxLength")
+	}
+	app.PasswordPolicy = password.Policy{
+		MinLength:        cfg.PasswordMinLength,
+		MaxLength:        cfg.PasswordMaxLength,
+		RequireUppercase: cfg.PasswordRequireUppercase,
+		RequireLowercase: cfg.PasswordRequireLowercase,
+		RequireDigit:     cfg.PasswordRequireDigit,
+		RequireSymbol:    cfg.PasswordRequireSymbol,
+		Denylist:         cfg.PasswordDenylist,
+	}
+	if cfg.PasswordHashAlgorithm == password.AlgorithmBcrypt {
+		// bcrypt refuses longer passwords, which must then be refused by the policy.
+		app.PasswordPolicy.MaxBytes = password.BcryptMaxLength
+		if app.PasswordPolicy.MaxLength > password.BcryptMaxLength {
+			app.PasswordPolicy.MaxLength = password.BcryptMaxLength
+		}
+	}
+	hasher, err := password.NewHasher(cfg.PasswordHashAlgorithm, cfg.BcryptCost, password.Argon2Params{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+	}, cfg.PasswordHashConcurrency)
 	if err != nil {
-		log.Fatalf("Failed to initialize the database: %v", err)
+		log.Fatalf("Failed to initialize the password hasher: %v", err)
 	}
-	defer database.Close()
+	app.PasswordHasher = hasher
 
-	// Initialize the new instance of the Application struct containing dependencies
-	app := &config.Application{
-		Config:         cfg,
-		UserRepository: models.NewUserRepository(db),
-		TaskRepository: models.NewTaskRepository(db),
+	// Initialize the mailer.
+	switch cfg.MailDriver {
+	case "log":
+		w := io.Writer(os.Stdout)
+		if cfg.MailLogFile != "" {
+			f, err := os.OpenFile(cfg.MailLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				log.Fatalf("Failed to open the mail log file: %v", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		app.Mailer = mail.NewLogMailer(w, cfg.MailFrom)
+
+	case "smtp":
+		app.Mailer = mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+
+	default:
+		log.Fatalf("Unsupported mail driver %q", cfg.MailDriver)
 	}
 
-	// Initialize the Gin router.
+	// Initialize the storage backend.
+	switch cfg.DatabaseDriver {
+	case database.DriverMemory:
+		log.Print("Warning: Using the in-memory storage, data will be lost on shutdown")
+
+		mdb := models.NewMemoryDB()
+		app.UserRepository = models.NewMemoryUserRepository(mdb)
+		app.TaskRepository = models.NewMemoryTaskRepository(mdb)
+		app.LabelRepository = models.NewMemoryLabelRepository(mdb)
+		app.ProjectRepository = models.NewMemoryProjectRepository(mdb)
+		app.TokenRepository = models.NewMemoryTokenRepository(mdb)
+		app.MFARepository = models.NewMemoryMFARepository(mdb)
+		app.AuditRepository = models.NewMemoryAuditRepository(mdb)
+		app.DataExportRepository = models.NewMemoryDataExportRepository(mdb)
+		app.WorkspaceRepository = models.NewMemoryWorkspaceRepository(mdb)
+
+	case database.DriverPostgres, database.DriverSQLite:
+		// Initialize the database.
+		db, err := database.Init(cfg.DatabaseDriver, cfg.DatabaseDSN)
+		if err != nil {
+			log.Fatalf("Failed to initialize the database: %v", err)
+		}
+		defer database.Close()
+		dialect := database.Dialect(cfg.DatabaseDriver)
+
+		// Apply pending schema migrations.
+		if cfg.AutoMigrate {
+			migrator, err := database.NewMigrator(db, dialect)
+			if err != nil {
+				log.Fatalf("Failed to load the migrations: %v", err)
+			}
+			applied, err := migrator.Up()
+			if err != nil {
+				log.Fatalf("Failed to migrate the database: %v", err)
+			}
+			for _, m := range applied {
+				log.Printf("Applied migration %d_%s", m.Version, m.Name)
+			}
+		}
+
+		app.UserRepository = models.NewUserRepository(db, dialect)
+		app.TaskRepository = models.NewTaskRepository(db, dialect)
+		app.LabelRepository = models.NewLabelRepository(db)
+		app.ProjectRepository = models.NewProjectRepository(db, dialect)
+		app.TokenRepository = models.NewTokenRepository(db, dialect)
+		app.MFARepository = models.NewMFARepository(db, dialect)
+		app.AuditRepository = models.NewAuditRepository(db, dialect)
+		app.DataExportRepository = models.NewDataExportRepository(db, dialect)
+		app.WorkspaceRepository = models.NewWorkspaceRepository(db, dialect)
+		if cfg.LoginThrottleStore == config.LoginThrottleStoreDatabase {
+			app.LoginThrottles = models.NewLoginThrottleRepository(db, dialect)
+		}
+
+	default:
+		log.Fatalf("Unsupported database driver %q", cfg.DatabaseDriver)
+	}
+
+	// Remove the expired data exports in the background.
+	go export.PurgeExpiredExports(app, cfg.DataExportPurgeInterval)
+
+	// Initialize the authorization of the tasks and workspaces.
+	app.Authorizer = authz.NewAuthorizer(app.WorkspaceRepository)
+
+	// Initialize the store of the failed login attempts.
+	switch cfg.LoginThrottleStore {
+	case config.LoginThrottleStoreMemory:
+		app.LoginThrottles = models.NewMemoryLoginThrottleRepository()
+
+	case config.LoginThrottleStoreDatabase:
+		if app.LoginThrottles == nil {
+			log.Fatalf("LoginThrottleStore %q requires the %q or %q database driver", cfg.LoginThrottleStore, database.DriverPostgres, database.DriverSQLite)
+		}
+
+	default:
+		log.Fatalf("Unsupported LoginThrottleStore %q", cfg.LoginThrottleStore)
+	}
+
+	// Initialize the Gin router. The client IP address is only taken from the X-Forwarded-For header
+	// set by trusted proxies.
 	r := gin.Default()
+	var trustedProxies []string
+	if cfg.TrustedProxies != "" {
+		for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+			trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TrustedProxies: %v", err)
+	}
 
 	// Set up API routes
 	apiRoutes := r.Group("/api")
 	apiRoutes.POST("/register", utils.InjectApp(app, auth.ValidateInputMiddleware), utils.InjectApp(app, auth.RegisterHandler))
 	apiRoutes.POST("/login", utils.InjectApp(app, auth.ValidateInputMiddleware), utils.InjectApp(app, auth.LoginHandler))
+	apiRoutes.POST("/login/mfa", utils.InjectApp(app, auth.LoginMFAHandler))
+	apiRoutes.POST("/token/refresh", utils.InjectApp(app, auth.RefreshTokenHandler))
+	apiRoutes.POST("/verify-email", utils.InjectApp(app, auth.VerifyEmailHandler))
+	apiRoutes.POST("/verify-email/resend", utils.InjectApp(app, auth.ResendVerificationEmailHandler))
+	apiRoutes.POST("/password/forgot", utils.InjectApp(app, auth.ForgotPasswordHandler))
+	apiRoutes.POST("/password/reset", utils.InjectApp(app, auth.ResetPasswordHandler))
+	apiRoutes.POST("/logout", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.LogoutHandler))
+	// Set up account API routes
+	apiRoutes.GET("/me", utils.InjectApp(app, auth.AuthenticateMiddleware), utils.InjectApp(app, auth.GetMeHandler))
+	apiRoutes.PATCH("/me", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.UpdateMeHandler))
+	apiRoutes.DELETE("/me", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.DeleteAccountHandler))
+	apiRoutes.POST("/me/password", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.ChangePasswordHandler))
+	apiRoutes.POST("/me/email", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.ChangeEmailHandler))
+	// Set up data export API routes
+	apiRoutes.POST("/me/export", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, export.CreateExportHandler))
+	apiRoutes.GET("/me/exports", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, export.GetExportsHandler))
+	apiRoutes.GET("/me/exports/:exportID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, export.ExtractExportIDMiddleware, utils.InjectApp(app, export.GetExportByIDHandler))
+	apiRoutes.GET("/me/exports/:exportID/download", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, export.ExtractExportIDMiddleware, utils.InjectApp(app, export.DownloadExportHandler))
+	// Set up Personal Access Token API routes
+	tokenApiRoutes := apiRoutes.Group("/tokens")
+	tokenApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.GetTokensHandler))
+	tokenApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.CreateTokenHandler))
+	tokenApiRoutes.DELETE("/:tokenID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, auth.ExtractTokenIDMiddleware, utils.InjectApp(app, auth.DeleteTokenByIDHandler))
+	// Set up two-factor authentication API routes
+	mfaApiRoutes := apiRoutes.Group("/mfa")
+	mfaApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.GetMFAHandler))
+	mfaApiRoutes.POST("/totp", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.EnrollTOTPHandler))
+	mfaApiRoutes.POST("/totp/confirm", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.ConfirmTOTPHandler))
+	mfaApiRoutes.DELETE("/totp", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.DisableTOTPHandler))
+	mfaApiRoutes.POST("/recovery-codes", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RegenerateRecoveryCodesHandler))
 	// Set up Task API routes
 	taskApiRoutes := apiRoutes.Group("/tasks")
-	taskApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), utils.InjectApp(app, task.GetTasksHandler))
-	taskApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), utils.InjectApp(app, task.CreateTaskHandler))
-	taskApiRoutes.GET("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetTaskByIDHandler))
-	taskApiRoutes.DELETE("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.DeleteTaskByIDHandler))
-	taskApiRoutes.PUT("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.UpdateTaskByIDHandler))
-	taskApiRoutes.PATCH("/mark-done", utils.InjectApp(app, auth.AuthenticateMiddleware), utils.InjectApp(app, task.MarkTasksDoneHandler))
+	taskApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), utils.InjectApp(app, task.GetTasksHandler))
+	taskApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), utils.InjectApp(app, task.CreateTaskHandler))
+	taskApiRoutes.GET("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetTaskByIDHandler))
+	taskApiRoutes.DELETE("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.DeleteTaskByIDHandler))
+	taskApiRoutes.PUT("/:id", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.UpdateTaskByIDHandler))
+	taskApiRoutes.GET("/due-today", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), utils.InjectApp(app, task.GetTasksDueTodayHandler))
+	taskApiRoutes.GET("/next", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), utils.InjectApp(app, task.GetNextTasksHandler))
+	taskApiRoutes.PATCH("/mark-done", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), utils.InjectApp(app, task.MarkTasksDoneHandler))
+	taskApiRoutes.GET("/:id/subtasks", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetSubtasksHandler))
+	taskApiRoutes.GET("/:id/tree", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetTaskTreeHandler))
+	taskApiRoutes.GET("/:id/dependencies", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetDependenciesHandler))
+	taskApiRoutes.POST("/:id/dependencies", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.AddDependencyHandler))
+	taskApiRoutes.DELETE("/:id/dependencies/:blockerID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.RemoveDependencyHandler))
+	taskApiRoutes.GET("/:id/critical-path", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetCriticalPathHandler))
+	taskApiRoutes.GET("/:id/occurrences", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.GetOccurrencesHandler))
+	taskApiRoutes.POST("/:id/skip", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.SkipOccurrenceHandler))
+	taskApiRoutes.DELETE("/:id/recurrence", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.EndRecurrenceHandler))
+	taskApiRoutes.PUT("/:id/assignee", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.AssignTaskHandler))
+	taskApiRoutes.DELETE("/:id/assignee", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, utils.InjectApp(app, task.UnassignTaskHandler))
+	taskApiRoutes.POST("/:id/labels/:labelID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, label.ExtractLabelIDMiddleware, utils.InjectApp(app, task.AttachLabelHandler))
+	taskApiRoutes.DELETE("/:id/labels/:labelID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksWrite), task.ExtractTaskIDMiddleware, label.ExtractLabelIDMiddleware, utils.InjectApp(app, task.DetachLabelHandler))
+	// Set up Label API routes
+	labelApiRoutes := apiRoutes.Group("/labels")
+	labelApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeLabelsRead), utils.InjectApp(app, label.GetLabelsHandler))
+	labelApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeLabelsWrite), utils.InjectApp(app, label.CreateLabelHandler))
+	labelApiRoutes.GET("/:labelID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeLabelsRead), label.ExtractLabelIDMiddleware, utils.InjectApp(app, label.GetLabelByIDHandler))
+	labelApiRoutes.PUT("/:labelID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeLabelsWrite), label.ExtractLabelIDMiddleware, utils.InjectApp(app, label.UpdateLabelByIDHandler))
+	labelApiRoutes.DELETE("/:labelID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeLabelsWrite), label.ExtractLabelIDMiddleware, utils.InjectApp(app, label.DeleteLabelByIDHandler))
+	// Set up Project API routes
+	projectApiRoutes := apiRoutes.Group("/projects")
+	projectApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeProjectsRead), utils.InjectApp(app, project.GetProjectsHandler))
+	projectApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeProjectsWrite), utils.InjectApp(app, project.CreateProjectHandler))
+	projectApiRoutes.GET("/:projectID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeProjectsRead), project.ExtractProjectIDMiddleware, utils.InjectApp(app, project.GetProjectByIDHandler))
+	projectApiRoutes.PUT("/:projectID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeProjectsWrite), project.ExtractProjectIDMiddleware, utils.InjectApp(app, project.UpdateProjectByIDHandler))
+	projectApiRoutes.DELETE("/:projectID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeProjectsWrite), project.ExtractProjectIDMiddleware, utils.InjectApp(app, project.DeleteProjectByIDHandler))
+	projectApiRoutes.GET("/:projectID/tasks", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeTasksRead), project.ExtractProjectIDMiddleware, utils.InjectApp(app, task.GetProjectTasksHandler))
+	// Set up Workspace API routes
+	workspaceApiRoutes := apiRoutes.Group("/workspaces")
+	workspaceApiRoutes.GET("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesRead), utils.InjectApp(app, workspace.GetWorkspacesHandler))
+	workspaceApiRoutes.POST("/", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), utils.InjectApp(app, workspace.CreateWorkspaceHandler))
+	workspaceApiRoutes.POST("/invites/accept", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), utils.InjectApp(app, workspace.AcceptInviteHandler))
+	workspaceApiRoutes.GET("/:workspaceID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesRead), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.GetWorkspaceByIDHandler))
+	workspaceApiRoutes.PUT("/:workspaceID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.UpdateWorkspaceByIDHandler))
+	workspaceApiRoutes.DELETE("/:workspaceID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.DeleteWorkspaceByIDHandler))
+	workspaceApiRoutes.GET("/:workspaceID/members", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesRead), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.GetMembersHandler))
+	workspaceApiRoutes.PUT("/:workspaceID/members/:userID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, workspace.ExtractMemberIDMiddleware, utils.InjectApp(app, workspace.UpdateMemberRoleHandler))
+	workspaceApiRoutes.DELETE("/:workspaceID/members/:userID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, workspace.ExtractMemberIDMiddleware, utils.InjectApp(app, workspace.RemoveMemberHandler))
+	workspaceApiRoutes.GET("/:workspaceID/invites", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesRead), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.GetInvitesHandler))
+	workspaceApiRoutes.POST("/:workspaceID/invites", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, utils.InjectApp(app, workspace.CreateInviteHandler))
+	workspaceApiRoutes.DELETE("/:workspaceID/invites/:inviteID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireScope(auth.ScopeWorkspacesWrite), workspace.ExtractWorkspaceIDMiddleware, workspace.ExtractInviteIDMiddleware, utils.InjectApp(app, workspace.DeleteInviteHandler))
+
+	// Set up admin API routes
+	adminApiRoutes := apiRoutes.Group("/admin")
+	adminApiRoutes.GET("/users", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), utils.InjectApp(app, admin.GetUsersHandler))
+	adminApiRoutes.GET("/users/:userID", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.GetUserByIDHandler))
+	adminApiRoutes.PUT("/users/:userID/role", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.UpdateUserRoleHandler))
+	adminApiRoutes.POST("/users/:userID/disable", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.DisableUserHandler))
+	adminApiRoutes.POST("/users/:userID/enable", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.EnableUserHandler))
+	adminApiRoutes.POST("/users/:userID/password-reset", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.ForcePasswordResetHandler))
+	adminApiRoutes.POST("/users/:userID/revoke-sessions", utils.InjectApp(app, auth.AuthenticateMiddleware), auth.RequireSessionMiddleware, utils.InjectApp(app, auth.RequireRoleMiddleware(models.UserRoleAdmin)), admin.ExtractUserIDMiddleware, utils.InjectApp(app, admin.RevokeUserSessionsHandler))
+
+	// Public keys to verify the access tokens with.
+	r.GET("/.well-known/jwks.json", utils.InjectApp(app, auth.JWKSHandler))
 
 	// Simple health check endpoint.
 	r.GET("/health", func(c *gin.Context) {
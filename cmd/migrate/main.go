@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/milanvthakor/task-manager-api/internal/database"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up      Apply all pending migrations
+  down    Roll back the most recently applied migration
+  status  List migrations and whether they have been applied
+  redo    Roll back and re-apply the most recently applied migration
+`
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables from the .env file
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Couldn't load .env file %v\n", err)
+	}
+
+	// Initialize the configuration.
+	cfg := config.New()
+	if cfg.DatabaseDriver == database.DriverMemory {
+		log.Fatalf("The %q database driver doesn't support migrations", cfg.DatabaseDriver)
+	}
+
+	// Initialize the database.
+	db, err := database.Init(cfg.DatabaseDriver, cfg.DatabaseDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize the database: %v", err)
+	}
+	defer database.Close()
+
+	migrator, err := database.NewMigrator(db, database.Dialect(cfg.DatabaseDriver))
+	if err != nil {
+		log.Fatalf("Failed to load the migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Print("No pending migrations")
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+
+	case "down":
+		m, err := migrator.Down()
+		if err != nil {
+			log.Fatalf("Failed to roll back migration: %v", err)
+		}
+		log.Printf("Rolled back migration %d_%s", m.Version, m.Name)
+
+	case "redo":
+		m, err := migrator.Redo()
+		if err != nil {
+			log.Fatalf("Failed to redo migration: %v", err)
+		}
+		log.Printf("Redid migration %d_%s", m.Version, m.Name)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
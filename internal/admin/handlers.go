@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/auth"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// invalidRoleMessage is the error returned for an unknown user role.
+const invalidRoleMessage = `Invalid role. It can be either "user" or "admin"`
+
+// userResponse is a user as seen by the admins, along with the number of tasks they have.
+type userResponse struct {
+	models.User
+	TwoFactorEnabled bool              `json:"two_factor_enabled"`
+	TaskCounts       models.TaskCounts `json:"task_counts"`
+}
+
+// userPageResponse is a page of users as seen by the admins, along with the cursor of the next one.
+type userPageResponse struct {
+	Users      []userResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// roleData holds the role to give a user.
+type roleData struct {
+	Role models.UserRole `json:"role"`
+}
+
+// GetUsersHandler handles retrieval of a page of the users, optionally filtered by email or display
+// name, role and status, along with the number of tasks of each.
+func GetUsersHandler(ctx *gin.Context, app *config.Application) {
+	opts, err := parseUserListOptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := app.UserRepository.ListUsers(*opts)
+	if err == models.ErrInvalidCursor {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve users: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	userIDs := make([]uint, 0, len(page.Users))
+	for _, user := range page.Users {
+		userIDs = append(userIDs, user.ID)
+	}
+	counts, err := app.TaskRepository.CountTasksByUserIDs(userIDs)
+	if err != nil {
+		log.Printf("Warning: Failed to count tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	response := userPageResponse{Users: make([]userResponse, 0, len(page.Users)), NextCursor: page.NextCursor}
+	for _, user := range page.Users {
+		response.Users = append(response.Users, newUserResponse(user, counts[user.ID]))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetUserByIDHandler handles retrieval of a user by ID, along with the number of tasks they have.
+func GetUserByIDHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	respondWithUser(ctx, app, http.StatusOK, user)
+}
+
+// UpdateUserRoleHandler handles the change of the role of a user. Admins can't change their own role, so
+// that there's always an admin left.
+func UpdateUserRoleHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var rd roleData
+	if err := ctx.ShouldBindJSON(&rd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !validator.IsValidUserRole(rd.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidRoleMessage})
+		return
+	}
+	if user.ID == ctx.MustGet("userID").(uint) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own role"})
+		return
+	}
+
+	if rd.Role != user.Role {
+		if err := app.UserRepository.UpdateUserRole(user.ID, rd.Role); err != nil {
+			log.Printf("Warning: Failed to update the role: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the role"})
+			return
+		}
+		recordAdminAction(ctx, app, user.ID, models.AuditEventRoleChanged, fmt.Sprintf("Role changed from %s to %s", user.Role, rd.Role))
+		user.Role = rd.Role
+	}
+
+	respondWithUser(ctx, app, http.StatusOK, user)
+}
+
+// DisableUserHandler handles the disabling of the account of a user, which can't log in anymore and
+// whose sessions and personal access tokens are rejected. Admins can't disable their own account.
+func DisableUserHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+	if user.ID == ctx.MustGet("userID").(uint) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You can't disable your own account"})
+		return
+	}
+
+	if user.DisabledAt == nil {
+		// Disabling the account revokes the access tokens issued until now.
+		if err := app.UserRepository.SetUserDisabled(user.ID, true); err != nil {
+			log.Printf("Warning: Failed to disable user: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable the account"})
+			return
+		}
+		if err := app.TokenRepository.RevokeUserRefreshTokens(user.ID); err != nil {
+			log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+		}
+		recordAdminAction(ctx, app, user.ID, models.AuditEventAccountDisabled, "Account disabled")
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+
+	respondWithUser(ctx, app, http.StatusOK, user)
+}
+
+// EnableUserHandler handles the enabling of the account of a user who was disabled. Their previous
+// sessions stay revoked.
+func EnableUserHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	if user.DisabledAt != nil {
+		if err := app.UserRepository.SetUserDisabled(user.ID, false); err != nil {
+			log.Printf("Warning: Failed to enable user: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable the account"})
+			return
+		}
+		recordAdminAction(ctx, app, user.ID, models.AuditEventAccountEnabled, "Account enabled")
+		user.DisabledAt = nil
+	}
+
+	respondWithUser(ctx, app, http.StatusOK, user)
+}
+
+// ForcePasswordResetHandler handles the reset of the password of a user, e.g. when it may have leaked.
+// Their password stops working, every session is revoked, and they're sent a link to choose a new one.
+func ForcePasswordResetHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	if err := auth.ForcePasswordReset(app, user); err != nil {
+		log.Printf("Warning: Failed to force the password reset of user %d: %v", user.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset the password"})
+		return
+	}
+	recordAdminAction(ctx, app, user.ID, models.AuditEventPasswordResetForced, "Password reset forced")
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "The password has been reset, and a link to choose a new one has been sent to the user"})
+}
+
+// RevokeUserSessionsHandler handles the revocation of every session of a user, who must log in again.
+// Their personal access tokens are kept.
+func RevokeUserSessionsHandler(ctx *gin.Context, app *config.Application) {
+	user := getTargetUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	if err := auth.RevokeSessions(app, user.ID); err != nil {
+		log.Printf("Warning: Failed to revoke the sessions of user %d: %v", user.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke the sessions"})
+		return
+	}
+	recordAdminAction(ctx, app, user.ID, models.AuditEventSessionsRevoked, "Sessions revoked")
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Sessions revoked successfully"})
+}
+
+// getTargetUser retrieves the user the request is about. If the user doesn't exist, it writes the error
+// response and returns nil.
+func getTargetUser(ctx *gin.Context, app *config.Application) *models.User {
+	userID := ctx.MustGet("targetUserID").(uint)
+
+	user, err := app.UserRepository.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the user"})
+		return nil
+	}
+	if user == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+
+	return user
+}
+
+// respondWithUser responds with a user along with the number of tasks they have.
+func respondWithUser(ctx *gin.Context, app *config.Application, status int, user *models.User) {
+	counts, err := app.TaskRepository.CountTasksByUserIDs([]uint{user.ID})
+	if err != nil {
+		log.Printf("Warning: Failed to count tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the user"})
+		return
+	}
+
+	ctx.JSON(status, newUserResponse(*user, counts[user.ID]))
+}
+
+// newUserResponse builds the response of a user along with the number of tasks they have.
+func newUserResponse(user models.User, counts models.TaskCounts) userResponse {
+	return userResponse{User: user, TwoFactorEnabled: user.TOTPEnabledAt != nil, TaskCounts: counts}
+}
+
+// recordAdminAction records an action of the authenticated admin on a user in the audit log, so that it
+// shows among the security events of the user. The IP address of the admin isn't recorded, as the user
+// can export their security events.
+func recordAdminAction(ctx *gin.Context, app *config.Application, userID uint, event, details string) {
+	entry := &models.AuditEntry{
+		UserID:  &userID,
+		Event:   event,
+		Details: fmt.Sprintf("%s by admin %d", details, ctx.MustGet("userID").(uint)),
+	}
+	if err := app.AuditRepository.CreateAuditEntry(entry); err != nil {
+		log.Printf("Warning: Failed to record admin action in the audit log: %v", err)
+	}
+}
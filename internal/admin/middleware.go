@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractUserIDMiddleware extract the user ID from URL parameters.
+func ExtractUserIDMiddleware(ctx *gin.Context) {
+	userIDStr := ctx.Param("userID")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Store the user ID in the context. "userID" already holds the ID of the authenticated admin.
+	ctx.Set("targetUserID", uint(userID))
+	ctx.Next()
+}
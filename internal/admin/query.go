@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+)
+
+const (
+	// defaultUserListLimit is the number of users returned per page when no limit is given.
+	defaultUserListLimit = 50
+	// maxUserListLimit is the maximum number of users that can be requested per page.
+	maxUserListLimit = 100
+)
+
+// parseUserListOptions parses the filtering and pagination options from the query string.
+func parseUserListOptions(ctx *gin.Context) (*models.UserListOptions, error) {
+	opts := &models.UserListOptions{
+		Query:  ctx.Query("q"),
+		Role:   models.UserRole(ctx.Query("role")),
+		Limit:  defaultUserListLimit,
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxUserListLimit {
+			return nil, errors.New("Invalid limit. It must be between 1 and " + strconv.Itoa(maxUserListLimit))
+		}
+		opts.Limit = limit
+	}
+	if opts.Role != "" && !validator.IsValidUserRole(opts.Role) {
+		return nil, errors.New(invalidRoleMessage)
+	}
+	switch ctx.Query("status") {
+	case "":
+	case "active":
+		disabled := false
+		opts.Disabled = &disabled
+	case "disabled":
+		disabled := true
+		opts.Disabled = &disabled
+	default:
+		return nil, errors.New(`Invalid status. It can be either "active" or "disabled"`)
+	}
+
+	return opts, nil
+}
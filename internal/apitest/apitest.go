@@ -0,0 +1,149 @@
+// Package apitest serves API routes from an in-memory datastore, so that handlers can be tested
+// without a database.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/password"
+	"github.com/milanvthakor/task-manager-api/internal/signing"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserHeader is the header Authenticate reads the ID of the authenticated user from.
+const UserHeader = "X-Test-User-ID"
+
+// Server serves the routes registered on Router, whose handlers use the dependencies in App.
+type Server struct {
+	t      *testing.T
+	App    *config.Application
+	Router *gin.Engine
+}
+
+// NewServer creates a test server without routes, with an application backed by an in-memory datastore.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// The cheapest cost keeps the tests fast.
+	hasher, err := password.NewHasher(password.AlgorithmBcrypt, bcrypt.MinCost, password.Argon2Params{}, 4)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+	mdb := models.NewMemoryDB()
+	app := &config.Application{
+		Config: &config.Config{
+			SecretKey:             "test secret",
+			AccessTokenTTL:        15 * time.Minute,
+			RefreshTokenTTL:       time.Hour,
+			UnverifiedLogin:       config.UnverifiedLoginAllow,
+			LoginMaxFailures:      5,
+			LoginMaxFailuresPerIP: 20,
+			RegisterMaxPerIP:      10,
+			LoginFailureWindow:    time.Hour,
+			LoginLockout:          time.Minute,
+			LoginMaxLockout:       time.Hour,
+			MFAIssuer:             "Task Manager",
+			MFATokenTTL:           5 * time.Minute,
+		},
+		Keys:                 signing.NewHMACKeySet("test secret"),
+		UserRepository:       models.NewMemoryUserRepository(mdb),
+		TaskRepository:       models.NewMemoryTaskRepository(mdb),
+		LabelRepository:      models.NewMemoryLabelRepository(mdb),
+		ProjectRepository:    models.NewMemoryProjectRepository(mdb),
+		TokenRepository:      models.NewMemoryTokenRepository(mdb),
+		MFARepository:        models.NewMemoryMFARepository(mdb),
+		AuditRepository:      models.NewMemoryAuditRepository(mdb),
+		LoginThrottles:       models.NewMemoryLoginThrottleRepository(),
+		DataExportRepository: models.NewMemoryDataExportRepository(mdb),
+		WorkspaceRepository:  models.NewMemoryWorkspaceRepository(mdb),
+		Mailer:               mail.NewLogMailer(io.Discard, "noreply@example.com"),
+		PasswordPolicy:       password.Policy{MinLength: 8, MaxLength: password.BcryptMaxLength, MaxBytes: password.BcryptMaxLength},
+		PasswordHasher:       hasher,
+	}
+	app.Authorizer = authz.NewAuthorizer(app.WorkspaceRepository)
+
+	return &Server{t: t, App: app, Router: gin.New()}
+}
+
+// Authenticate authenticates requests as the user whose ID is in UserHeader, in place of
+// auth.AuthenticateMiddleware.
+func Authenticate(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.GetHeader(UserHeader), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	ctx.Set("userID", uint(userID))
+	ctx.Next()
+}
+
+// AsUser returns the header authenticating a request as a user with Authenticate.
+func AsUser(userID uint) http.Header {
+	return http.Header{UserHeader: {strconv.FormatUint(uint64(userID), 10)}}
+}
+
+// Bearer returns the header authenticating a request with an access token.
+func Bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+// NewUser creates a user and returns its ID.
+func (s *Server) NewUser(email string) uint {
+	s.t.Helper()
+
+	if err := s.App.UserRepository.CreateUser(&models.User{Email: email}, "hash"); err != nil {
+		s.t.Fatalf("CreateUser(%q): %v", email, err)
+	}
+	user, err := s.App.UserRepository.GetUserByEmail(email)
+	if err != nil || user == nil {
+		s.t.Fatalf("GetUserByEmail(%q): got %v, %v", email, user, err)
+	}
+
+	return user.ID
+}
+
+// Do sends a request with the header, and the body encoded in JSON unless it's nil, and decodes the
+// response into out unless it's nil. It returns the status code.
+func (s *Server) Do(method, path string, header http.Header, body, out interface{}) int {
+	s.t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("json.Marshal: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: failed to decode %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+
+	return w.Code
+}
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// profileData holds the profile settings to update. The settings left out are kept.
+type profileData struct {
+	DisplayName *string `json:"display_name"`
+	Timezone    *string `json:"timezone"`
+	Locale      *string `json:"locale"`
+}
+
+// changePasswordData holds the current password of the user along with the new one.
+type changePasswordData struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// changeEmailData holds the new email of the user along with their password.
+type changeEmailData struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// deleteAccountData holds the password of the user, along with a code when two-factor authentication is
+// enabled.
+type deleteAccountData struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// GetMeHandler handles retrieval of the profile of the authenticated user.
+func GetMeHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// UpdateMeHandler handles the update of the profile settings of the authenticated user.
+func UpdateMeHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var pd profileData
+	if err := ctx.ShouldBindJSON(&pd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if pd.DisplayName != nil {
+		name := strings.TrimSpace(*pd.DisplayName)
+		if !validator.IsValidDisplayName(name) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid display name. It must be at most 100 characters long, without line breaks or other control characters"})
+			return
+		}
+		user.DisplayName = name
+	}
+	if pd.Timezone != nil {
+		if !validator.IsValidTimezone(*pd.Timezone) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone. It must be an IANA timezone name, e.g. Europe/Berlin"})
+			return
+		}
+		user.Timezone = *pd.Timezone
+	}
+	if pd.Locale != nil {
+		if !validator.IsValidLocale(*pd.Locale) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid locale. It must be a language tag, e.g. en or pt-BR"})
+			return
+		}
+		user.Locale = *pd.Locale
+	}
+
+	if err := app.UserRepository.UpdateUserProfile(user); err != nil {
+		log.Printf("Warning: Failed to update the profile: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the profile"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// ChangePasswordHandler handles the change of the password of the authenticated user, which requires the
+// current password. Every session of the user is revoked, like after a password reset.
+func ChangePasswordHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var pd changePasswordData
+	if err := ctx.ShouldBindJSON(&pd); err != nil || pd.CurrentPassword == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if err := app.PasswordPolicy.Validate(pd.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !verifyCurrentPassword(ctx, app, user, pd.CurrentPassword) {
+		return
+	}
+
+	// Hash password.
+	hash, err := app.PasswordHasher.Hash(pd.NewPassword)
+	if err != nil {
+		log.Printf("Warning: Failed to generate hash from the password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the password"})
+		return
+	}
+
+	// Update the password, which revokes the access tokens issued until now.
+	if err := app.UserRepository.UpdateUserPassword(user.ID, hash); err != nil {
+		log.Printf("Warning: Failed to update the password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change the password"})
+		return
+	}
+
+	// Revoke the refresh tokens so that the sessions can't be renewed, and the reset links.
+	if err := app.TokenRepository.RevokeUserRefreshTokens(user.ID); err != nil {
+		log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+	}
+	if err := app.TokenRepository.DeleteUserPasswordResetTokens(user.ID); err != nil {
+		log.Printf("Warning: Failed to delete password reset tokens: %v", err)
+	}
+
+	msg := passwordChangedMessage(user.Email)
+	go func() {
+		if err := app.Mailer.Send(msg); err != nil {
+			log.Printf("Warning: Failed to send password change email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password changed successfully. Please log in again"})
+}
+
+// ChangeEmailHandler handles the change of the email of the authenticated user, which requires the
+// password. A verification link is sent to the new email, which only replaces the current one once
+// verified.
+func ChangeEmailHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var ed changeEmailData
+	if err := ctx.ShouldBindJSON(&ed); err != nil || ed.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !validator.IsValidEmail(ed.Email) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+	if ed.Email == user.Email {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "The new email is the same as the current one"})
+		return
+	}
+	if !verifyCurrentPassword(ctx, app, user, ed.Password) {
+		return
+	}
+
+	// Check if the new email is already taken. It's checked again once verified.
+	existing, err := app.UserRepository.GetUserByEmail(ed.Email)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+		return
+	}
+	if existing != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		return
+	}
+
+	// Throttle the emails sent to the user, like the resent verification links.
+	latest, err := app.TokenRepository.GetLatestEmailVerificationToken(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get email verification token from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the verification link"})
+		return
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < app.Config.EmailVerificationResendInterval {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "A verification link was just sent. Please try again later"})
+		return
+	}
+
+	if err := sendVerificationEmail(app, user.ID, ed.Email); err != nil {
+		log.Printf("Warning: Failed to send the verification link: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the verification link"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "A link to verify the new email has been sent to it. The email will change once verified"})
+}
+
+// DeleteAccountHandler handles the deletion of the account of the authenticated user, along with their
+// tasks, labels, projects, tokens and the workspaces they own. It requires the password, and a code when
+// two-factor authentication is enabled. The tasks they created in the workspaces of others are handed
+// over to the owners.
+func DeleteAccountHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var dd deleteAccountData
+	if err := ctx.ShouldBindJSON(&dd); err != nil || dd.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !verifyCurrentPassword(ctx, app, user, dd.Password) {
+		return
+	}
+	if user.TOTPEnabledAt != nil && !verifySecondFactor(ctx, app, user, dd.Code) {
+		return
+	}
+	if !checkOwnedWorkspaces(ctx, app, user.ID) {
+		return
+	}
+
+	if err := app.UserRepository.DeleteUser(user.ID); err != nil {
+		log.Printf("Warning: Failed to delete user: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the account"})
+		return
+	}
+	resetLoginThrottle(app, user.Email)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// checkOwnedWorkspaces checks that the workspaces the user owns have no other members, as they would be
+// deleted along with the account. If one has, or they can't be checked, it writes the error response and
+// returns false.
+func checkOwnedWorkspaces(ctx *gin.Context, app *config.Application, userID uint) bool {
+	memberships, err := app.WorkspaceRepository.ListWorkspaceMemberships(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve workspaces: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the account"})
+		return false
+	}
+
+	for _, membership := range memberships {
+		if membership.Role != models.WorkspaceRoleOwner {
+			continue
+		}
+
+		members, err := app.WorkspaceRepository.ListWorkspaceMembers(membership.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to retrieve workspace members: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the account"})
+			return false
+		}
+		if len(members) > 1 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "You own the " + strconv.Quote(membership.Name) + " workspace, which has other members. Transfer its ownership or delete it first"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// verifyCurrentPassword checks the password of the authenticated user before a sensitive change. Wrong
+// passwords count as failed login attempts, so that a stolen session can't be used to guess it. If the
+// password is wrong, it writes the error response and returns false.
+func verifyCurrentPassword(ctx *gin.Context, app *config.Application, user *models.User, password string) bool {
+	if !checkLoginThrottle(ctx, app, user.Email) {
+		return false
+	}
+
+	match, err := app.UserRepository.VerifyUserPassword(user.ID, password, app.PasswordHasher)
+	if err != nil {
+		log.Printf("Warning: Failed to verify the password of user %d: %v", user.ID, err)
+	}
+	if !match {
+		recordLoginFailure(ctx, app, user.Email, user)
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Wrong password"})
+		return false
+	}
+
+	return true
+}
+
+// passwordChangedMessage builds the email notifying a user that their password was changed.
+func passwordChangedMessage(email string) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "Your password was changed",
+		Body: "The password of your Task Manager account was just changed, and every session was logged out.\n\n" +
+			"If it wasn't you, reset your password right away with the \"Forgot password\" link of the login page.",
+	}
+}
+
+// emailChangedMessage builds the email notifying a user, at their previous email, that their email was
+// changed.
+func emailChangedMessage(previousEmail, newEmail string) mail.Message {
+	return mail.Message{
+		To:      previousEmail,
+		Subject: "Your email was changed",
+		Body: "The email of your Task Manager account was just changed to " + newEmail + ".\n\n" +
+			"If it wasn't you, please contact us right away.",
+	}
+}
@@ -3,11 +3,12 @@ package auth
 import (
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
 	"github.com/milanvthakor/task-manager-api/internal/models"
 	"github.com/milanvthakor/task-manager-api/pkg/config"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // userData holds the authentication details of the user.
@@ -19,8 +20,26 @@ type userData struct {
 // RegisterHandler handles user registration.
 func RegisterHandler(ctx *gin.Context, app *config.Application) {
 	userData := ctx.MustGet("userData").(userData)
+	if err := app.PasswordPolicy.Validate(userData.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Check if the user with the email already exists in the database.
+	if !checkRegisterThrottle(ctx, app) {
+		return
+	}
+
+	// Hash password, even for a registered email, so that the response time doesn't tell which emails
+	// are registered.
+	hash, err := app.PasswordHasher.Hash(userData.Password)
+	if err != nil {
+		log.Printf("Warning: Failed to generate hash from the password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the password"})
+		return
+	}
+
+	// Check if the user with the email already exists in the database. If so, the response is the same,
+	// and the user is told by email instead.
 	user, err := app.UserRepository.GetUserByEmail(userData.Email)
 	if err != nil {
 		log.Printf("Warning: Failed to get user details from the database: %v", err)
@@ -28,36 +47,70 @@ func RegisterHandler(ctx *gin.Context, app *config.Application) {
 		return
 	}
 	if user != nil {
-		ctx.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-		return
-	}
+		msg := accountExistsMessage(user.Email)
+		go func() {
+			if err := app.Mailer.Send(msg); err != nil {
+				log.Printf("Warning: Failed to send account exists email to user %d: %v", user.ID, err)
+			}
+		}()
 
-	// Hash password.
-	hash, err := bcrypt.GenerateFromPassword([]byte(userData.Password), bcrypt.DefaultCost)
-	if err != nil {
-		log.Printf("Warning: Failed to generate hash from the password: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the password"})
+		ctx.JSON(http.StatusCreated, gin.H{"message": registeredMessage})
 		return
 	}
 
 	// Store user details in the database.
-	user = &models.User{
-		Email:    userData.Email,
-		Password: string(hash),
-	}
-	if err := app.UserRepository.CreateUser(user); err != nil {
+	user = &models.User{Email: userData.Email}
+	if err := app.UserRepository.CreateUser(user, hash); err != nil {
 		log.Printf("Warning: Failed to register user: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+	// Create the Inbox project of the user. Should it fail, the Inbox is created on first use instead.
+	if _, err := models.InboxProject(app.ProjectRepository, user.ID); err != nil {
+		log.Printf("Warning: Failed to create the Inbox project: %v", err)
+	}
+
+	// Send the link to verify the email. Should it fail, the user can ask for a new one.
+	if err := sendVerificationEmail(app, user.ID, user.Email); err != nil {
+		log.Printf("Warning: Failed to send the verification link: %v", err)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": registeredMessage})
+}
+
+// registeredMessage answers registrations, whether the email was already registered or not.
+const registeredMessage = "User registered successfully. Please check your email to verify it"
+
+// accountExistsMessage builds the email telling a user that someone tried to register with their
+// email.
+func accountExistsMessage(email string) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "You already have an account",
+		Body: "Someone tried to create a Task Manager account with this email, which already has one.\n\n" +
+			"If it was you, log in instead, or reset your password with the \"Forgot password\" link of the login page.\n\n" +
+			"If it wasn't you, you can ignore this email.",
+	}
 }
 
-// LoginHandler handles user login.
+// dummyPasswordHash is compared with the password when logging in with an unknown email, so that the
+// response time doesn't tell which emails are registered. It's hashed on first use with the configured
+// hasher.
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// LoginHandler handles user login. The failed attempts are tracked per account and per IP address,
+// which get locked after too many of them.
 func LoginHandler(ctx *gin.Context, app *config.Application) {
 	userData := ctx.MustGet("userData").(userData)
 
+	if !checkLoginThrottle(ctx, app, userData.Email) {
+		return
+	}
+
 	// Check if the user with the email already exists in the database.
 	user, err := app.UserRepository.GetUserByEmail(userData.Email)
 	if err != nil {
@@ -65,24 +118,58 @@ func LoginHandler(ctx *gin.Context, app *config.Application) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
 		return
 	}
-	if user == nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Email doesn't exists"})
+
+	// Compare hashed password with the input password. It's hashed again if it was hashed with another
+	// algorithm or other parameters, which is tried again on the next login should it fail.
+	var match bool
+	if user != nil {
+		if match, err = app.UserRepository.VerifyUserPassword(user.ID, userData.Password, app.PasswordHasher); err != nil {
+			log.Printf("Warning: Failed to verify the password of user %d: %v", user.ID, err)
+		}
+	} else {
+		dummyPasswordHashOnce.Do(func() {
+			var err error
+			if dummyPasswordHash, err = app.PasswordHasher.Hash("dummy password"); err != nil {
+				log.Printf("Warning: Failed to generate hash from the password: %v", err)
+			}
+		})
+		app.PasswordHasher.Verify(dummyPasswordHash, userData.Password)
+	}
+	if !match || user == nil {
+		recordLoginFailure(ctx, app, userData.Email, user)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": invalidCredentialsMessage})
+		return
+	}
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": accountDisabledMessage})
 		return
 	}
 
-	// Compare hashed password with the input password.
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userData.Password)); err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Wrong Password"})
+	// The users with two-factor authentication must send a code too.
+	if user.TOTPEnabledAt != nil {
+		startMFAChallenge(ctx, app, user)
 		return
 	}
 
-	// Generate a JWT token.
-	token, err := generateToken(app.Config.SecretKey, user.Email, user.ID)
+	startSession(ctx, app, user)
+}
+
+// startSession responds with the tokens of a new session of a user who just logged in, which forgets
+// the failed login attempts of their account.
+func startSession(ctx *gin.Context, app *config.Application, user *models.User) {
+	resetLoginThrottle(app, user.Email)
+
+	familyID, err := RandomToken(16)
 	if err != nil {
 		log.Printf("Warning: Failed to generate token: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	response := issueTokens(ctx, app, user, familyID)
+	if response == nil {
+		return
+	}
+	response["message"] = "Login successful"
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Login successful", "token": token})
+	ctx.JSON(http.StatusOK, response)
 }
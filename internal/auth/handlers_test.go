@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/apitest"
+	"github.com/milanvthakor/task-manager-api/internal/totp"
+	"github.com/milanvthakor/task-manager-api/internal/utils"
+)
+
+// testPassword is the password of the users registered by the tests.
+const testPassword = "tr0ub4dor&3"
+
+// newTestServer creates a test server with the authentication routes.
+func newTestServer(t *testing.T) *apitest.Server {
+	t.Helper()
+
+	s := apitest.NewServer(t)
+	app := s.App
+	api := s.Router.Group("/api")
+	api.POST("/register", utils.InjectApp(app, ValidateInputMiddleware), utils.InjectApp(app, RegisterHandler))
+	api.POST("/login", utils.InjectApp(app, ValidateInputMiddleware), utils.InjectApp(app, LoginHandler))
+	api.POST("/login/mfa", utils.InjectApp(app, LoginMFAHandler))
+	api.POST("/token/refresh", utils.InjectApp(app, RefreshTokenHandler))
+	api.POST("/mfa/totp", utils.InjectApp(app, AuthenticateMiddleware), RequireSessionMiddleware, utils.InjectApp(app, EnrollTOTPHandler))
+	api.POST("/mfa/totp/confirm", utils.InjectApp(app, AuthenticateMiddleware), RequireSessionMiddleware, utils.InjectApp(app, ConfirmTOTPHandler))
+
+	return s
+}
+
+// loginResponse holds the fields of the responses of the login routes.
+type loginResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token"`
+	Error        string `json:"error"`
+}
+
+// register registers a user with testPassword through the API.
+func register(t *testing.T, s *apitest.Server, email string) {
+	t.Helper()
+
+	var res gin.H
+	if code := s.Do(http.MethodPost, "/api/register", nil, gin.H{"email": email, "password": testPassword}, &res); code != http.StatusCreated {
+		t.Fatalf("registering %q: got status %d, %v", email, code, res)
+	}
+}
+
+// login logs a user in with testPassword through the API.
+func login(t *testing.T, s *apitest.Server, email string) loginResponse {
+	t.Helper()
+
+	var res loginResponse
+	if code := s.Do(http.MethodPost, "/api/login", nil, gin.H{"email": email, "password": testPassword}, &res); code != http.StatusOK {
+		t.Fatalf("logging in %q: got status %d, %q", email, code, res.Error)
+	}
+
+	return res
+}
+
+func TestRegisterExistingEmail(t *testing.T) {
+	s := newTestServer(t)
+
+	// Registering an email twice is answered the same, so that it doesn't tell which emails are registered.
+	var first, second gin.H
+	firstCode := s.Do(http.MethodPost, "/api/register", nil, gin.H{"email": "alice@example.com", "password": testPassword}, &first)
+	secondCode := s.Do(http.MethodPost, "/api/register", nil, gin.H{"email": "alice@example.com", "password": "other password"}, &second)
+	if firstCode != http.StatusCreated || secondCode != firstCode || second["message"] != first["message"] {
+		t.Errorf("got %d %v, then %d %v, want the same response", firstCode, first, secondCode, second)
+	}
+
+	// The password of the account isn't replaced.
+	login(t, s, "alice@example.com")
+}
+
+func TestRegisterThrottle(t *testing.T) {
+	s := newTestServer(t)
+	s.App.Config.RegisterMaxPerIP = 2
+
+	// The IP address is locked once it made RegisterMaxPerIP registrations.
+	register(t, s, "alice@example.com")
+	register(t, s, "bob@example.com")
+	var res gin.H
+	if code := s.Do(http.MethodPost, "/api/register", nil, gin.H{"email": "carol@example.com", "password": testPassword}, &res); code != http.StatusTooManyRequests {
+		t.Errorf("got status %d, %v, want %d", code, res, http.StatusTooManyRequests)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	register(t, s, "alice@example.com")
+	session := login(t, s, "alice@example.com")
+	other := login(t, s, "alice@example.com")
+
+	refreshTokens := map[string]string{"first": session.RefreshToken, "other session": other.RefreshToken}
+
+	// Reusing a refresh token revokes its family, including the token it was rotated to, but not the
+	// other sessions.
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "first", token: "first", want: http.StatusOK},
+		{name: "reused", token: "first", want: http.StatusUnauthorized},
+		{name: "rotated after reuse", token: "rotated", want: http.StatusUnauthorized},
+		{name: "other session", token: "other session", want: http.StatusOK},
+		{name: "invalid", token: "invalid", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, ok := refreshTokens[tt.token]
+			if !ok {
+				token = tt.token
+			}
+
+			var res loginResponse
+			if code := s.Do(http.MethodPost, "/api/token/refresh", nil, gin.H{"refresh_token": token}, &res); code != tt.want {
+				t.Fatalf("got status %d, %q, want %d", code, res.Error, tt.want)
+			}
+			if tt.want == http.StatusOK {
+				if res.Token == "" || res.RefreshToken == "" || res.RefreshToken == token {
+					t.Fatalf("got %+v, want new tokens", res)
+				}
+				if tt.token == "first" {
+					refreshTokens["rotated"] = res.RefreshToken
+				}
+			}
+		})
+	}
+}
+
+// recoveryCodePattern matches the recovery codes.
+var recoveryCodePattern = regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`)
+
+func TestLoginMFA(t *testing.T) {
+	s := newTestServer(t)
+	register(t, s, "alice@example.com")
+	session := login(t, s, "alice@example.com")
+
+	var enrolled struct {
+		Secret string `json:"secret"`
+	}
+	if code := s.Do(http.MethodPost, "/api/mfa/totp", apitest.Bearer(session.Token), nil, &enrolled); code != http.StatusOK {
+		t.Fatalf("enrolling: got status %d", code)
+	}
+	code := func(step int64) string {
+		t.Helper()
+		c, err := totp.Code(enrolled.Secret, step)
+		if err != nil {
+			t.Fatalf("totp.Code: %v", err)
+		}
+		return c
+	}
+	step := totp.Step(time.Now())
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if c := s.Do(http.MethodPost, "/api/mfa/totp/confirm", apitest.Bearer(session.Token), gin.H{"code": code(step)}, &confirmed); c != http.StatusOK || len(confirmed.RecoveryCodes) == 0 {
+		t.Fatalf("confirming: got status %d, %v", c, confirmed.RecoveryCodes)
+	}
+	// Recovery codes hold 80 random bits, base32-encoded in groups of 4 characters.
+	for _, recoveryCode := range confirmed.RecoveryCodes {
+		if !recoveryCodePattern.MatchString(recoveryCode) {
+			t.Errorf("got recovery code %q, want it to match %s", recoveryCode, recoveryCodePattern)
+		}
+	}
+
+	// Each attempt continues the previous login unless it starts a new one.
+	var mfaToken string
+	tests := []struct {
+		name     string
+		newLogin bool
+		code     string
+		want     int
+	}{
+		{name: "wrong code", newLogin: true, code: code(step - 10), want: http.StatusUnauthorized},
+		{name: "code used to confirm", code: code(step), want: http.StatusUnauthorized},
+		{name: "next code", code: code(step + 1), want: http.StatusOK},
+		{name: "token already exchanged", code: code(step + 2), want: http.StatusUnauthorized},
+		{name: "replayed code", newLogin: true, code: code(step + 1), want: http.StatusUnauthorized},
+		{name: "recovery code", newLogin: true, code: confirmed.RecoveryCodes[0], want: http.StatusOK},
+		{name: "reused recovery code", newLogin: true, code: confirmed.RecoveryCodes[0], want: http.StatusUnauthorized},
+		{name: "recovery code typed loosely", newLogin: true, code: strings.ToLower(strings.ReplaceAll(confirmed.RecoveryCodes[1], "-", " ")), want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.newLogin {
+				login := login(t, s, "alice@example.com")
+				if !login.MFARequired || login.MFAToken == "" || login.Token != "" {
+					t.Fatalf("got %+v, want a code required", login)
+				}
+				mfaToken = login.MFAToken
+			}
+
+			var res loginResponse
+			if c := s.Do(http.MethodPost, "/api/login/mfa", nil, gin.H{"mfa_token": mfaToken, "code": tt.code}, &res); c != tt.want {
+				t.Fatalf("got status %d, %q, want %d", c, res.Error, tt.want)
+			}
+			if tt.want == http.StatusOK && (res.Token == "" || res.RefreshToken == "") {
+				t.Errorf("got %+v, want the tokens of a new session", res)
+			}
+		})
+	}
+}
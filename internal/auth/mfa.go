@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/totp"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// recoveryCodeEncoding is the encoding of the recovery codes, whose letters and digits can't be
+// confused with one another.
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// mfaCodeData holds a code of the authenticator app, or a recovery code.
+type mfaCodeData struct {
+	Code string `json:"code"`
+}
+
+// loginMFAData holds the token of a login waiting for a second factor, along with the code.
+type loginMFAData struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+const (
+	// maxMFAAttempts is the number of codes that can be tried for a login, after which the user must
+	// log in again.
+	maxMFAAttempts = 5
+	// recoveryCodeCount is the number of recovery codes generated at once.
+	recoveryCodeCount = 10
+	// recoveryCodeBytes is the number of random bytes of a recovery code, i.e. 80 bits, which keeps
+	// their hashes out of reach of brute force.
+	recoveryCodeBytes = 10
+	// totpSkew is the number of time steps before and after the current one whose codes are accepted,
+	// to tolerate clock drift.
+	totpSkew = 1
+)
+
+// GetMFAHandler handles retrieval of the two-factor authentication settings of the authenticated user.
+func GetMFAHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	count, err := app.MFARepository.CountRecoveryCodes(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to count recovery codes: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve two-factor authentication settings"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"totp_enabled":        user.TOTPEnabledAt != nil,
+		"recovery_codes_left": count,
+	})
+}
+
+// EnrollTOTPHandler handles the enrollment of an authenticator app. It generates a new secret, which
+// only enables two-factor authentication once confirmed with a code.
+func EnrollTOTPHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+	if user.TOTPEnabledAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Warning: Failed to generate TOTP secret: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll the authenticator app"})
+		return
+	}
+	if err := app.UserRepository.UpdateUserTOTP(user.ID, &secret, false); err != nil {
+		log.Printf("Warning: Failed to store TOTP secret: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll the authenticator app"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Add the secret to your authenticator app, then confirm it with a code",
+		"secret":      secret,
+		"otpauth_uri": totp.URI(app.Config.MFAIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTOTPHandler handles the confirmation of an enrolled authenticator app with a code, which
+// enables two-factor authentication. The recovery codes are only returned in the response, as only
+// their hashes are stored.
+func ConfirmTOTPHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var cd mfaCodeData
+	if err := ctx.ShouldBindJSON(&cd); err != nil || cd.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if user.TOTPEnabledAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "No authenticator app is enrolled"})
+		return
+	}
+	step, ok := totp.Validate(*user.TOTPSecret, cd.Code, time.Now(), totpSkew)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if err := app.UserRepository.UpdateUserTOTP(user.ID, user.TOTPSecret, true); err != nil {
+		log.Printf("Warning: Failed to enable two-factor authentication: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	// The code can't be used again to log in.
+	if _, err := app.UserRepository.UseUserTOTPStep(user.ID, step); err != nil {
+		log.Printf("Warning: Failed to record the use of TOTP code: %v", err)
+	}
+
+	codes := replaceRecoveryCodes(ctx, app, user.ID)
+	if codes == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled successfully. Store the recovery codes somewhere safe, they won't be shown again",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTPHandler handles the deactivation of two-factor authentication, which requires a code.
+func DisableTOTPHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var cd mfaCodeData
+	if err := ctx.ShouldBindJSON(&cd); err != nil || cd.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if user.TOTPEnabledAt == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication isn't enabled"})
+		return
+	}
+	if !verifySecondFactor(ctx, app, user, cd.Code) {
+		return
+	}
+
+	if err := app.UserRepository.UpdateUserTOTP(user.ID, nil, false); err != nil {
+		log.Printf("Warning: Failed to disable two-factor authentication: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if err := app.MFARepository.ReplaceRecoveryCodes(user.ID, nil); err != nil {
+		log.Printf("Warning: Failed to delete recovery codes: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled successfully"})
+}
+
+// RegenerateRecoveryCodesHandler handles the replacement of the recovery codes, which requires a code.
+func RegenerateRecoveryCodesHandler(ctx *gin.Context, app *config.Application) {
+	user := getAuthenticatedUser(ctx, app)
+	if user == nil {
+		return
+	}
+
+	var cd mfaCodeData
+	if err := ctx.ShouldBindJSON(&cd); err != nil || cd.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if user.TOTPEnabledAt == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication isn't enabled"})
+		return
+	}
+	if !verifySecondFactor(ctx, app, user, cd.Code) {
+		return
+	}
+
+	codes := replaceRecoveryCodes(ctx, app, user.ID)
+	if codes == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Recovery codes regenerated successfully. The previous ones can no longer be used",
+		"recovery_codes": codes,
+	})
+}
+
+// LoginMFAHandler handles the second step of the login of the users with two-factor authentication,
+// which exchanges the token returned by LoginHandler and a code for the tokens of a new session.
+func LoginMFAHandler(ctx *gin.Context, app *config.Application) {
+	var ld loginMFAData
+	if err := ctx.ShouldBindJSON(&ld); err != nil || ld.MFAToken == "" || ld.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Retrieve the login waiting for the code.
+	challenge, err := app.MFARepository.GetMFAChallengeByHash(HashToken(ld.MFAToken))
+	if err != nil {
+		log.Printf("Warning: Failed to get MFA challenge from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the code"})
+		return
+	}
+	if challenge == nil || time.Now().After(challenge.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token. Please log in again"})
+		return
+	}
+
+	// Count the attempt first, so that concurrent attempts can't exceed the limit.
+	attempts, err := app.MFARepository.AddMFAChallengeAttempt(challenge.ID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token. Please log in again"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to count MFA attempt: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the code"})
+		return
+	}
+	if attempts > maxMFAAttempts {
+		if _, err := app.MFARepository.DeleteMFAChallenge(challenge.ID); err != nil {
+			log.Printf("Warning: Failed to delete MFA challenge: %v", err)
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Too many attempts. Please log in again"})
+		return
+	}
+
+	user, err := app.UserRepository.GetUserByID(challenge.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the code"})
+		return
+	}
+	if user == nil || user.TOTPEnabledAt == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token. Please log in again"})
+		return
+	}
+
+	// Invalid codes count as failed login attempts, like wrong passwords.
+	if !checkLoginThrottle(ctx, app, user.Email) {
+		return
+	}
+	if !verifySecondFactor(ctx, app, user, ld.Code) {
+		recordLoginFailure(ctx, app, user.Email, user)
+		return
+	}
+
+	// The token can only be exchanged once.
+	deleted, err := app.MFARepository.DeleteMFAChallenge(challenge.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to delete MFA challenge: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the code"})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token. Please log in again"})
+		return
+	}
+
+	startSession(ctx, app, user)
+}
+
+// startMFAChallenge starts the second step of the login of a user with two-factor authentication,
+// responding with the short-lived token to send along with a code to LoginMFAHandler.
+func startMFAChallenge(ctx *gin.Context, app *config.Application, user *models.User) {
+	token, err := RandomToken(32)
+	if err != nil {
+		log.Printf("Warning: Failed to generate MFA token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	if _, err := app.MFARepository.CreateMFAChallenge(&models.MFAChallenge{
+		TokenHash: HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(app.Config.MFATokenTTL),
+	}); err != nil {
+		log.Printf("Warning: Failed to store MFA challenge: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      "Two-factor authentication required. Send a code along with the mfa_token to /api/login/mfa",
+		"mfa_required": true,
+		"mfa_token":    token,
+		"expires_in":   int(app.Config.MFATokenTTL.Seconds()),
+	})
+}
+
+// verifySecondFactor checks a code of the authenticator app of a user, or one of their recovery codes,
+// and uses it so that it can't be used again. If the code is invalid, it writes the error response and
+// returns false.
+func verifySecondFactor(ctx *gin.Context, app *config.Application, user *models.User, code string) bool {
+	code = strings.ReplaceAll(code, " ", "")
+
+	var ok bool
+	var err error
+	if len(code) == totp.Digits && user.TOTPSecret != nil {
+		if step, valid := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew); valid {
+			ok, err = app.UserRepository.UseUserTOTPStep(user.ID, step)
+		}
+	} else {
+		ok, err = app.MFARepository.UseRecoveryCode(user.ID, HashToken(normalizeRecoveryCode(code)))
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to verify the second factor: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the code"})
+		return false
+	}
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return false
+	}
+
+	return true
+}
+
+// replaceRecoveryCodes generates new recovery codes for a user and stores their hashes, replacing the
+// previous ones. If they can't be stored, it writes the error response and returns nil.
+func replaceRecoveryCodes(ctx *gin.Context, app *config.Application, userID uint) []string {
+	codes := make([]string, recoveryCodeCount)
+	codeHashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			log.Printf("Warning: Failed to generate recovery codes: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+			return nil
+		}
+		// Codes are shown in groups of 4 characters, e.g. "ABCD-EFGH-IJKL-MNOP".
+		code := recoveryCodeEncoding.EncodeToString(b)
+		codes[i] = code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+		codeHashes[i] = HashToken(code)
+	}
+
+	if err := app.MFARepository.ReplaceRecoveryCodes(userID, codeHashes); err != nil {
+		log.Printf("Warning: Failed to store recovery codes: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return nil
+	}
+
+	return codes
+}
+
+// normalizeRecoveryCode strips the dashes and the case of a recovery code, as typed by the user.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+}
+
+// getAuthenticatedUser retrieves the authenticated user from the database. If it can't be retrieved,
+// it writes the error response and returns nil.
+func getAuthenticatedUser(ctx *gin.Context, app *config.Application) *models.User {
+	userID := ctx.MustGet("userID").(uint)
+
+	user, err := app.UserRepository.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the user"})
+		return nil
+	}
+	if user == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+
+	return user
+}
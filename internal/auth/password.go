@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// forgotPasswordData holds the email of the account to reset the password of.
+type forgotPasswordData struct {
+	Email string `json:"email"`
+}
+
+// resetPasswordData holds the password reset token along with the new password.
+type resetPasswordData struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// invalidResetTokenMessage is the error returned when a password reset token is unknown, expired or
+// already used.
+const invalidResetTokenMessage = "Invalid or expired reset token"
+
+// ForgotPasswordHandler handles the request of a password reset link, which is sent by email. The
+// response is the same whether the account exists or not, so that it doesn't tell which emails are
+// registered.
+func ForgotPasswordHandler(ctx *gin.Context, app *config.Application) {
+	var fd forgotPasswordData
+	if err := ctx.ShouldBindJSON(&fd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !validator.IsValidEmail(fd.Email) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+
+	user, err := app.UserRepository.GetUserByEmail(fd.Email)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the reset link"})
+		return
+	}
+	if user != nil {
+		if err := sendPasswordResetLink(app, user); err != nil {
+			log.Printf("Warning: Failed to send the reset link: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the reset link"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for this email, a link to reset its password has been sent to it"})
+}
+
+// sendPasswordResetLink stores a new password reset token for a user, and sends the link to reset their
+// password in the background, so that the response time doesn't tell whether the account exists.
+func sendPasswordResetLink(app *config.Application, user *models.User) error {
+	token, err := RandomToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(app.Config.PasswordResetTTL)
+	if _, err := app.TokenRepository.CreatePasswordResetToken(&models.PasswordResetToken{
+		TokenHash: HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	msg := passwordResetMessage(app.Config.AppURL, user.Email, token, expiresAt)
+	go func() {
+		if err := app.Mailer.Send(msg); err != nil {
+			log.Printf("Warning: Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ForcePasswordReset replaces the password of a user with a random one nobody knows, which revokes every
+// session, and sends them the link to choose a new one.
+func ForcePasswordReset(app *config.Application, user *models.User) error {
+	placeholder, err := RandomToken(32)
+	if err != nil {
+		return err
+	}
+	hash, err := app.PasswordHasher.Hash(placeholder)
+	if err != nil {
+		return err
+	}
+
+	if err := app.UserRepository.UpdateUserPassword(user.ID, hash); err != nil {
+		return err
+	}
+	if err := app.TokenRepository.RevokeUserRefreshTokens(user.ID); err != nil {
+		return err
+	}
+
+	return sendPasswordResetLink(app, user)
+}
+
+// ResetPasswordHandler handles the reset of a password with a token sent by email. The token can only
+// be used once, and every session of the user is revoked.
+func ResetPasswordHandler(ctx *gin.Context, app *config.Application) {
+	var rd resetPasswordData
+	if err := ctx.ShouldBindJSON(&rd); err != nil || rd.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if err := app.PasswordPolicy.Validate(rd.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Retrieve the reset token from the database
+	resetToken, err := app.TokenRepository.GetPasswordResetTokenByHash(HashToken(rd.Token))
+	if err != nil {
+		log.Printf("Warning: Failed to get password reset token from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the reset token"})
+		return
+	}
+	if resetToken == nil || time.Now().After(resetToken.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidResetTokenMessage})
+		return
+	}
+
+	// Hash password.
+	hash, err := app.PasswordHasher.Hash(rd.Password)
+	if err != nil {
+		log.Printf("Warning: Failed to generate hash from the password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the password"})
+		return
+	}
+
+	// Use the reset token along with updating the password, which only succeeds once, revokes the access
+	// tokens issued until now and deletes the other reset links.
+	used, err := app.TokenRepository.ResetPasswordWithToken(resetToken.ID, resetToken.UserID, hash)
+	if err != nil {
+		log.Printf("Warning: Failed to reset the password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset the password"})
+		return
+	}
+	if !used {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidResetTokenMessage})
+		return
+	}
+
+	// Revoke the refresh tokens so that the sessions can't be renewed.
+	if err := app.TokenRepository.RevokeUserRefreshTokens(resetToken.UserID); err != nil {
+		log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+	}
+
+	// Unlock the account, should it be locked after failed login attempts.
+	user, err := app.UserRepository.GetUserByID(resetToken.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+	} else if user != nil {
+		resetLoginThrottle(app, user.Email)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully. Please log in again"})
+}
+
+// passwordResetMessage builds the email sending a password reset link.
+func passwordResetMessage(appURL, email, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimSuffix(appURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+
+	return mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: "Someone asked to reset the password of your Task Manager account.\n\n" +
+			"To choose a new password, open the following link before " + expiresAt.UTC().Format(time.RFC1123) + ":\n\n" +
+			link + "\n\n" +
+			"If it wasn't you, you can ignore this email and your password won't change.",
+	}
+}
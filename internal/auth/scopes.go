@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes restrict what tokens can do on behalf of their user. The access tokens issued at login are
+// granted every scope, while personal access tokens can be restricted to some of them.
+const (
+	ScopeTasksRead     = "tasks:read"
+	ScopeTasksWrite    = "tasks:write"
+	ScopeLabelsRead    = "labels:read"
+	ScopeLabelsWrite   = "labels:write"
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+	// The workspace scopes cover the workspaces and their members and invites. Their tasks are covered
+	// by the task scopes.
+	ScopeWorkspacesRead  = "workspaces:read"
+	ScopeWorkspacesWrite = "workspaces:write"
+)
+
+// scopes lists the known scopes.
+var scopes = []string{
+	ScopeTasksRead,
+	ScopeTasksWrite,
+	ScopeLabelsRead,
+	ScopeLabelsWrite,
+	ScopeProjectsRead,
+	ScopeProjectsWrite,
+	ScopeWorkspacesRead,
+	ScopeWorkspacesWrite,
+}
+
+// readScopes lists the scopes which don't allow any change, granted to the users who haven't verified
+// their email when UnverifiedLogin is "restrict".
+var readScopes = []string{
+	ScopeTasksRead,
+	ScopeLabelsRead,
+	ScopeProjectsRead,
+	ScopeWorkspacesRead,
+}
+
+// isValidScope checks if the scope is one of the known scopes.
+func isValidScope(scope string) bool {
+	return hasScope(scopes, scope)
+}
+
+// RequireScope returns a middleware rejecting the requests whose token wasn't granted the scope. It
+// must follow AuthenticateMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		granted := ctx.GetStringSlice("scopes")
+		if !hasScope(granted, scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient scope. This endpoint requires the " + strconv.Quote(scope) + " scope"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// hasScope checks if the scope is one of the granted scopes.
+func hasScope(granted []string, scope string) bool {
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasScopes checks if every one of the scopes is one of the granted scopes.
+func hasScopes(granted, scopes []string) bool {
+	for _, scope := range scopes {
+		if !hasScope(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// refreshData holds the refresh token to renew the access token with.
+type refreshData struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// invalidRefreshTokenMessage is the error returned when a refresh token is unknown, expired or revoked.
+const invalidRefreshTokenMessage = "Invalid refresh token"
+
+// accountDisabledMessage is the error returned when a disabled user logs in or uses their tokens.
+const accountDisabledMessage = "Account disabled. Please contact an administrator"
+
+// issueTokens issues an access token along with a refresh token of the given family to a user. The
+// access token is granted every scope, unless the user hasn't verified their email and UnverifiedLogin
+// restricts them. Disabled users aren't issued any. If they can't be issued, it writes the error response
+// and returns nil.
+func issueTokens(ctx *gin.Context, app *config.Application, user *models.User, familyID string) gin.H {
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": accountDisabledMessage})
+		return nil
+	}
+
+	granted := scopes
+	if user.VerifiedAt == nil {
+		switch app.Config.UnverifiedLogin {
+		case config.UnverifiedLoginDeny:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Email not verified. Please verify it with the link sent by email"})
+			return nil
+		case config.UnverifiedLoginRestrict:
+			granted = readScopes
+		}
+	}
+
+	refreshToken, err := RandomToken(32)
+	if err != nil {
+		log.Printf("Warning: Failed to generate refresh token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return nil
+	}
+	if _, err := app.TokenRepository.CreateRefreshToken(&models.RefreshToken{
+		TokenHash: HashToken(refreshToken),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(app.Config.RefreshTokenTTL),
+	}); err != nil {
+		log.Printf("Warning: Failed to store refresh token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return nil
+	}
+
+	// Generate a JWT token.
+	token, err := generateToken(app.Keys, user.Email, user.ID, familyID, granted, app.Config.AccessTokenTTL)
+	if err != nil {
+		log.Printf("Warning: Failed to generate token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return nil
+	}
+
+	return gin.H{
+		"token":         token,
+		"expires_in":    int(app.Config.AccessTokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+	}
+}
+
+// RefreshTokenHandler handles the renewal of an access token with a refresh token. The refresh token
+// is rotated: it can only be used once and a new one is issued. Reusing a refresh token revokes all
+// the refresh tokens rotated from the same login, as it may have been stolen.
+func RefreshTokenHandler(ctx *gin.Context, app *config.Application) {
+	var rd refreshData
+	if err := ctx.ShouldBindJSON(&rd); err != nil || rd.RefreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Retrieve the refresh token from the database
+	refreshToken, err := app.TokenRepository.GetRefreshTokenByHash(HashToken(rd.RefreshToken))
+	if err != nil {
+		log.Printf("Warning: Failed to get refresh token from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the refresh token"})
+		return
+	}
+	if refreshToken == nil || refreshToken.RevokedAt != nil || time.Now().After(refreshToken.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": invalidRefreshTokenMessage})
+		return
+	}
+
+	// Mark the refresh token as used, which only succeeds once.
+	used, err := app.TokenRepository.UseRefreshToken(refreshToken.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to use refresh token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the refresh token"})
+		return
+	}
+	if !used {
+		log.Printf("Warning: Refresh token reuse detected for user %d, revoking family %s", refreshToken.UserID, refreshToken.FamilyID)
+		if err := app.TokenRepository.RevokeRefreshTokenFamily(refreshToken.FamilyID); err != nil {
+			log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used. Please log in again"})
+		return
+	}
+
+	user, err := app.UserRepository.GetUserByID(refreshToken.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the refresh token"})
+		return
+	}
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": invalidRefreshTokenMessage})
+		return
+	}
+
+	response := issueTokens(ctx, app, user, refreshToken.FamilyID)
+	if response == nil {
+		return
+	}
+	response["message"] = "Token refreshed successfully"
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// LogoutHandler handles user logout. The access token is revoked along with the refresh tokens of the
+// session, or of all the sessions of the user when the "all" query parameter is true.
+func LogoutHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+	claims := ctx.MustGet("tokenClaims").(jwt.MapClaims)
+
+	all, err := strconv.ParseBool(ctx.DefaultQuery("all", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid all. It must be true or false"})
+		return
+	}
+
+	// Revoke the access token until it expires
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if err := app.TokenRepository.RevokeAccessToken(jti, time.Unix(int64(exp), 0)); err != nil {
+		log.Printf("Warning: Failed to revoke access token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	// Revoke the refresh tokens so that the session can't be renewed, along with the access tokens
+	// of the other sessions when logging out everywhere
+	if all {
+		err = RevokeSessions(app, userID)
+	} else if sessionID, ok := claims["sid"].(string); ok {
+		err = app.TokenRepository.RevokeRefreshTokenFamily(sessionID)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to revoke refresh tokens: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// RevokeSessions revokes every session of a user: the access tokens issued until now, and the refresh
+// tokens so that the sessions can't be renewed.
+func RevokeSessions(app *config.Application, userID uint) error {
+	if err := app.UserRepository.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+
+	return app.TokenRepository.RevokeUserRefreshTokens(userID)
+}
+
+// JWKSHandler handles the publication of the public keys access tokens are signed with, as a JSON Web
+// Key Set, so that other services can verify the tokens.
+func JWKSHandler(ctx *gin.Context, app *config.Application) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, app.Keys.JWKS())
+}
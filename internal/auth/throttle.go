@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// invalidCredentialsMessage is the error returned when logging in with an unknown email or a wrong
+// password alike, so that it doesn't tell which emails are registered.
+const invalidCredentialsMessage = "Invalid credentials"
+
+// accountThrottleKey returns the key the failed login attempts of an account are tracked by. It doesn't
+// depend on whether the account exists, so that lockouts don't tell either.
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// ipThrottleKey returns the key the failed login attempts from an IP address are tracked by.
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// registerThrottleKey returns the key the registrations from an IP address are tracked by.
+func registerThrottleKey(ip string) string {
+	return "register:" + ip
+}
+
+// checkLoginThrottle checks that neither the account nor the IP address of the client are locked after
+// too many failed login attempts. If one of them is, it writes the error response and returns false.
+func checkLoginThrottle(ctx *gin.Context, app *config.Application, email string) bool {
+	var lockedUntil time.Time
+	for _, key := range []string{accountThrottleKey(email), ipThrottleKey(ctx.ClientIP())} {
+		throttle, err := app.LoginThrottles.GetLoginThrottle(key)
+		if err != nil {
+			log.Printf("Warning: Failed to get failed login attempts: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the credentials"})
+			return false
+		}
+		if throttle != nil && throttle.LockedUntil != nil && throttle.LockedUntil.After(lockedUntil) {
+			lockedUntil = *throttle.LockedUntil
+		}
+	}
+
+	if wait := time.Until(lockedUntil); wait > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Please try again later"})
+		return false
+	}
+
+	return true
+}
+
+// checkRegisterThrottle counts a registration from the IP address of the client, and locks the address
+// after too many of them, as each one hashes a password. If it is locked, it writes the error response
+// and returns false.
+func checkRegisterThrottle(ctx *gin.Context, app *config.Application) bool {
+	key := registerThrottleKey(ctx.ClientIP())
+	throttle, err := app.LoginThrottles.GetLoginThrottle(key)
+	if err != nil {
+		log.Printf("Warning: Failed to get registrations: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the user"})
+		return false
+	}
+	if throttle != nil && throttle.LockedUntil != nil {
+		if wait := time.Until(*throttle.LockedUntil); wait > 0 {
+			ctx.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many registrations. Please try again later"})
+			return false
+		}
+	}
+
+	registrations, err := app.LoginThrottles.AddLoginFailure(key, app.Config.LoginFailureWindow)
+	if err != nil {
+		log.Printf("Warning: Failed to record registration: %v", err)
+		return true
+	}
+	if registrations >= app.Config.RegisterMaxPerIP {
+		lockout := lockoutDuration(app.Config, registrations-app.Config.RegisterMaxPerIP)
+		if err := app.LoginThrottles.LockLogin(key, time.Now().Add(lockout)); err != nil {
+			log.Printf("Warning: Failed to lock registrations: %v", err)
+		}
+	}
+
+	return true
+}
+
+// recordLoginFailure counts a failed login attempt for the account and the IP address of the client,
+// and locks those with too many failures. The lockouts are recorded in the audit log, and the user is
+// notified by email when their account gets locked. The user is nil if the account doesn't exist.
+func recordLoginFailure(ctx *gin.Context, app *config.Application, email string, user *models.User) {
+	ip := ctx.ClientIP()
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+
+	limits := []struct {
+		key         string
+		maxFailures int
+		subject     string
+	}{
+		{accountThrottleKey(email), app.Config.LoginMaxFailures, "Account " + strconv.Quote(email)},
+		{ipThrottleKey(ip), app.Config.LoginMaxFailuresPerIP, "IP address " + ip},
+	}
+	for _, limit := range limits {
+		failures, err := app.LoginThrottles.AddLoginFailure(limit.key, app.Config.LoginFailureWindow)
+		if err != nil {
+			log.Printf("Warning: Failed to record failed login attempt: %v", err)
+			continue
+		}
+		if failures < limit.maxFailures {
+			continue
+		}
+
+		lockout := lockoutDuration(app.Config, failures-limit.maxFailures)
+		if err := app.LoginThrottles.LockLogin(limit.key, time.Now().Add(lockout)); err != nil {
+			log.Printf("Warning: Failed to lock login: %v", err)
+			continue
+		}
+
+		entry := &models.AuditEntry{
+			UserID:  userID,
+			Event:   models.AuditEventLoginLocked,
+			IP:      ip,
+			Details: fmt.Sprintf("%s locked for %s after %d failed login attempts", limit.subject, lockout, failures),
+		}
+		if err := app.AuditRepository.CreateAuditEntry(entry); err != nil {
+			log.Printf("Warning: Failed to record lockout in the audit log: %v", err)
+		}
+
+		// Only notify the user of the first lockout, rather than of every further failure.
+		if user != nil && limit.key == accountThrottleKey(email) && failures == limit.maxFailures {
+			msg := lockoutMessage(user.Email, failures, lockout)
+			go func() {
+				if err := app.Mailer.Send(msg); err != nil {
+					log.Printf("Warning: Failed to send lockout email to user %d: %v", user.ID, err)
+				}
+			}()
+		}
+	}
+}
+
+// resetLoginThrottle forgets the failed login attempts of an account, which unlocks it. The failed
+// attempts from IP addresses are kept, so that logging in to an account doesn't clear those made
+// against other accounts.
+func resetLoginThrottle(app *config.Application, email string) {
+	if err := app.LoginThrottles.ResetLoginThrottle(accountThrottleKey(email)); err != nil {
+		log.Printf("Warning: Failed to reset failed login attempts: %v", err)
+	}
+}
+
+// lockoutDuration returns how long logins are locked after the given number of failures beyond the
+// limit. It doubles on every failure, up to the maximum.
+func lockoutDuration(cfg *config.Config, extraFailures int) time.Duration {
+	lockout := cfg.LoginLockout
+	for i := 0; i < extraFailures && lockout < cfg.LoginMaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > cfg.LoginMaxLockout {
+		lockout = cfg.LoginMaxLockout
+	}
+
+	return lockout
+}
+
+// lockoutMessage builds the email notifying a user that their account was locked.
+func lockoutMessage(email string, failures int, lockout time.Duration) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "Your account was temporarily locked",
+		Body: fmt.Sprintf("Your Task Manager account was locked for %s after %d failed login attempts.\n\n", lockout, failures) +
+			"If it wasn't you, someone may be trying to guess your password. Resetting your password unlocks your account right away.",
+	}
+}
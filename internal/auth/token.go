@@ -1,47 +1,57 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/milanvthakor/task-manager-api/internal/signing"
 )
 
-// generateToken generates a JWT token.
-func generateToken(secretKey, email string, userID uint) (string, error) {
+// generateToken generates a JWT access token signed with the current key of the set. It has a unique
+// jti claim, so that it can be revoked, the sid claim identifies the family of the refresh token it was
+// issued with, and the scope claim lists the granted scopes, space-separated.
+func generateToken(keys *signing.KeySet, email string, userID uint, sessionID string, granted []string, ttl time.Duration) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"email":  email,
 		"userID": userID,
-		"exp":    time.Now().Add(time.Hour).Unix(),
+		"jti":    jti,
+		"sid":    sessionID,
+		"scope":  strings.Join(granted, " "),
+		"iat":    now.Unix(),
+		"exp":    now.Add(ttl).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(secretKey))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	return keys.Sign(claims)
 }
 
-// validateToken validates a JWT token.
-func validateToken(secretKey, tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-
-		return []byte(secretKey), nil
-	})
+// validateToken validates a JWT token against the keys of the set.
+func validateToken(keys *signing.KeySet, tokenString string) (jwt.MapClaims, error) {
+	return keys.Verify(tokenString)
+}
 
-	if err != nil {
-		return nil, err
+// RandomToken generates a URL-safe random token from the given number of random bytes.
+func RandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return nil, jwt.ErrSignatureInvalid
+// HashToken returns the hex-encoded SHA-256 hash of a token, which is how tokens are stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
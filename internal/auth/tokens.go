@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// personalAccessTokenPrefix prefixes the personal access tokens, which tells them apart from JWTs and
+// makes them easy to find by secret scanners.
+const personalAccessTokenPrefix = "tm_pat_"
+
+// personalAccessTokenData holds the details of a personal access token to create.
+type personalAccessTokenData struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// GetTokensHandler handles retrieval of the personal access tokens of the authenticated user. The
+// tokens themselves can't be retrieved, only their prefix.
+func GetTokensHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	tokens, err := app.TokenRepository.ListPersonalAccessTokens(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve personal access tokens: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// CreateTokenHandler handles the creation of a personal access token. The token is only returned in
+// the response, as only its hash is stored. Its scopes can't exceed the scopes of the access token
+// creating it, which it is granted when none are given. Users who haven't verified their email can't
+// create any when UnverifiedLogin is "restrict".
+func CreateTokenHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+	granted := ctx.GetStringSlice("scopes")
+
+	var td personalAccessTokenData
+	if err := ctx.ShouldBindJSON(&td); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	td.Name = strings.TrimSpace(td.Name)
+	if td.Name == "" || len(td.Name) > 100 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name. It must not be empty nor longer than 100 characters"})
+		return
+	}
+	for _, scope := range td.Scopes {
+		if !isValidScope(scope) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope " + strconv.Quote(scope) + ". It can have one of the following values: " + strings.Join(scopes, ", ")})
+			return
+		}
+		if !hasScope(granted, scope) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient scope. A token can't be granted the " + strconv.Quote(scope) + " scope, which yours lacks"})
+			return
+		}
+	}
+	// Tokens without scopes are granted every scope, so they inherit the scopes of a restricted token.
+	if len(td.Scopes) == 0 && !hasScopes(granted, scopes) {
+		td.Scopes = granted
+	}
+	if td.ExpiresAt != nil && !td.ExpiresAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiry. It must be in the future"})
+		return
+	}
+
+	if app.Config.UnverifiedLogin == config.UnverifiedLoginRestrict {
+		user, err := app.UserRepository.GetUserByID(userID)
+		if err != nil {
+			log.Printf("Warning: Failed to get user details from the database: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+		if user == nil || user.VerifiedAt == nil {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Email not verified. Please verify it with the link sent by email to create tokens"})
+			return
+		}
+	}
+
+	secret, err := RandomToken(32)
+	if err != nil {
+		log.Printf("Warning: Failed to generate personal access token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	token := personalAccessTokenPrefix + secret
+
+	// Store the hash of the token in the database.
+	pat, err := app.TokenRepository.CreatePersonalAccessToken(&models.PersonalAccessToken{
+		Name:        td.Name,
+		TokenHash:   HashToken(token),
+		TokenPrefix: token[:len(personalAccessTokenPrefix)+4],
+		Scopes:      td.Scopes,
+		UserID:      userID,
+		ExpiresAt:   td.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to create personal access token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":      "Token created successfully. Copy it now, it won't be shown again",
+		"token":        token,
+		"access_token": pat,
+	})
+}
+
+// DeleteTokenByIDHandler handles the revocation of a personal access token of the authenticated user.
+func DeleteTokenByIDHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+	tokenID := ctx.MustGet("tokenID").(uint)
+
+	err := app.TokenRepository.DeletePersonalAccessToken(tokenID, userID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to delete personal access token from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+// authenticatePersonalAccessToken authenticates a request with a personal access token, whose Scopes are
+// set to the scopes it is granted. If the token is unknown or expired, or its user is disabled, it writes
+// the error response and returns nil.
+func authenticatePersonalAccessToken(ctx *gin.Context, app *config.Application, token string) *models.PersonalAccessToken {
+	pat, err := app.TokenRepository.GetPersonalAccessTokenByHash(HashToken(token))
+	if err != nil {
+		log.Printf("Warning: Failed to get personal access token from the database: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+		return nil
+	}
+	if pat == nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil
+	}
+	if pat.ExpiresAt != nil && time.Now().After(*pat.ExpiresAt) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has expired"})
+		return nil
+	}
+
+	// The tokens of a disabled user are kept, but rejected until the account is enabled again.
+	user, err := app.UserRepository.GetUserByID(pat.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+		return nil
+	}
+	if user == nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil
+	}
+	if user.DisabledAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": accountDisabledMessage})
+		return nil
+	}
+
+	// Tokens without scopes are granted every scope, but only the read scopes while the user hasn't
+	// verified their email when UnverifiedLogin is "restrict".
+	if len(pat.Scopes) == 0 {
+		pat.Scopes = scopes
+	}
+	if user.VerifiedAt == nil && app.Config.UnverifiedLogin == config.UnverifiedLoginRestrict {
+		var restricted []string
+		for _, scope := range pat.Scopes {
+			if hasScope(readScopes, scope) {
+				restricted = append(restricted, scope)
+			}
+		}
+		pat.Scopes = restricted
+	}
+
+	// Record the use of the token, at most once a minute.
+	if pat.LastUsedAt == nil || time.Since(*pat.LastUsedAt) > time.Minute {
+		if err := app.TokenRepository.TouchPersonalAccessToken(pat.ID); err != nil {
+			log.Printf("Warning: Failed to record the use of personal access token: %v", err)
+		}
+	}
+
+	return pat
+}
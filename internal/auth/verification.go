@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// verifyEmailData holds the email verification token.
+type verifyEmailData struct {
+	Token string `json:"token"`
+}
+
+// resendVerificationData holds the email of the account to resend the verification link of.
+type resendVerificationData struct {
+	Email string `json:"email"`
+}
+
+// invalidVerificationTokenMessage is the error returned when an email verification token is unknown,
+// expired or already used.
+const invalidVerificationTokenMessage = "Invalid or expired verification token"
+
+// VerifyEmailHandler handles the verification of an email with a token sent by email.
+func VerifyEmailHandler(ctx *gin.Context, app *config.Application) {
+	var vd verifyEmailData
+	if err := ctx.ShouldBindJSON(&vd); err != nil || vd.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Retrieve the verification token from the database
+	verificationToken, err := app.TokenRepository.GetEmailVerificationTokenByHash(HashToken(vd.Token))
+	if err != nil {
+		log.Printf("Warning: Failed to get email verification token from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+		return
+	}
+	if verificationToken == nil || time.Now().After(verificationToken.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidVerificationTokenMessage})
+		return
+	}
+
+	// Use the verification token, which only succeeds once.
+	used, err := app.TokenRepository.UseEmailVerificationToken(verificationToken.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to use email verification token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+		return
+	}
+	if !used {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidVerificationTokenMessage})
+		return
+	}
+
+	// When the token was sent to change the email, the new email may have been taken meanwhile.
+	user, err := app.UserRepository.GetUserByID(verificationToken.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+		return
+	}
+	if user == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidVerificationTokenMessage})
+		return
+	}
+	if user.Email != verificationToken.Email {
+		existing, err := app.UserRepository.GetUserByEmail(verificationToken.Email)
+		if err != nil {
+			log.Printf("Warning: Failed to get user details from the database: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+			return
+		}
+		if existing != nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+			return
+		}
+	}
+
+	if err := app.UserRepository.VerifyUserEmail(user.ID, verificationToken.Email); err != nil {
+		log.Printf("Warning: Failed to verify the email: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the email"})
+		return
+	}
+
+	// Notify the previous email when the email changed.
+	if user.Email != verificationToken.Email {
+		msg := emailChangedMessage(user.Email, verificationToken.Email)
+		go func() {
+			if err := app.Mailer.Send(msg); err != nil {
+				log.Printf("Warning: Failed to send email change email to user %d: %v", user.ID, err)
+			}
+		}()
+	}
+
+	// The other verification links are no longer needed.
+	if err := app.TokenRepository.DeleteUserEmailVerificationTokens(verificationToken.UserID); err != nil {
+		log.Printf("Warning: Failed to delete email verification tokens: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerificationEmailHandler handles the request of a new email verification link. A link is only
+// sent if the account exists, isn't verified yet and wasn't sent one within the resend interval, but
+// the response is the same in every case, so that it doesn't tell which emails are registered.
+func ResendVerificationEmailHandler(ctx *gin.Context, app *config.Application) {
+	var rd resendVerificationData
+	if err := ctx.ShouldBindJSON(&rd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !validator.IsValidEmail(rd.Email) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+
+	user, err := app.UserRepository.GetUserByEmail(rd.Email)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the verification link"})
+		return
+	}
+	if user != nil && user.VerifiedAt == nil {
+		latest, err := app.TokenRepository.GetLatestEmailVerificationToken(user.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to get email verification token from the database: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the verification link"})
+			return
+		}
+
+		// Throttle the emails sent to the user.
+		if latest == nil || time.Since(latest.CreatedAt) >= app.Config.EmailVerificationResendInterval {
+			if err := sendVerificationEmail(app, user.ID, user.Email); err != nil {
+				log.Printf("Warning: Failed to send the verification link: %v", err)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the verification link"})
+				return
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If an unverified account exists for this email, a new verification link has been sent to it"})
+}
+
+// sendVerificationEmail stores a new email verification token for the email of a user, and sends the
+// link to verify it in the background.
+func sendVerificationEmail(app *config.Application, userID uint, email string) error {
+	token, err := RandomToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(app.Config.EmailVerificationTTL)
+	if _, err := app.TokenRepository.CreateEmailVerificationToken(&models.EmailVerificationToken{
+		TokenHash: HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	msg := verificationMessage(app.Config.AppURL, email, token, expiresAt)
+	go func() {
+		if err := app.Mailer.Send(msg); err != nil {
+			log.Printf("Warning: Failed to send verification email to user %d: %v", userID, err)
+		}
+	}()
+
+	return nil
+}
+
+// verificationMessage builds the email sending an email verification link.
+func verificationMessage(appURL, email, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimSuffix(appURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+
+	return mail.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body: "Please confirm that this email belongs to your Task Manager account.\n\n" +
+			"To verify it, open the following link before " + expiresAt.UTC().Format(time.RFC1123) + ":\n\n" +
+			link + "\n\n" +
+			"If you didn't create an account, you can ignore this email.",
+	}
+}
@@ -0,0 +1,113 @@
+package authz
+
+import (
+	"github.com/milanvthakor/task-manager-api/internal/models"
+)
+
+// ForbiddenMessage is the error returned when the role of a user in a workspace doesn't allow an action.
+const ForbiddenMessage = "Insufficient permissions. Your role in the workspace doesn't allow it"
+
+// Action represents something a user may do with a task or a workspace.
+type Action string
+
+const (
+	// ActionViewTasks allows reading the tasks, their labels, subtasks and dependencies.
+	ActionViewTasks Action = "view_tasks"
+	// ActionEditTasks allows creating, updating, completing and deleting tasks.
+	ActionEditTasks Action = "edit_tasks"
+	// ActionManageMembers allows inviting, removing and changing the role of members of lower rank.
+	ActionManageMembers Action = "manage_members"
+	// ActionManageWorkspace allows renaming the workspace.
+	ActionManageWorkspace Action = "manage_workspace"
+	// ActionDeleteWorkspace allows deleting the workspace along with its tasks.
+	ActionDeleteWorkspace Action = "delete_workspace"
+)
+
+// roleActions holds the actions allowed to each workspace role.
+var roleActions = map[models.WorkspaceRole][]Action{
+	models.WorkspaceRoleOwner:  {ActionViewTasks, ActionEditTasks, ActionManageMembers, ActionManageWorkspace, ActionDeleteWorkspace},
+	models.WorkspaceRoleAdmin:  {ActionViewTasks, ActionEditTasks, ActionManageMembers, ActionManageWorkspace},
+	models.WorkspaceRoleMember: {ActionViewTasks, ActionEditTasks},
+	models.WorkspaceRoleViewer: {ActionViewTasks},
+}
+
+// Decision represents the outcome of an authorization check.
+type Decision int
+
+const (
+	// DecisionHidden is returned when the user can't even know the resource exists, which should be
+	// answered as if it didn't.
+	DecisionHidden Decision = iota
+	// DecisionForbidden is returned when the user can see the resource but not do the action.
+	DecisionForbidden
+	// DecisionAllowed is returned when the user may do the action.
+	DecisionAllowed
+)
+
+// Allows checks if a workspace role allows an action.
+func Allows(role models.WorkspaceRole, action Action) bool {
+	for _, allowed := range roleActions[role] {
+		if allowed == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanManageMember checks if a member may remove another member or change their role, which requires
+// managing members and a higher rank than theirs.
+func CanManageMember(actor, target *models.WorkspaceMember) bool {
+	return Allows(actor.Role, ActionManageMembers) && actor.Role.Rank() > target.Role.Rank()
+}
+
+// CanAssignRole checks if a member may give a role to someone, which requires managing members and a
+// higher rank than the role. Only the owner may hand over the owner role, which transfers the ownership.
+func CanAssignRole(actor *models.WorkspaceMember, role models.WorkspaceRole) bool {
+	if role == models.WorkspaceRoleOwner {
+		return actor.Role == models.WorkspaceRoleOwner
+	}
+
+	return Allows(actor.Role, ActionManageMembers) && actor.Role.Rank() > role.Rank()
+}
+
+// Authorizer decides what users may do with tasks and workspaces. Personal tasks are only visible to the
+// user who created them, and the tasks of a workspace to its members, depending on their role.
+type Authorizer struct {
+	workspaces models.WorkspaceStore
+}
+
+// NewAuthorizer creates a new instance of Authorizer.
+func NewAuthorizer(workspaces models.WorkspaceStore) *Authorizer {
+	return &Authorizer{workspaces: workspaces}
+}
+
+// AuthorizeTask decides if a user may do an action with a task.
+func (a *Authorizer) AuthorizeTask(userID uint, task *models.Task, action Action) (Decision, error) {
+	if task.WorkspaceID == nil {
+		if task.UserID != userID {
+			return DecisionHidden, nil
+		}
+		return DecisionAllowed, nil
+	}
+
+	_, decision, err := a.AuthorizeWorkspace(userID, *task.WorkspaceID, action)
+	return decision, err
+}
+
+// AuthorizeWorkspace decides if a user may do an action in a workspace. The membership of the user is
+// returned along with the decision, unless they aren't a member.
+func (a *Authorizer) AuthorizeWorkspace(userID, workspaceID uint, action Action) (*models.WorkspaceMember, Decision, error) {
+	member, err := a.workspaces.GetWorkspaceMember(workspaceID, userID)
+	if err != nil {
+		return nil, DecisionHidden, err
+	}
+	if member == nil {
+		return nil, DecisionHidden, nil
+	}
+	if !Allows(member.Role, action) {
+		return member, DecisionForbidden, nil
+	}
+
+	return member, DecisionAllowed, nil
+}
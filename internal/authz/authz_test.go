@@ -0,0 +1,176 @@
+package authz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/models"
+)
+
+// newTestWorkspace creates a workspace with a member of each role, and returns the authorizer, the ID of
+// the workspace and the IDs of the members keyed by role, along with the ID of a user outside of it.
+func newTestWorkspace(t *testing.T) (*Authorizer, uint, map[models.WorkspaceRole]uint, uint) {
+	t.Helper()
+
+	mdb := models.NewMemoryDB()
+	users := models.NewMemoryUserRepository(mdb)
+	workspaces := models.NewMemoryWorkspaceRepository(mdb)
+
+	newUser := func(email string) uint {
+		user := &models.User{Email: email}
+		if err := users.CreateUser(user, "hash"); err != nil {
+			t.Fatalf("CreateUser(%q): %v", email, err)
+		}
+		return user.ID
+	}
+
+	members := map[models.WorkspaceRole]uint{models.WorkspaceRoleOwner: newUser("owner@example.com")}
+	workspace, err := workspaces.CreateWorkspace(&models.Workspace{Name: "Team"}, members[models.WorkspaceRoleOwner])
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	for _, role := range []models.WorkspaceRole{models.WorkspaceRoleAdmin, models.WorkspaceRoleMember, models.WorkspaceRoleViewer} {
+		email := string(role) + "@example.com"
+		members[role] = newUser(email)
+		invite, err := workspaces.CreateWorkspaceInvite(&models.WorkspaceInvite{
+			WorkspaceID: workspace.ID,
+			Email:       email,
+			Role:        role,
+			TokenHash:   email,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateWorkspaceInvite: %v", err)
+		}
+		if err := workspaces.AcceptWorkspaceInvite(invite.ID, members[role]); err != nil {
+			t.Fatalf("AcceptWorkspaceInvite: %v", err)
+		}
+	}
+
+	return NewAuthorizer(workspaces), workspace.ID, members, newUser("outsider@example.com")
+}
+
+func TestAuthorizeTask(t *testing.T) {
+	authorizer, workspaceID, members, outsiderID := newTestWorkspace(t)
+	ownerID := members[models.WorkspaceRoleOwner]
+	workspaceTask := &models.Task{ID: 1, UserID: members[models.WorkspaceRoleMember], WorkspaceID: &workspaceID}
+	personalTask := &models.Task{ID: 2, UserID: ownerID}
+
+	tests := []struct {
+		name   string
+		userID uint
+		task   *models.Task
+		action Action
+		want   Decision
+	}{
+		{name: "owner views", userID: ownerID, task: workspaceTask, action: ActionViewTasks, want: DecisionAllowed},
+		{name: "owner edits", userID: ownerID, task: workspaceTask, action: ActionEditTasks, want: DecisionAllowed},
+		{name: "admin edits", userID: members[models.WorkspaceRoleAdmin], task: workspaceTask, action: ActionEditTasks, want: DecisionAllowed},
+		{name: "member edits", userID: members[models.WorkspaceRoleMember], task: workspaceTask, action: ActionEditTasks, want: DecisionAllowed},
+		{name: "viewer views", userID: members[models.WorkspaceRoleViewer], task: workspaceTask, action: ActionViewTasks, want: DecisionAllowed},
+		{name: "viewer edits", userID: members[models.WorkspaceRoleViewer], task: workspaceTask, action: ActionEditTasks, want: DecisionForbidden},
+		{name: "outsider views", userID: outsiderID, task: workspaceTask, action: ActionViewTasks, want: DecisionHidden},
+		{name: "creator of personal task edits", userID: ownerID, task: personalTask, action: ActionEditTasks, want: DecisionAllowed},
+		{name: "member views personal task", userID: members[models.WorkspaceRoleMember], task: personalTask, action: ActionViewTasks, want: DecisionHidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authorizer.AuthorizeTask(tt.userID, tt.task, tt.action)
+			if err != nil {
+				t.Fatalf("AuthorizeTask: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got decision %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWorkspace(t *testing.T) {
+	authorizer, workspaceID, members, outsiderID := newTestWorkspace(t)
+
+	// The actions allowed to each role, the others being forbidden.
+	allowed := map[models.WorkspaceRole][]Action{
+		models.WorkspaceRoleOwner:  {ActionViewTasks, ActionEditTasks, ActionManageMembers, ActionManageWorkspace, ActionDeleteWorkspace},
+		models.WorkspaceRoleAdmin:  {ActionViewTasks, ActionEditTasks, ActionManageMembers, ActionManageWorkspace},
+		models.WorkspaceRoleMember: {ActionViewTasks, ActionEditTasks},
+		models.WorkspaceRoleViewer: {ActionViewTasks},
+	}
+	actions := allowed[models.WorkspaceRoleOwner]
+
+	for role, userID := range members {
+		for _, action := range actions {
+			want := DecisionForbidden
+			for _, a := range allowed[role] {
+				if a == action {
+					want = DecisionAllowed
+				}
+			}
+
+			t.Run(string(role)+"/"+string(action), func(t *testing.T) {
+				member, got, err := authorizer.AuthorizeWorkspace(userID, workspaceID, action)
+				if err != nil {
+					t.Fatalf("AuthorizeWorkspace: %v", err)
+				}
+				if got != want {
+					t.Errorf("got decision %d, want %d", got, want)
+				}
+				if member == nil || member.Role != role {
+					t.Errorf("got member %+v, want the %s", member, role)
+				}
+			})
+		}
+	}
+
+	member, got, err := authorizer.AuthorizeWorkspace(outsiderID, workspaceID, ActionViewTasks)
+	if err != nil || got != DecisionHidden || member != nil {
+		t.Errorf("outsider: got %+v, %d, %v, want the workspace hidden", member, got, err)
+	}
+}
+
+func TestCanAssignRole(t *testing.T) {
+	tests := []struct {
+		actor models.WorkspaceRole
+		role  models.WorkspaceRole
+		want  bool
+	}{
+		{actor: models.WorkspaceRoleOwner, role: models.WorkspaceRoleOwner, want: true},
+		{actor: models.WorkspaceRoleOwner, role: models.WorkspaceRoleAdmin, want: true},
+		{actor: models.WorkspaceRoleAdmin, role: models.WorkspaceRoleOwner, want: false},
+		{actor: models.WorkspaceRoleAdmin, role: models.WorkspaceRoleAdmin, want: false},
+		{actor: models.WorkspaceRoleAdmin, role: models.WorkspaceRoleMember, want: true},
+		{actor: models.WorkspaceRoleAdmin, role: models.WorkspaceRoleViewer, want: true},
+		{actor: models.WorkspaceRoleMember, role: models.WorkspaceRoleViewer, want: false},
+		{actor: models.WorkspaceRoleViewer, role: models.WorkspaceRoleViewer, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.actor)+"/"+string(tt.role), func(t *testing.T) {
+			if got := CanAssignRole(&models.WorkspaceMember{Role: tt.actor}, tt.role); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageMember(t *testing.T) {
+	tests := []struct {
+		actor  models.WorkspaceRole
+		target models.WorkspaceRole
+		want   bool
+	}{
+		{actor: models.WorkspaceRoleOwner, target: models.WorkspaceRoleAdmin, want: true},
+		{actor: models.WorkspaceRoleAdmin, target: models.WorkspaceRoleOwner, want: false},
+		{actor: models.WorkspaceRoleAdmin, target: models.WorkspaceRoleAdmin, want: false},
+		{actor: models.WorkspaceRoleAdmin, target: models.WorkspaceRoleMember, want: true},
+		{actor: models.WorkspaceRoleMember, target: models.WorkspaceRoleViewer, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.actor)+"/"+string(tt.target), func(t *testing.T) {
+			actor := &models.WorkspaceMember{Role: tt.actor}
+			target := &models.WorkspaceMember{Role: tt.target}
+			if got := CanManageMember(actor, target); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
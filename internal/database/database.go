@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 var (
@@ -14,14 +15,23 @@ var (
 	once sync.Once
 )
 
-// Init initializes the database connection.
-func Init(databaseDSN string) (*sql.DB, error) {
+// Init initializes the database connection using the given SQL driver.
+func Init(driver, databaseDSN string) (*sql.DB, error) {
 	var err error
 
 	// Use sync.Once to ensure initialization only happens once.
 	once.Do(func() {
+		switch driver {
+		case DriverPostgres:
+		case DriverSQLite:
+			databaseDSN = sqliteDSN(databaseDSN)
+		default:
+			err = fmt.Errorf("unsupported database driver %q", driver)
+			return
+		}
+
 		// Open a new database connection
-		db, err = sql.Open("postgres", databaseDSN)
+		db, err = sql.Open(driver, databaseDSN)
 		if err != nil {
 			err = fmt.Errorf("failed to open database connection: %w", err)
 			return
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported storage drivers.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Dialect describes the SQL flavour spoken by an SQL storage driver.
+type Dialect string
+
+// Supported SQL dialects.
+const (
+	DialectPostgres Dialect = DriverPostgres
+	DialectSQLite   Dialect = DriverSQLite
+)
+
+// sqliteTimeLayout is the fixed-width layout used to store timestamps in SQLite, so that
+// they compare correctly as text.
+const sqliteTimeLayout = "2006-01-02 15:04:05.000000"
+
+// Time converts t into a query argument that compares correctly within the dialect.
+func (d Dialect) Time(t time.Time) interface{} {
+	t = t.UTC().Truncate(time.Microsecond)
+	if d == DialectSQLite {
+		return t.Format(sqliteTimeLayout)
+	}
+
+	return t
+}
+
+// NullTime converts t into a query argument like Time, or NULL when t is nil.
+func (d Dialect) NullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return d.Time(*t)
+}
+
+// MaxTime is the greatest timestamp stored by the application, used in place of NULL when sorting.
+var MaxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// MaxTimeLiteral returns the SQL literal of MaxTime.
+func (d Dialect) MaxTimeLiteral() string {
+	if d == DialectSQLite {
+		return "'" + MaxTime.Format(sqliteTimeLayout) + "'"
+	}
+
+	return "TIMESTAMPTZ '" + MaxTime.Format("2006-01-02 15:04:05Z07:00") + "'"
+}
+
+// ILike returns the operator matching a LIKE pattern case-insensitively.
+func (d Dialect) ILike() string {
+	if d == DialectSQLite {
+		// SQLite's LIKE is already case-insensitive for ASCII characters.
+		return "LIKE"
+	}
+
+	return "ILIKE"
+}
+
+// sqliteDSN enables the connection options the application relies upon in a SQLite DSN.
+func sqliteDSN(dsn string) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// Take the write lock when a transaction begins, as SQLite serializes writers anyway.
+	params.Set("_txlock", "immediate")
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + params.Encode()
+}
@@ -0,0 +1,351 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationsFS holds the SQL migration files bundled into the binary.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrations run, so that
+// several API replicas starting at the same time don't apply the same migration twice.
+const migrationLockID = 7281741
+
+// ErrNoMigrationsApplied is returned when rolling back with no applied migrations.
+var ErrNoMigrationsApplied = errors.New("no migrations have been applied")
+
+// Migration represents a single versioned schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigrationsTable holds the DDL of the table tracking applied migrations for each dialect.
+var schemaMigrationsTable = map[Dialect]string{
+	DialectPostgres: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`,
+	DialectSQLite: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`,
+}
+
+// Migrator applies and rolls back the embedded schema migrations.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// NewMigrator creates a new instance of Migrator with the embedded migrations of the dialect.
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	if _, ok := schemaMigrationsTable[dialect]; !ok {
+		return nil, fmt.Errorf("unsupported migration dialect %q", dialect)
+	}
+
+	migrations, err := loadMigrations(migrationsFS, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations reads the "<version>_<name>.up.sql" and "<version>_<name>.down.sql"
+// files from the directory and returns them ordered by version.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := path.Ext(base)
+		if direction != ".up" && direction != ".down" {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+		base = strings.TrimSuffix(base, direction)
+
+		versionStr, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", fileName, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", fileName, err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		if direction == ".up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations in order and returns the applied ones.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		appliedAt, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+
+			ok, err := m.apply(ctx, conn, migration, true)
+			if err != nil {
+				return err
+			}
+			if ok {
+				applied = append(applied, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down() (*Migration, error) {
+	var rolledBack *Migration
+
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		migration, err := m.latestApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if _, err := m.apply(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		rolledBack = migration
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo() (*Migration, error) {
+	var redone *Migration
+
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		migration, err := m.latestApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if _, err := m.apply(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		if _, err := m.apply(ctx, conn, *migration, true); err != nil {
+			return err
+		}
+		redone = migration
+
+		return nil
+	})
+
+	return redone, err
+}
+
+// Status returns every known migration along with the time it was applied, if any.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		appliedAt, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if t, ok := appliedAt[migration.Version]; ok {
+				t := t
+				status.AppliedAt = &t
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a dedicated connection while holding the migration lock. PostgreSQL uses
+// an advisory lock, whereas SQLite relies on the write lock taken by each migration transaction.
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	// Advisory locks are bound to the session, so the same connection must be used throughout.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect == DialectPostgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+	}
+
+	if _, err := conn.ExecContext(ctx, schemaMigrationsTable[m.dialect]); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(ctx, conn)
+}
+
+// appliedVersions returns the applied migration versions mapped to the time they were applied.
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[uint]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[uint]time.Time)
+	for rows.Next() {
+		var version uint
+		var t time.Time
+		if err := rows.Scan(&version, &t); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = t
+	}
+
+	return appliedAt, rows.Err()
+}
+
+// latestApplied returns the applied migration with the highest version.
+func (m *Migrator) latestApplied(ctx context.Context, conn *sql.Conn) (*Migration, error) {
+	var version uint
+	err := conn.QueryRowContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoMigrationsApplied
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("applied migration %d is unknown to this binary", version)
+}
+
+// apply runs the up or down script of a migration and records it, all within a transaction.
+// It reports whether the script was run.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) (bool, error) {
+	script := migration.Up
+	if !up {
+		script = migration.Down
+		if strings.TrimSpace(script) == "" {
+			return false, fmt.Errorf("migration %d_%s can't be rolled back", migration.Version, migration.Name)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Make sure another process hasn't already run the migration in the meantime.
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = $1", migration.Version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	if (count > 0) == up {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return false, fmt.Errorf("failed to run migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)", migration.Version, migration.Name, m.dialect.Time(time.Now()))
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newTestSQLiteDB opens a new SQLite database in the test's temporary directory.
+func newTestSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open(DriverSQLite, sqliteDSN(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// appliedCount returns the number of applied migrations according to the migrator.
+func appliedCount(t *testing.T, m *Migrator) int {
+	t.Helper()
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	count := 0
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestMigratorUpDownRedo(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	m, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	total := len(m.migrations)
+	latest := m.migrations[total-1]
+
+	if _, err := m.Down(); err != ErrNoMigrationsApplied {
+		t.Errorf("rolling back a new database: got error %v, want %v", err, ErrNoMigrationsApplied)
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != total || appliedCount(t, m) != total {
+		t.Fatalf("got %d migrations applied, want %d", len(applied), total)
+	}
+	if applied, err := m.Up(); err != nil || len(applied) != 0 {
+		t.Errorf("applying again: got %d migrations, %v, want none", len(applied), err)
+	}
+
+	redone, err := m.Redo()
+	if err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if redone.Version != latest.Version || appliedCount(t, m) != total {
+		t.Errorf("got migration %d redone and %d applied, want %d and %d", redone.Version, appliedCount(t, m), latest.Version, total)
+	}
+
+	// Every migration can be rolled back, in reverse order, and applied again.
+	for i := total - 1; i >= 0; i-- {
+		rolledBack, err := m.Down()
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if rolledBack.Version != m.migrations[i].Version {
+			t.Fatalf("got migration %d rolled back, want %d", rolledBack.Version, m.migrations[i].Version)
+		}
+	}
+	if count := appliedCount(t, m); count != 0 {
+		t.Fatalf("got %d migrations applied after rolling back all of them, want none", count)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')").Scan(&tables); err != nil {
+		t.Fatalf("counting tables: %v", err)
+	}
+	if tables != 0 {
+		t.Errorf("got %d tables left after rolling back all migrations, want none", tables)
+	}
+	if applied, err := m.Up(); err != nil || len(applied) != total {
+		t.Errorf("applying again: got %d migrations, %v, want %d", len(applied), err, total)
+	}
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	postgres, err := loadMigrations(migrationsFS, "migrations/postgres")
+	if err != nil {
+		t.Fatalf("loading the PostgreSQL migrations: %v", err)
+	}
+	sqlite, err := loadMigrations(migrationsFS, "migrations/sqlite")
+	if err != nil {
+		t.Fatalf("loading the SQLite migrations: %v", err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("got %d PostgreSQL migrations and %d SQLite ones, want the same", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("got PostgreSQL migration %d_%s and SQLite migration %d_%s, want the same", postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(data string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(data)} }
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []uint
+		wantErr string
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"m/0010_b.up.sql":   file("CREATE TABLE b (id INTEGER);"),
+				"m/0010_b.down.sql": file("DROP TABLE b;"),
+				"m/0002_a.up.sql":   file("CREATE TABLE a (id INTEGER);"),
+				"m/README.md":       file("ignored"),
+			},
+			want: []uint{2, 10},
+		},
+		{name: "invalid direction", files: fstest.MapFS{"m/0001_a.sideways.sql": file("SELECT 1;")}, wantErr: "invalid migration file name"},
+		{name: "missing name", files: fstest.MapFS{"m/0001.up.sql": file("SELECT 1;")}, wantErr: "invalid migration file name"},
+		{name: "invalid version", files: fstest.MapFS{"m/first_a.up.sql": file("SELECT 1;")}, wantErr: "invalid migration version"},
+		{
+			name:    "conflicting names",
+			files:   fstest.MapFS{"m/0001_a.up.sql": file("SELECT 1;"), "m/0001_b.down.sql": file("SELECT 1;")},
+			wantErr: "conflicting names",
+		},
+		{name: "no up script", files: fstest.MapFS{"m/0001_a.down.sql": file("SELECT 1;")}, wantErr: "has no up script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations: %v", err)
+			}
+
+			var got []uint
+			for _, migration := range migrations {
+				got = append(got, migration.Version)
+			}
+			if len(got) != len(tt.want) || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("got versions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
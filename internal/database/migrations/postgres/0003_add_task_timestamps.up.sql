@@ -0,0 +1,7 @@
+ALTER TABLE tasks
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX tasks_userid_created_at_idx ON tasks (userID, created_at, id);
+CREATE INDEX tasks_userid_updated_at_idx ON tasks (userID, updated_at, id);
+CREATE INDEX tasks_userid_title_idx ON tasks (userID, title, id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_userid_due_at_idx;
+
+ALTER TABLE tasks
+    DROP COLUMN start_at,
+    DROP COLUMN due_at;
@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+    ADD COLUMN due_at TIMESTAMPTZ,
+    ADD COLUMN start_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_userid_due_at_idx ON tasks (userID, due_at, id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0;
+
+CREATE INDEX tasks_userid_priority_idx ON tasks (userID, priority, id);
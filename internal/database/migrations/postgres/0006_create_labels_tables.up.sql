@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS labels (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    UNIQUE (userID, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    label_id INTEGER NOT NULL REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_labels_label_id_idx ON task_labels (label_id);
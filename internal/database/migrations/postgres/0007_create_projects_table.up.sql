@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every user has at most one Inbox project.
+CREATE UNIQUE INDEX projects_userid_inbox_idx ON projects (userID) WHERE is_inbox;
+
+-- Give the existing users their Inbox project.
+INSERT INTO projects (name, is_inbox, userID) SELECT 'Inbox', TRUE, id FROM users;
+
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects (id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_project_id_idx ON tasks (project_id);
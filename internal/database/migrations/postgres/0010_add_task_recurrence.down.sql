@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+    DROP COLUMN recurrence_anchor,
+    DROP COLUMN recurrence;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN sessions_revoked_at;
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,13 @@
+-- Password reset tokens are stored hashed. They are deleted once used, so that they can only be used once.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX password_reset_tokens_userid_idx ON password_reset_tokens (userID);
+
+-- The access tokens issued before sessions_revoked_at are rejected, e.g. after a password reset.
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
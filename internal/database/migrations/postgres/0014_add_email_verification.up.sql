@@ -0,0 +1,15 @@
+-- Users verify their email with a link sent by email. The existing users are considered verified.
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMPTZ;
+UPDATE users SET verified_at = NOW();
+
+-- Email verification tokens are stored hashed along with the email they verify, and deleted once used.
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX email_verification_tokens_userid_idx ON email_verification_tokens (userID);
@@ -0,0 +1,19 @@
+-- Failed login attempts, keyed by account or IP address, when they are tracked in the database.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+-- Security-relevant events, such as lockouts.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    userID INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    event VARCHAR(50) NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_userid_idx ON audit_log (userID);
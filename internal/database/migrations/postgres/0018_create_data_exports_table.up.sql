@@ -0,0 +1,13 @@
+-- Exports of the personal data of the users, built in the background. The archive is kept until the
+-- export expires.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id SERIAL PRIMARY KEY,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    expires_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX data_exports_userid_idx ON data_exports (userID);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS tasks_workspace_id_created_at_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspace_invites;
+
+DROP TABLE IF EXISTS workspace_members;
+
+DROP TABLE IF EXISTS workspaces;
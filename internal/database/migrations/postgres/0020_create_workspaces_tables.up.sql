@@ -0,0 +1,35 @@
+-- Workspaces share a backlog of tasks between their members. Each member has a role, and every
+-- workspace has a single owner.
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, userID)
+);
+
+CREATE INDEX workspace_members_userid_idx ON workspace_members (userID);
+
+-- Invites are sent by email with a token, stored hashed, and deleted once accepted.
+CREATE TABLE IF NOT EXISTS workspace_invites (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX workspace_invites_workspace_id_idx ON workspace_invites (workspace_id);
+
+-- The tasks of a workspace belong to it rather than to the user who created them.
+ALTER TABLE tasks ADD COLUMN workspace_id INTEGER REFERENCES workspaces (id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_workspace_id_created_at_idx ON tasks (workspace_id, created_at, id);
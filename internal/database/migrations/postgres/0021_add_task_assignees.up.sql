@@ -0,0 +1,4 @@
+-- A task can be assigned to a user who can see it. The assignment is dropped along with the user.
+ALTER TABLE tasks ADD COLUMN assignee_id INTEGER REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_assignee_id_created_at_idx ON tasks (assignee_id, created_at, id);
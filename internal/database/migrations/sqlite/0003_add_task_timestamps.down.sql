@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_userid_title_idx;
+DROP INDEX IF EXISTS tasks_userid_updated_at_idx;
+DROP INDEX IF EXISTS tasks_userid_created_at_idx;
+
+ALTER TABLE tasks DROP COLUMN updated_at;
+ALTER TABLE tasks DROP COLUMN created_at;
@@ -0,0 +1,12 @@
+-- SQLite doesn't allow a non-constant default when adding a column, so existing rows are
+-- backfilled with the current time afterwards.
+ALTER TABLE tasks ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00.000000';
+ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00.000000';
+
+UPDATE tasks SET
+    created_at = strftime('%Y-%m-%d %H:%M:%f000', 'now'),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f000', 'now');
+
+CREATE INDEX tasks_userid_created_at_idx ON tasks (userID, created_at, id);
+CREATE INDEX tasks_userid_updated_at_idx ON tasks (userID, updated_at, id);
+CREATE INDEX tasks_userid_title_idx ON tasks (userID, title, id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tasks_userid_due_at_idx;
+
+ALTER TABLE tasks DROP COLUMN start_at;
+ALTER TABLE tasks DROP COLUMN due_at;
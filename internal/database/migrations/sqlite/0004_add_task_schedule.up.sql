@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN start_at TIMESTAMP;
+
+CREATE INDEX tasks_userid_due_at_idx ON tasks (userID, due_at, id);
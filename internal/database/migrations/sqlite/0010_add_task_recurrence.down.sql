@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN recurrence_anchor;
+ALTER TABLE tasks DROP COLUMN recurrence;
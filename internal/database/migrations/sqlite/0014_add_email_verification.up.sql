@@ -0,0 +1,15 @@
+-- Users verify their email with a link sent by email. The existing users are considered verified.
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMP;
+UPDATE users SET verified_at = strftime('%Y-%m-%d %H:%M:%f000', 'now');
+
+-- Email verification tokens are stored hashed along with the email they verify, and deleted once used.
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX email_verification_tokens_userid_idx ON email_verification_tokens (userID);
@@ -0,0 +1,19 @@
+-- Failed login attempts, keyed by account or IP address, when they are tracked in the database.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+-- Security-relevant events, such as lockouts.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userID INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX audit_log_userid_idx ON audit_log (userID);
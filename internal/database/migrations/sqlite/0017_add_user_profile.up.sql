@@ -0,0 +1,7 @@
+-- Profile settings of the users, and when they registered. SQLite can't add a column with a
+-- non-constant default, so the creation time of the existing users is set afterwards.
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN created_at TIMESTAMP;
+UPDATE users SET created_at = strftime('%Y-%m-%d %H:%M:%f000', 'now');
@@ -0,0 +1,13 @@
+-- Exports of the personal data of the users, built in the background. The archive is kept until the
+-- export expires.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    archive BLOB,
+    expires_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX data_exports_userid_idx ON data_exports (userID);
@@ -0,0 +1,3 @@
+-- Role of the users, and when their account was disabled by an admin.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
@@ -0,0 +1,35 @@
+-- Workspaces share a backlog of tasks between their members. Each member has a role, and every
+-- workspace has a single owner.
+CREATE TABLE IF NOT EXISTS workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    userID INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+    PRIMARY KEY (workspace_id, userID)
+);
+
+CREATE INDEX workspace_members_userid_idx ON workspace_members (userID);
+
+-- Invites are sent by email with a token, stored hashed, and deleted once accepted.
+CREATE TABLE IF NOT EXISTS workspace_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX workspace_invites_workspace_id_idx ON workspace_invites (workspace_id);
+
+-- The tasks of a workspace belong to it rather than to the user who created them.
+ALTER TABLE tasks ADD COLUMN workspace_id INTEGER REFERENCES workspaces (id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_workspace_id_created_at_idx ON tasks (workspace_id, created_at, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_assignee_id_created_at_idx;
+
+ALTER TABLE tasks DROP COLUMN assignee_id;
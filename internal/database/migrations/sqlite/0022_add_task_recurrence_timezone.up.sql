@@ -0,0 +1,2 @@
+-- Timezone the series of a recurring task is expanded in, so that it keeps its time of day across DST changes.
+ALTER TABLE tasks ADD COLUMN recurrence_timezone TEXT;
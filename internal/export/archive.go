@@ -0,0 +1,208 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// profile is the profile of the user in the archive.
+type profile struct {
+	*models.User
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+// exportedTask is a task in the archive, along with the IDs of the tasks blocking it.
+type exportedTask struct {
+	models.Task
+	BlockedBy []uint `json:"blocked_by"`
+}
+
+// archiveWriter writes the files of a zip archive, keeping the first error.
+type archiveWriter struct {
+	zw  *zip.Writer
+	err error
+}
+
+// BuildArchive builds the zip archive of everything tied to a user: their profile, tasks, labels,
+// projects, workspaces, personal access tokens and security events. Each is written both as JSON and as CSV, except
+// the profile.
+func BuildArchive(app *config.Application, user *models.User) ([]byte, error) {
+	tasks, err := app.TaskRepository.ListTasksByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	exportedTasks := make([]exportedTask, 0, len(tasks))
+	for _, task := range tasks {
+		labels, err := app.LabelRepository.ListLabelsByTaskID(task.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the labels of task %d: %w", task.ID, err)
+		}
+		task.Labels = labels
+		blockers, err := app.TaskRepository.ListBlockers(task.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the blockers of task %d: %w", task.ID, err)
+		}
+		blockerIDs := make([]uint, 0, len(blockers))
+		for _, blocker := range blockers {
+			blockerIDs = append(blockerIDs, blocker.ID)
+		}
+		exportedTasks = append(exportedTasks, exportedTask{Task: task, BlockedBy: blockerIDs})
+	}
+	labels, err := app.LabelRepository.ListLabelsByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	projects, err := app.ProjectRepository.ListProjectsByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	workspaces, err := app.WorkspaceRepository.ListWorkspaceMemberships(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	tokens, err := app.TokenRepository.ListPersonalAccessTokens(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	events, err := app.AuditRepository.ListUserAuditEntries(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log entries: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := &archiveWriter{zw: zip.NewWriter(&buf)}
+
+	w.writeJSON("profile.json", profile{User: user, TwoFactorEnabled: user.TOTPEnabledAt != nil})
+
+	w.writeJSON("tasks.json", exportedTasks)
+	taskRows := [][]string{{"id", "title", "description", "status", "priority", "labels", "project_id", "parent_id", "workspace_id", "assignee_id", "blocked_by", "due_at", "start_at", "recurrence", "created_at", "updated_at"}}
+	for _, task := range exportedTasks {
+		labelNames := make([]string, 0, len(task.Labels))
+		for _, label := range task.Labels {
+			labelNames = append(labelNames, label.Name)
+		}
+		blockerIDs := make([]string, 0, len(task.BlockedBy))
+		for _, id := range task.BlockedBy {
+			blockerIDs = append(blockerIDs, formatID(id))
+		}
+		recurrence := ""
+		if task.Recurrence != nil {
+			recurrence = *task.Recurrence
+		}
+		taskRows = append(taskRows, []string{formatID(task.ID), task.Title, task.Description, string(task.Status), string(task.Priority),
+			strings.Join(labelNames, ";"), formatOptionalID(task.ProjectID), formatOptionalID(task.ParentID), formatOptionalID(task.WorkspaceID), formatOptionalID(task.AssigneeID), strings.Join(blockerIDs, ";"),
+			formatOptionalTime(task.DueAt), formatOptionalTime(task.StartAt), recurrence, formatTime(task.CreatedAt), formatTime(task.UpdatedAt)})
+	}
+	w.writeCSV("tasks.csv", taskRows)
+
+	w.writeJSON("labels.json", labels)
+	labelRows := [][]string{{"id", "name", "color"}}
+	for _, label := range labels {
+		labelRows = append(labelRows, []string{formatID(label.ID), label.Name, label.Color})
+	}
+	w.writeCSV("labels.csv", labelRows)
+
+	w.writeJSON("projects.json", projects)
+	projectRows := [][]string{{"id", "name", "is_inbox", "created_at"}}
+	for _, project := range projects {
+		projectRows = append(projectRows, []string{formatID(project.ID), project.Name, strconv.FormatBool(project.IsInbox), formatTime(project.CreatedAt)})
+	}
+	w.writeCSV("projects.csv", projectRows)
+
+	w.writeJSON("workspaces.json", workspaces)
+	workspaceRows := [][]string{{"id", "name", "role", "created_at"}}
+	for _, workspace := range workspaces {
+		workspaceRows = append(workspaceRows, []string{formatID(workspace.ID), workspace.Name, string(workspace.Role), formatTime(workspace.CreatedAt)})
+	}
+	w.writeCSV("workspaces.csv", workspaceRows)
+
+	w.writeJSON("personal_access_tokens.json", tokens)
+	tokenRows := [][]string{{"id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "created_at"}}
+	for _, token := range tokens {
+		tokenRows = append(tokenRows, []string{formatID(token.ID), token.Name, token.TokenPrefix, strings.Join(token.Scopes, " "),
+			formatOptionalTime(token.ExpiresAt), formatOptionalTime(token.LastUsedAt), formatTime(token.CreatedAt)})
+	}
+	w.writeCSV("personal_access_tokens.csv", tokenRows)
+
+	w.writeJSON("security_events.json", events)
+	eventRows := [][]string{{"id", "event", "ip", "details", "created_at"}}
+	for _, event := range events {
+		eventRows = append(eventRows, []string{formatID(event.ID), event.Event, event.IP, event.Details, formatTime(event.CreatedAt)})
+	}
+	w.writeCSV("security_events.csv", eventRows)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := w.zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes a value as an indented JSON file.
+func (w *archiveWriter) writeJSON(name string, v interface{}) {
+	if w.err != nil {
+		return
+	}
+	f, err := w.zw.Create(name)
+	if err != nil {
+		w.err = err
+		return
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	w.err = enc.Encode(v)
+}
+
+// writeCSV writes rows, starting with the header, as a CSV file.
+func (w *archiveWriter) writeCSV(name string, rows [][]string) {
+	if w.err != nil {
+		return
+	}
+	f, err := w.zw.Create(name)
+	if err != nil {
+		w.err = err
+		return
+	}
+	cw := csv.NewWriter(f)
+	w.err = cw.WriteAll(rows)
+}
+
+// formatID formats an ID for a CSV file.
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// formatOptionalID formats an optional ID for a CSV file, which is empty when unset.
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+
+	return formatID(*id)
+}
+
+// formatTime formats a time for a CSV file, in RFC 3339.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatOptionalTime formats an optional time for a CSV file, which is empty when unset.
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return formatTime(*t)
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// exportTimeout is how long an export may stay pending before a new one can be requested, should the
+// server have stopped while building it.
+const exportTimeout = 10 * time.Minute
+
+// exportResponse is a data export along with the URL to download its archive, once ready.
+type exportResponse struct {
+	models.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// CreateExportHandler handles the request of an export of the personal data of the authenticated user.
+// The archive is built in the background, and the user is notified by email once it's ready.
+func CreateExportHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	// Only one export can be built at a time.
+	latest, err := app.DataExportRepository.GetLatestDataExport(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to get data export details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export the data"})
+		return
+	}
+	if latest != nil && latest.Status == models.DataExportStatusPending && time.Since(latest.CreatedAt) < exportTimeout {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An export is already in progress"})
+		return
+	}
+
+	export, err := app.DataExportRepository.CreateDataExport(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to create data export: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export the data"})
+		return
+	}
+	go runExport(app, export.ID, userID)
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "The export has started. You will be notified by email once it's ready to download",
+		"export":  newExportResponse(export),
+	})
+}
+
+// GetExportsHandler handles retrieval of the data exports of the authenticated user.
+func GetExportsHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	exports, err := app.DataExportRepository.ListDataExports(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve data exports: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve data exports"})
+		return
+	}
+
+	response := make([]exportResponse, 0, len(exports))
+	for i := range exports {
+		response = append(response, newExportResponse(&exports[i]))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetExportByIDHandler handles the retrieval of a data export by ID only if it belongs to the
+// authenticated user.
+func GetExportByIDHandler(ctx *gin.Context, app *config.Application) {
+	export := getOwnedExport(ctx, app)
+	if export == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newExportResponse(export))
+}
+
+// DownloadExportHandler handles the download of the archive of a data export by ID only if it belongs
+// to the authenticated user, and is ready.
+func DownloadExportHandler(ctx *gin.Context, app *config.Application) {
+	export := getOwnedExport(ctx, app)
+	if export == nil {
+		return
+	}
+	if export.Status != models.DataExportStatusReady {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The export isn't ready"})
+		return
+	}
+
+	archive, err := app.DataExportRepository.GetDataExportArchive(export.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get data export archive from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the archive"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="task-manager-export-%d.zip"`, export.ID))
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+// runExport builds the archive of a data export and notifies the user by email once it's ready.
+func runExport(app *config.Application, exportID, userID uint) {
+	user, err := app.UserRepository.GetUserByID(userID)
+	if err == nil && user == nil {
+		err = fmt.Errorf("user %d not found", userID)
+	}
+	var archive []byte
+	if err == nil {
+		archive, err = BuildArchive(app, user)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to build data export %d: %v", exportID, err)
+		if err := app.DataExportRepository.FailDataExport(exportID); err != nil {
+			log.Printf("Warning: Failed to mark data export %d as failed: %v", exportID, err)
+		}
+		return
+	}
+
+	expiresAt := time.Now().Add(app.Config.DataExportTTL)
+	if err := app.DataExportRepository.CompleteDataExport(exportID, archive, expiresAt); err != nil {
+		log.Printf("Warning: Failed to store data export %d: %v", exportID, err)
+		if err := app.DataExportRepository.FailDataExport(exportID); err != nil {
+			log.Printf("Warning: Failed to mark data export %d as failed: %v", exportID, err)
+		}
+		return
+	}
+
+	if err := app.Mailer.Send(exportReadyMessage(app.Config.AppURL, user.Email, expiresAt)); err != nil {
+		log.Printf("Warning: Failed to send data export email to user %d: %v", userID, err)
+	}
+}
+
+// getOwnedExport retrieves the data export of the ID in the URL, only if it belongs to the
+// authenticated user. Otherwise, it writes the error response and returns nil.
+func getOwnedExport(ctx *gin.Context, app *config.Application) *models.DataExport {
+	userID := ctx.MustGet("userID").(uint)
+	exportID := ctx.MustGet("exportID").(uint)
+
+	// Retrieve the export from the database
+	export, err := app.DataExportRepository.GetDataExportByID(exportID)
+	if err != nil {
+		log.Printf("Warning: Failed to get data export details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve data export"})
+		return nil
+	}
+
+	// Check if the export is associated with the authenticated user, and hasn't expired
+	if export == nil || export.UserID != userID || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Data export not found"})
+		return nil
+	}
+
+	return export
+}
+
+// newExportResponse returns a data export along with the URL to download its archive, once ready.
+func newExportResponse(export *models.DataExport) exportResponse {
+	response := exportResponse{DataExport: *export}
+	if export.Status == models.DataExportStatusReady {
+		response.DownloadURL = fmt.Sprintf("/api/me/exports/%d/download", export.ID)
+	}
+
+	return response
+}
+
+// exportReadyMessage builds the email notifying a user that their data export is ready.
+func exportReadyMessage(appURL, email string, expiresAt time.Time) mail.Message {
+	link := strings.TrimSuffix(appURL, "/") + "/account/exports"
+
+	return mail.Message{
+		To:      email,
+		Subject: "Your data export is ready",
+		Body: "The export of your Task Manager data you asked for is ready.\n\n" +
+			"You can download it from the following page before " + expiresAt.UTC().Format(time.RFC1123) + ":\n\n" +
+			link + "\n\n" +
+			"If you didn't ask for it, please change your password, as someone may have access to your account.",
+	}
+}
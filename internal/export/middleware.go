@@ -0,0 +1,22 @@
+package export
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractExportIDMiddleware extract the data export ID from URL parameters.
+func ExtractExportIDMiddleware(ctx *gin.Context) {
+	exportIDStr := ctx.Param("exportID")
+	exportID, err := strconv.ParseUint(exportIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	// Store the export ID in the context
+	ctx.Set("exportID", uint(exportID))
+	ctx.Next()
+}
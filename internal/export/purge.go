@@ -0,0 +1,32 @@
+package export
+
+import (
+	"log"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// PurgeExpiredExports removes the expired data exports, along with their archives, every interval. It
+// never returns, so it's meant to run in its own goroutine.
+func PurgeExpiredExports(app *config.Application, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeExpiredExports(app)
+		<-ticker.C
+	}
+}
+
+// purgeExpiredExports removes the expired data exports once.
+func purgeExpiredExports(app *config.Application) {
+	deleted, err := app.DataExportRepository.DeleteExpiredDataExports()
+	if err != nil {
+		log.Printf("Warning: Failed to remove expired data exports: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Removed %d expired data exports", deleted)
+	}
+}
@@ -0,0 +1,206 @@
+package label
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// labelData holds the label details.
+type labelData struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+const (
+	// invalidNameMessage is the error returned when a label name is blank or too long.
+	invalidNameMessage = "Invalid name. It must not be empty nor longer than 50 characters"
+	// invalidColorMessage is the error returned when a label color isn't a hex color.
+	invalidColorMessage = `Invalid color. It must be a hex color, e.g. "#1f77b4"`
+)
+
+// GetLabelsHandler handles retrieval of the labels of the authenticated user.
+func GetLabelsHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	labels, err := app.LabelRepository.ListLabelsByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve labels: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve labels"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, labels)
+}
+
+// CreateLabelHandler handles the creation of a new label.
+func CreateLabelHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	var ld labelData
+	if err := ctx.ShouldBindJSON(&ld); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	ld.Name = strings.TrimSpace(ld.Name)
+	if !validator.IsValidLabelName(ld.Name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+		return
+	}
+	if !validator.IsValidColor(ld.Color) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidColorMessage})
+		return
+	}
+
+	// Check if the user already has a label with the name.
+	if !checkNameAvailable(ctx, app, userID, ld.Name, 0) {
+		return
+	}
+
+	// Store label details in the database.
+	label := &models.Label{
+		Name:   ld.Name,
+		Color:  strings.ToLower(ld.Color),
+		UserID: userID,
+	}
+	newLabel, err := app.LabelRepository.CreateLabel(label)
+	if err != nil {
+		log.Printf("Warning: Failed to create label: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Label created successfully",
+		"label":   newLabel,
+	})
+}
+
+// GetLabelByIDHandler handles the retrieval of a label by ID only if it belongs to the authenticated user.
+func GetLabelByIDHandler(ctx *gin.Context, app *config.Application) {
+	label := getOwnedLabel(ctx, app)
+	if label == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, label)
+}
+
+// UpdateLabelByIDHandler handles the updating of a label by ID only if it belongs to the authenticated user.
+func UpdateLabelByIDHandler(ctx *gin.Context, app *config.Application) {
+	label := getOwnedLabel(ctx, app)
+	if label == nil {
+		return
+	}
+
+	// Parse request body to get updated details
+	var ld labelData
+	if err := ctx.ShouldBindJSON(&ld); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Update only provided fields
+	if !validator.IsBlank(ld.Name) {
+		ld.Name = strings.TrimSpace(ld.Name)
+		if !validator.IsValidLabelName(ld.Name) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+			return
+		}
+		if !checkNameAvailable(ctx, app, label.UserID, ld.Name, label.ID) {
+			return
+		}
+
+		label.Name = ld.Name
+	}
+	if !validator.IsBlank(ld.Color) {
+		if !validator.IsValidColor(ld.Color) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidColorMessage})
+			return
+		}
+
+		label.Color = strings.ToLower(ld.Color)
+	}
+
+	// Update the label in the database
+	updatedLabel, err := app.LabelRepository.UpdateLabel(label)
+	if err != nil {
+		log.Printf("Warning: Failed to update label: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Label updated successfully",
+		"label":   updatedLabel,
+	})
+}
+
+// DeleteLabelByIDHandler handles the deletion of a label by ID only if it belongs to the authenticated user.
+// The label is detached from all of its tasks.
+func DeleteLabelByIDHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+	labelID := ctx.MustGet("labelID").(uint)
+
+	// Delete the label from the database
+	err := app.LabelRepository.DeleteLabel(labelID, userID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to delete label from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Label deleted successfully"})
+}
+
+// getOwnedLabel retrieves the label identified in the URL. If it doesn't belong to the authenticated
+// user, or can't be retrieved, it writes the error response and returns nil.
+func getOwnedLabel(ctx *gin.Context, app *config.Application) *models.Label {
+	userID := ctx.MustGet("userID").(uint)
+	labelID := ctx.MustGet("labelID").(uint)
+
+	// Retrieve the label from the database
+	label, err := app.LabelRepository.GetLabelByID(labelID)
+	if err != nil {
+		log.Printf("Warning: Failed to get label details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve label"})
+		return nil
+	}
+
+	// Check if the label is associated with the authenticated user
+	if label == nil || label.UserID != userID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		return nil
+	}
+
+	return label
+}
+
+// checkNameAvailable checks that the user has no label with the name other than the excluded one.
+// Otherwise, it writes the error response and returns false.
+func checkNameAvailable(ctx *gin.Context, app *config.Application, userID uint, name string, excludedID uint) bool {
+	existing, err := app.LabelRepository.GetLabelByName(userID, name)
+	if err != nil {
+		log.Printf("Warning: Failed to get label details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the label name"})
+		return false
+	}
+	if existing != nil && existing.ID != excludedID {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Label name already exists"})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,22 @@
+package label
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractLabelIDMiddleware extract the label ID from URL parameters.
+func ExtractLabelIDMiddleware(ctx *gin.Context) {
+	labelIDStr := ctx.Param("labelID")
+	labelID, err := strconv.ParseUint(labelIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid label ID"})
+		return
+	}
+
+	// Store the label ID in the context
+	ctx.Set("labelID", uint(labelID))
+	ctx.Next()
+}
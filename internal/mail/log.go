@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer provides an implementation of Mailer writing emails to a log instead of sending them,
+// for local development.
+type LogMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewLogMailer creates a new instance of LogMailer writing to w, e.g. the standard output or a file.
+func NewLogMailer(w io.Writer, from string) *LogMailer {
+	return &LogMailer{w: w, from: from}
+}
+
+// Send writes an email to the log.
+func (m *LogMailer) Send(msg Message) error {
+	to, subject, err := encodeHeaders(msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err = fmt.Fprintf(m.w, "Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), m.from, to, subject, msg.Body)
+	return err
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"errors"
+	"mime"
+	"strings"
+)
+
+// ErrInvalidHeader is returned when sending a message whose recipient or subject contains a line break,
+// which would add headers to the email or start its body early.
+var ErrInvalidHeader = errors.New("mail: line break in the recipient or the subject")
+
+// Message represents a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer provides an interface to send emails.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// encodeHeaders checks that the recipient and the subject of a message fit on their header lines, and
+// encodes the subject as defined by RFC 2047 unless it's plain ASCII.
+func encodeHeaders(msg Message) (to, subject string, err error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return "", "", ErrInvalidHeader
+	}
+
+	return msg.To, mime.QEncoding.Encode("UTF-8", msg.Subject), nil
+}
@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogMailerHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     Message
+		subject string
+		wantErr error
+	}{
+		{
+			name:    "plain subject",
+			msg:     Message{To: "alice@example.com", Subject: "You're invited to join Team"},
+			subject: "Subject: You're invited to join Team\n",
+		},
+		{
+			name:    "non-ASCII subject",
+			msg:     Message{To: "alice@example.com", Subject: "You're invited to join Équipe"},
+			subject: "Subject: =?UTF-8?q?You're_invited_to_join_=C3=89quipe?=\n",
+		},
+		{
+			name:    "line break in subject",
+			msg:     Message{To: "alice@example.com", Subject: "team\r\nBcc: victim@example.com\r\nX-Injected: yes"},
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "line break in recipient",
+			msg:     Message{To: "alice@example.com\nBcc: victim@example.com", Subject: "Hello"},
+			wantErr: ErrInvalidHeader,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			err := NewLogMailer(&log, "noreply@example.com").Send(tt.msg)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if log.Len() != 0 {
+					t.Errorf("got %q written, want nothing", log.String())
+				}
+				return
+			}
+			if !strings.Contains(log.String(), tt.subject) {
+				t.Errorf("got %q, want it to contain %q", log.String(), tt.subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer provides an implementation of Mailer sending emails through an SMTP server.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer creates a new instance of SMTPMailer. The server is only authenticated with when a
+// username is given.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{addr: net.JoinHostPort(host, port), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+// Send sends an email through the SMTP server.
+func (m *SMTPMailer) Send(msg Message) error {
+	to, subject, err := encodeHeaders(msg)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	// The envelope sender is the bare address, without the display name.
+	sender := m.from
+	if addr, err := netmail.ParseAddress(m.from); err == nil {
+		sender = addr.Address
+	}
+
+	return smtp.SendMail(m.addr, m.auth, sender, []string{to}, []byte(b.String()))
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// The events recorded in the audit log.
+const (
+	AuditEventLoginLocked         = "login_locked"
+	AuditEventRoleChanged         = "role_changed"
+	AuditEventAccountDisabled     = "account_disabled"
+	AuditEventAccountEnabled      = "account_enabled"
+	AuditEventPasswordResetForced = "password_reset_forced"
+	AuditEventSessionsRevoked     = "sessions_revoked"
+)
+
+// AuditEntry represents a security-relevant event recorded in the audit log. UserID is nil when the
+// event doesn't concern a known user, e.g. the lockout of an IP address.
+type AuditEntry struct {
+	ID        uint      `json:"id"`
+	UserID    *uint     `json:"user_id"`
+	Event     string    `json:"event"`
+	IP        string    `json:"ip"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditStore provides an interface for storage operations related to the audit log.
+type AuditStore interface {
+	CreateAuditEntry(entry *AuditEntry) error
+	ListUserAuditEntries(userID uint) ([]AuditEntry, error)
+}
+
+// AuditRepository provides an implementation of AuditStore backed by an SQL database.
+type AuditRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewAuditRepository creates a new instance of AuditRepository.
+func NewAuditRepository(db *sql.DB, dialect database.Dialect) *AuditRepository {
+	return &AuditRepository{db: db, dialect: dialect}
+}
+
+// CreateAuditEntry inserts a new entry into the audit log in the database and sets its ID.
+func (r *AuditRepository) CreateAuditEntry(entry *AuditEntry) error {
+	now := time.Now()
+	err := r.db.QueryRow("INSERT INTO audit_log (userID, event, ip, details, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		entry.UserID, entry.Event, entry.IP, entry.Details, r.dialect.Time(now)).Scan(&entry.ID)
+	if err != nil {
+		return err
+	}
+	entry.CreatedAt = now
+
+	return nil
+}
+
+// ListUserAuditEntries retrieves the audit log entries of a user from the database, oldest first.
+func (r *AuditRepository) ListUserAuditEntries(userID uint) ([]AuditEntry, error) {
+	rows, err := r.db.Query("SELECT id, userID, event, ip, details, created_at FROM audit_log WHERE userID = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var entryUserID sql.NullInt64
+		if err := rows.Scan(&entry.ID, &entryUserID, &entry.Event, &entry.IP, &entry.Details, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.UserID = nullIDPtr(entryUserID)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
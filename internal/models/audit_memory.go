@@ -0,0 +1,41 @@
+package models
+
+// MemoryAuditRepository provides an implementation of AuditStore backed by a MemoryDB.
+type MemoryAuditRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryAuditRepository creates a new instance of MemoryAuditRepository.
+func NewMemoryAuditRepository(mdb *MemoryDB) *MemoryAuditRepository {
+	return &MemoryAuditRepository{mdb: mdb}
+}
+
+// CreateAuditEntry inserts a new entry into the audit log in the datastore and sets its ID.
+func (r *MemoryAuditRepository) CreateAuditEntry(entry *AuditEntry) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	newEntry := *entry
+	newEntry.ID = r.mdb.nextID("audit_log")
+	newEntry.CreatedAt = memoryNow()
+	r.mdb.auditLog = append(r.mdb.auditLog, newEntry)
+	entry.ID = newEntry.ID
+	entry.CreatedAt = newEntry.CreatedAt
+
+	return nil
+}
+
+// ListUserAuditEntries retrieves the audit log entries of a user from the datastore, oldest first.
+func (r *MemoryAuditRepository) ListUserAuditEntries(userID uint) ([]AuditEntry, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	entries := []AuditEntry{}
+	for _, entry := range r.mdb.auditLog {
+		if entry.UserID != nil && *entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
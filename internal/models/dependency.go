@@ -0,0 +1,286 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// ErrDependencyCycle is returned when a task would end up blocked by itself, directly or through other tasks.
+var ErrDependencyCycle = errors.New("task dependency would create a cycle")
+
+// TaskDependency represents a task that is blocked by another task.
+type TaskDependency struct {
+	TaskID    uint `json:"task_id"`
+	BlockerID uint `json:"blocker_id"`
+}
+
+// TaskGraph holds a set of tasks and the dependencies between them.
+type TaskGraph struct {
+	Tasks        []Task
+	Dependencies []TaskDependency
+}
+
+// blockedExpression is the SQL expression computing whether the task of the current row of the
+// tasks table is blocked by a task that isn't done yet.
+const blockedExpression = "EXISTS (SELECT 1 FROM task_dependencies JOIN tasks AS blockers ON blockers.id = task_dependencies.blocker_id" +
+	" WHERE task_dependencies.task_id = tasks.id AND blockers.status <> '" + string(TaskStatusDone) + "')"
+
+// CriticalPath returns the longest chain of tasks that aren't done yet and that the task is
+// transitively blocked by, in the order they should be done, ending with the task itself. Ties
+// between chains of the same length are broken in favor of the tasks with the lowest IDs.
+func (g *TaskGraph) CriticalPath(taskID uint) []Task {
+	tasks := make(map[uint]Task, len(g.Tasks))
+	for _, task := range g.Tasks {
+		tasks[task.ID] = task
+	}
+
+	blockers := make(map[uint][]uint)
+	for _, dep := range g.Dependencies {
+		if blocker, ok := tasks[dep.BlockerID]; ok && blocker.Status != TaskStatusDone {
+			blockers[dep.TaskID] = append(blockers[dep.TaskID], dep.BlockerID)
+		}
+	}
+	for id := range blockers {
+		sort.Slice(blockers[id], func(i, j int) bool { return blockers[id][i] < blockers[id][j] })
+	}
+
+	// longest holds the longest chain ending with each task, blockers first. The graph is acyclic,
+	// so the recursion terminates.
+	longest := make(map[uint][]uint)
+	var chain func(id uint) []uint
+	chain = func(id uint) []uint {
+		if c, ok := longest[id]; ok {
+			return c
+		}
+
+		var best []uint
+		for _, blockerID := range blockers[id] {
+			if c := chain(blockerID); len(c) > len(best) {
+				best = c
+			}
+		}
+
+		c := append(append([]uint{}, best...), id)
+		longest[id] = c
+		return c
+	}
+
+	path := []Task{}
+	for _, id := range chain(taskID) {
+		if task, ok := tasks[id]; ok {
+			path = append(path, task)
+		}
+	}
+
+	return path
+}
+
+// upstreamCTE is a recursive common table expression selecting the IDs of the tasks that the task $1
+// is transitively blocked by.
+const upstreamCTE = "WITH RECURSIVE upstream (id) AS (" +
+	"SELECT blocker_id FROM task_dependencies WHERE task_id = $1" +
+	" UNION SELECT task_dependencies.blocker_id FROM task_dependencies JOIN upstream ON task_dependencies.task_id = upstream.id" +
+	") "
+
+// AddDependency declares that a task is blocked by another task in the database, which must both exist.
+// Declaring it twice has no effect.
+func (r *TaskRepository) AddDependency(taskID, blockerID uint) error {
+	if taskID == blockerID {
+		return ErrDependencyCycle
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id IN ($1, $2)", taskID, blockerID).Scan(&count); err != nil {
+		return err
+	}
+	if count < 2 {
+		return ErrUnknownRecord
+	}
+
+	// The blocker must not already be blocked by the task, directly or not.
+	if err := tx.QueryRow(upstreamCTE+"SELECT COUNT(*) FROM upstream WHERE id = $2", blockerID, taskID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDependencyCycle
+	}
+
+	if _, err := tx.Exec("INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", taskID, blockerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveDependency removes the dependency of a task on another task from the database.
+func (r *TaskRepository) RemoveDependency(taskID, blockerID uint) error {
+	res, err := r.db.Exec("DELETE FROM task_dependencies WHERE task_id = $1 AND blocker_id = $2", taskID, blockerID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// ListBlockers retrieves the tasks that directly block a task from the database.
+func (r *TaskRepository) ListBlockers(taskID uint) ([]Task, error) {
+	return r.list("SELECT "+taskColumns+" FROM tasks WHERE id IN (SELECT blocker_id FROM task_dependencies WHERE task_id = $1) ORDER BY id", taskID)
+}
+
+// GetUpstreamGraph retrieves the tasks that a task is transitively blocked by, along with the
+// task itself and the dependencies between all of them, from the database.
+func (r *TaskRepository) GetUpstreamGraph(taskID uint) (*TaskGraph, error) {
+	tasks, err := r.list(upstreamCTE+"SELECT "+taskColumns+" FROM tasks WHERE id = $1 OR id IN (SELECT id FROM upstream) ORDER BY id", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(upstreamCTE+"SELECT task_id, blocker_id FROM task_dependencies"+
+		" WHERE task_id = $1 OR task_id IN (SELECT id FROM upstream) ORDER BY task_id, blocker_id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := &TaskGraph{Tasks: tasks, Dependencies: []TaskDependency{}}
+	for rows.Next() {
+		var dep TaskDependency
+		if err := rows.Scan(&dep.TaskID, &dep.BlockerID); err != nil {
+			return nil, err
+		}
+		graph.Dependencies = append(graph.Dependencies, dep)
+	}
+
+	return graph, rows.Err()
+}
+
+// AddDependency declares that a task is blocked by another task in the datastore. Declaring it twice
+// has no effect.
+func (r *MemoryTaskRepository) AddDependency(taskID, blockerID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.tasks[taskID]; !ok {
+		return ErrUnknownRecord
+	}
+	if _, ok := r.mdb.tasks[blockerID]; !ok {
+		return ErrUnknownRecord
+	}
+	if taskID == blockerID || r.mdb.upstreamTaskIDs(blockerID)[taskID] {
+		return ErrDependencyCycle
+	}
+
+	if r.mdb.dependencies[taskID] == nil {
+		r.mdb.dependencies[taskID] = make(map[uint]bool)
+	}
+	r.mdb.dependencies[taskID][blockerID] = true
+
+	return nil
+}
+
+// RemoveDependency removes the dependency of a task on another task from the datastore.
+func (r *MemoryTaskRepository) RemoveDependency(taskID, blockerID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if !r.mdb.dependencies[taskID][blockerID] {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	delete(r.mdb.dependencies[taskID], blockerID)
+
+	return nil
+}
+
+// ListBlockers retrieves the tasks that directly block a task from the datastore.
+func (r *MemoryTaskRepository) ListBlockers(taskID uint) ([]Task, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	tasks := []Task{}
+	for blockerID := range r.mdb.dependencies[taskID] {
+		tasks = append(tasks, r.mdb.taskView(r.mdb.tasks[blockerID]))
+	}
+	sortTasks(tasks, func(a, b *Task) bool { return a.ID < b.ID })
+
+	return tasks, nil
+}
+
+// GetUpstreamGraph retrieves the tasks that a task is transitively blocked by, along with the
+// task itself and the dependencies between all of them, from the datastore.
+func (r *MemoryTaskRepository) GetUpstreamGraph(taskID uint) (*TaskGraph, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	ids := r.mdb.upstreamTaskIDs(taskID)
+	ids[taskID] = true
+
+	graph := &TaskGraph{Tasks: []Task{}, Dependencies: []TaskDependency{}}
+	for id := range ids {
+		task, ok := r.mdb.tasks[id]
+		if !ok {
+			continue
+		}
+		graph.Tasks = append(graph.Tasks, r.mdb.taskView(task))
+		for blockerID := range r.mdb.dependencies[id] {
+			graph.Dependencies = append(graph.Dependencies, TaskDependency{TaskID: id, BlockerID: blockerID})
+		}
+	}
+	sortTasks(graph.Tasks, func(a, b *Task) bool { return a.ID < b.ID })
+	sort.Slice(graph.Dependencies, func(i, j int) bool {
+		a, b := graph.Dependencies[i], graph.Dependencies[j]
+		if a.TaskID != b.TaskID {
+			return a.TaskID < b.TaskID
+		}
+		return a.BlockerID < b.BlockerID
+	})
+
+	return graph, nil
+}
+
+// upstreamTaskIDs returns the set of the IDs of the tasks that a task is transitively blocked by.
+// The caller must hold the lock.
+func (m *MemoryDB) upstreamTaskIDs(taskID uint) map[uint]bool {
+	ids := make(map[uint]bool)
+	pending := []uint{taskID}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for blockerID := range m.dependencies[id] {
+			if !ids[blockerID] {
+				ids[blockerID] = true
+				pending = append(pending, blockerID)
+			}
+		}
+	}
+
+	return ids
+}
+
+// taskView returns a copy of a stored task with its computed fields set. The caller must hold the lock.
+func (m *MemoryDB) taskView(task Task) Task {
+	task.Blocked = false
+	for blockerID := range m.dependencies[task.ID] {
+		if m.tasks[blockerID].Status != TaskStatusDone {
+			task.Blocked = true
+			break
+		}
+	}
+
+	return task
+}
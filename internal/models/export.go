@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// The statuses of a data export.
+const (
+	DataExportStatusPending = "pending"
+	DataExportStatusReady   = "ready"
+	DataExportStatusFailed  = "failed"
+)
+
+// DataExport represents an export of the personal data of a user, built in the background. The archive
+// can be downloaded once ready, until ExpiresAt. Size is the size of the archive in bytes.
+type DataExport struct {
+	ID          uint       `json:"id"`
+	UserID      uint       `json:"-"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DataExportStore provides an interface for storage operations related to data exports.
+type DataExportStore interface {
+	CreateDataExport(userID uint) (*DataExport, error)
+	GetDataExportByID(exportID uint) (*DataExport, error)
+	GetLatestDataExport(userID uint) (*DataExport, error)
+	ListDataExports(userID uint) ([]DataExport, error)
+	CompleteDataExport(exportID uint, archive []byte, expiresAt time.Time) error
+	FailDataExport(exportID uint) error
+	GetDataExportArchive(exportID uint) ([]byte, error)
+	DeleteExpiredDataExports() (int64, error)
+}
+
+// dataExportColumns lists the columns of the data_exports table in the order expected by scanDataExport.
+const dataExportColumns = "id, userID, status, COALESCE(LENGTH(archive), 0), expires_at, completed_at, created_at"
+
+// scanDataExport scans a row selected with dataExportColumns into a data export.
+func scanDataExport(row rowScanner) (*DataExport, error) {
+	var export DataExport
+	var expiresAt, completedAt sql.NullTime
+	if err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.Size, &expiresAt, &completedAt, &export.CreatedAt); err != nil {
+		return nil, err
+	}
+	export.ExpiresAt = nullTimePtr(expiresAt)
+	export.CompletedAt = nullTimePtr(completedAt)
+
+	return &export, nil
+}
+
+// DataExportRepository provides an implementation of DataExportStore backed by an SQL database.
+type DataExportRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewDataExportRepository creates a new instance of DataExportRepository.
+func NewDataExportRepository(db *sql.DB, dialect database.Dialect) *DataExportRepository {
+	return &DataExportRepository{db: db, dialect: dialect}
+}
+
+// CreateDataExport inserts a new pending data export of a user into the database.
+func (r *DataExportRepository) CreateDataExport(userID uint) (*DataExport, error) {
+	row := r.db.QueryRow("INSERT INTO data_exports (userID, status, created_at) VALUES ($1, $2, $3) RETURNING "+dataExportColumns,
+		userID, DataExportStatusPending, r.dialect.Time(time.Now()))
+
+	return scanDataExport(row)
+}
+
+// GetDataExportByID retrieves a data export by ID from the database, without its archive.
+func (r *DataExportRepository) GetDataExportByID(exportID uint) (*DataExport, error) {
+	export, err := scanDataExport(r.db.QueryRow("SELECT "+dataExportColumns+" FROM data_exports WHERE id = $1", exportID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// GetLatestDataExport retrieves the latest data export of a user from the database, without its archive.
+func (r *DataExportRepository) GetLatestDataExport(userID uint) (*DataExport, error) {
+	row := r.db.QueryRow("SELECT "+dataExportColumns+" FROM data_exports WHERE userID = $1 ORDER BY created_at DESC, id DESC LIMIT 1", userID)
+
+	export, err := scanDataExport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// ListDataExports retrieves the data exports of a user from the database, latest first, without their
+// archives.
+func (r *DataExportRepository) ListDataExports(userID uint) ([]DataExport, error) {
+	rows, err := r.db.Query("SELECT "+dataExportColumns+" FROM data_exports WHERE userID = $1 ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []DataExport{}
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+
+	return exports, rows.Err()
+}
+
+// CompleteDataExport stores the archive of a data export in the database, which is then ready until it
+// expires.
+func (r *DataExportRepository) CompleteDataExport(exportID uint, archive []byte, expiresAt time.Time) error {
+	_, err := r.db.Exec("UPDATE data_exports SET status = $1, archive = $2, expires_at = $3, completed_at = $4 WHERE id = $5",
+		DataExportStatusReady, archive, r.dialect.Time(expiresAt), r.dialect.Time(time.Now()), exportID)
+	return err
+}
+
+// FailDataExport marks a data export as failed in the database.
+func (r *DataExportRepository) FailDataExport(exportID uint) error {
+	_, err := r.db.Exec("UPDATE data_exports SET status = $1, completed_at = $2 WHERE id = $3",
+		DataExportStatusFailed, r.dialect.Time(time.Now()), exportID)
+	return err
+}
+
+// GetDataExportArchive retrieves the archive of a data export from the database. It returns nil if the
+// export doesn't exist, isn't ready or has expired, in which case its archive is removed.
+func (r *DataExportRepository) GetDataExportArchive(exportID uint) ([]byte, error) {
+	var archive []byte
+	var expiresAt sql.NullTime
+	err := r.db.QueryRow("SELECT archive, expires_at FROM data_exports WHERE id = $1", exportID).Scan(&archive, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		if _, err := r.db.Exec("UPDATE data_exports SET archive = NULL WHERE id = $1", exportID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	return archive, nil
+}
+
+// DeleteExpiredDataExports removes the data exports that have expired from the database, along with
+// their archives, and returns how many were removed.
+func (r *DataExportRepository) DeleteExpiredDataExports() (int64, error) {
+	result, err := r.db.Exec("DELETE FROM data_exports WHERE expires_at <= $1", r.dialect.Time(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// MemoryDataExportRepository provides an implementation of DataExportStore backed by a MemoryDB.
+type MemoryDataExportRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryDataExportRepository creates a new instance of MemoryDataExportRepository.
+func NewMemoryDataExportRepository(mdb *MemoryDB) *MemoryDataExportRepository {
+	return &MemoryDataExportRepository{mdb: mdb}
+}
+
+// CreateDataExport inserts a new pending data export of a user into the datastore.
+func (r *MemoryDataExportRepository) CreateDataExport(userID uint) (*DataExport, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[userID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	export := DataExport{
+		ID:        r.mdb.nextID("data_exports"),
+		UserID:    userID,
+		Status:    DataExportStatusPending,
+		CreatedAt: memoryNow(),
+	}
+	r.mdb.dataExports[export.ID] = export
+
+	return &export, nil
+}
+
+// GetDataExportByID retrieves a data export by ID from the datastore, without its archive.
+func (r *MemoryDataExportRepository) GetDataExportByID(exportID uint) (*DataExport, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	export, ok := r.mdb.dataExports[exportID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &export, nil
+}
+
+// GetLatestDataExport retrieves the latest data export of a user from the datastore, without its
+// archive.
+func (r *MemoryDataExportRepository) GetLatestDataExport(userID uint) (*DataExport, error) {
+	exports, err := r.ListDataExports(userID)
+	if err != nil || len(exports) == 0 {
+		return nil, err
+	}
+
+	return &exports[0], nil
+}
+
+// ListDataExports retrieves the data exports of a user from the datastore, latest first, without their
+// archives.
+func (r *MemoryDataExportRepository) ListDataExports(userID uint) ([]DataExport, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	exports := []DataExport{}
+	for _, export := range r.mdb.dataExports {
+		if export.UserID == userID {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		if !exports[i].CreatedAt.Equal(exports[j].CreatedAt) {
+			return exports[i].CreatedAt.After(exports[j].CreatedAt)
+		}
+		return exports[i].ID > exports[j].ID
+	})
+
+	return exports, nil
+}
+
+// CompleteDataExport stores the archive of a data export in the datastore, which is then ready until it
+// expires.
+func (r *MemoryDataExportRepository) CompleteDataExport(exportID uint, archive []byte, expiresAt time.Time) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if export, ok := r.mdb.dataExports[exportID]; ok {
+		now := memoryNow()
+		export.Status = DataExportStatusReady
+		export.Size = int64(len(archive))
+		export.ExpiresAt = memoryTime(&expiresAt)
+		export.CompletedAt = &now
+		r.mdb.dataExports[exportID] = export
+		r.mdb.dataExportArchives[exportID] = append([]byte(nil), archive...)
+	}
+
+	return nil
+}
+
+// FailDataExport marks a data export as failed in the datastore.
+func (r *MemoryDataExportRepository) FailDataExport(exportID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if export, ok := r.mdb.dataExports[exportID]; ok {
+		now := memoryNow()
+		export.Status = DataExportStatusFailed
+		export.CompletedAt = &now
+		r.mdb.dataExports[exportID] = export
+	}
+
+	return nil
+}
+
+// GetDataExportArchive retrieves the archive of a data export from the datastore. It returns nil if the
+// export doesn't exist, isn't ready or has expired, in which case its archive is removed.
+func (r *MemoryDataExportRepository) GetDataExportArchive(exportID uint) ([]byte, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	export, ok := r.mdb.dataExports[exportID]
+	if !ok {
+		return nil, nil
+	}
+	if export.ExpiresAt != nil && !export.ExpiresAt.After(time.Now()) {
+		export.Size = 0
+		r.mdb.dataExports[exportID] = export
+		delete(r.mdb.dataExportArchives, exportID)
+		return nil, nil
+	}
+
+	return r.mdb.dataExportArchives[exportID], nil
+}
+
+// DeleteExpiredDataExports removes the data exports that have expired from the datastore, along with
+// their archives, and returns how many were removed.
+func (r *MemoryDataExportRepository) DeleteExpiredDataExports() (int64, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	var deleted int64
+	now := time.Now()
+	for id, export := range r.mdb.dataExports {
+		if export.ExpiresAt != nil && !export.ExpiresAt.After(now) {
+			delete(r.mdb.dataExports, id)
+			delete(r.mdb.dataExportArchives, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestExpiredDataExports(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		userID := newTestUser(t, s.users, "alice@example.com")
+		newExport := func(expiresAt time.Time) uint {
+			t.Helper()
+
+			export, err := s.exports.CreateDataExport(userID)
+			if err != nil {
+				t.Fatalf("CreateDataExport: %v", err)
+			}
+			if err := s.exports.CompleteDataExport(export.ID, []byte("archive"), expiresAt); err != nil {
+				t.Fatalf("CompleteDataExport: %v", err)
+			}
+			return export.ID
+		}
+		valid := newExport(time.Now().Add(time.Hour))
+		expired := newExport(time.Now().Add(-time.Hour))
+		downloaded := newExport(time.Now().Add(-time.Hour))
+
+		// The archive of an expired export is removed when it's read.
+		if archive, err := s.exports.GetDataExportArchive(valid); err != nil || !bytes.Equal(archive, []byte("archive")) {
+			t.Errorf("GetDataExportArchive(valid): got %q, %v", archive, err)
+		}
+		if archive, err := s.exports.GetDataExportArchive(downloaded); err != nil || archive != nil {
+			t.Errorf("GetDataExportArchive(expired): got %q, %v, want nil", archive, err)
+		}
+		if export, err := s.exports.GetDataExportByID(downloaded); err != nil || export == nil || export.Size != 0 {
+			t.Errorf("GetDataExportByID(expired): got %+v, %v, want an export without archive", export, err)
+		}
+
+		// Purging removes the expired exports only.
+		if deleted, err := s.exports.DeleteExpiredDataExports(); err != nil || deleted != 2 {
+			t.Errorf("DeleteExpiredDataExports: got %d, %v, want 2", deleted, err)
+		}
+		exports, err := s.exports.ListDataExports(userID)
+		if err != nil || len(exports) != 1 || exports[0].ID != valid {
+			t.Errorf("ListDataExports: got %+v, %v, want export %d", exports, err, valid)
+		}
+		if export, err := s.exports.GetDataExportByID(expired); err != nil || export != nil {
+			t.Errorf("GetDataExportByID(expired): got %+v, %v, want nil", export, err)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package models
+
+import "database/sql"
+
+// Label represents a label used to group the tasks of a user.
+type Label struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	UserID uint   `json:"-"`
+}
+
+// LabelStore provides an interface for label-related storage operations.
+type LabelStore interface {
+	CreateLabel(label *Label) (*Label, error)
+	GetLabelByID(labelID uint) (*Label, error)
+	GetLabelByName(userID uint, name string) (*Label, error)
+	UpdateLabel(label *Label) (*Label, error)
+	DeleteLabel(labelID, userID uint) error
+	ListLabelsByUserID(userID uint) ([]Label, error)
+	AttachLabel(taskID, labelID uint) error
+	DetachLabel(taskID, labelID uint) error
+	ListLabelsByTaskID(taskID uint) ([]Label, error)
+}
+
+// labelColumns lists the columns of the labels table in the order expected by scanLabel.
+const labelColumns = "id, name, color, userID"
+
+// scanLabel scans a row selected with labelColumns into a label.
+func scanLabel(row rowScanner) (*Label, error) {
+	var label Label
+	if err := row.Scan(&label.ID, &label.Name, &label.Color, &label.UserID); err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+// LabelRepository provides an implementation of LabelStore backed by an SQL database.
+type LabelRepository struct {
+	db *sql.DB
+}
+
+// NewLabelRepository creates a new instance of LabelRepository.
+func NewLabelRepository(db *sql.DB) *LabelRepository {
+	return &LabelRepository{db: db}
+}
+
+// CreateLabel inserts a new label into the database.
+func (r *LabelRepository) CreateLabel(label *Label) (*Label, error) {
+	row := r.db.QueryRow("INSERT INTO labels (name, color, userID) VALUES ($1, $2, $3) RETURNING "+labelColumns, label.Name, label.Color, label.UserID)
+
+	return scanLabel(row)
+}
+
+// GetLabelByID retrieves a label by its ID from the database.
+func (r *LabelRepository) GetLabelByID(labelID uint) (*Label, error) {
+	label, err := scanLabel(r.db.QueryRow("SELECT "+labelColumns+" FROM labels WHERE id = $1", labelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return label, err
+}
+
+// GetLabelByName retrieves a label of a user by its name from the database.
+func (r *LabelRepository) GetLabelByName(userID uint, name string) (*Label, error) {
+	label, err := scanLabel(r.db.QueryRow("SELECT "+labelColumns+" FROM labels WHERE userID = $1 AND name = $2", userID, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return label, err
+}
+
+// UpdateLabel updates a label in the database.
+func (r *LabelRepository) UpdateLabel(label *Label) (*Label, error) {
+	row := r.db.QueryRow("UPDATE labels SET name = $1, color = $2 WHERE id = $3 RETURNING "+labelColumns, label.Name, label.Color, label.ID)
+
+	return scanLabel(row)
+}
+
+// DeleteLabel deletes a label from the database, detaching it from its tasks.
+func (r *LabelRepository) DeleteLabel(labelID, userID uint) error {
+	res, err := r.db.Exec("DELETE FROM labels WHERE id = $1 AND userID = $2", labelID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// ListLabelsByUserID retrieves the labels of a user ordered by name from the database.
+func (r *LabelRepository) ListLabelsByUserID(userID uint) ([]Label, error) {
+	return r.list("SELECT "+labelColumns+" FROM labels WHERE userID = $1 ORDER BY name, id", userID)
+}
+
+// AttachLabel attaches a label to a task in the database. Attaching it twice has no effect.
+func (r *LabelRepository) AttachLabel(taskID, labelID uint) error {
+	_, err := r.db.Exec("INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", taskID, labelID)
+	return err
+}
+
+// DetachLabel detaches a label from a task in the database.
+func (r *LabelRepository) DetachLabel(taskID, labelID uint) error {
+	res, err := r.db.Exec("DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2", taskID, labelID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// ListLabelsByTaskID retrieves the labels attached to a task ordered by name from the database.
+func (r *LabelRepository) ListLabelsByTaskID(taskID uint) ([]Label, error) {
+	return r.list("SELECT labels.id, labels.name, labels.color, labels.userID FROM labels"+
+		" JOIN task_labels ON task_labels.label_id = labels.id"+
+		" WHERE task_labels.task_id = $1 ORDER BY labels.name, labels.id", taskID)
+}
+
+// list retrieves the labels selected by the query.
+func (r *LabelRepository) list(query string, args ...interface{}) ([]Label, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []Label{}
+	for rows.Next() {
+		label, err := scanLabel(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, *label)
+	}
+
+	return labels, rows.Err()
+}
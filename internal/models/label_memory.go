@@ -0,0 +1,188 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// ErrDuplicateLabel is returned when creating a label with a name the user already uses.
+var ErrDuplicateLabel = errors.New("duplicate key value violates unique constraint on label name")
+
+// ErrUnknownRecord is returned when a record references another record that doesn't exist.
+var ErrUnknownRecord = errors.New("insert or update violates foreign key constraint")
+
+// MemoryLabelRepository provides an implementation of LabelStore backed by a MemoryDB.
+type MemoryLabelRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryLabelRepository creates a new instance of MemoryLabelRepository.
+func NewMemoryLabelRepository(mdb *MemoryDB) *MemoryLabelRepository {
+	return &MemoryLabelRepository{mdb: mdb}
+}
+
+// CreateLabel inserts a new label into the datastore.
+func (r *MemoryLabelRepository) CreateLabel(label *Label) (*Label, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[label.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+	if r.findLabelByName(label.UserID, label.Name, 0) {
+		return nil, ErrDuplicateLabel
+	}
+
+	newLabel := *label
+	newLabel.ID = r.mdb.nextID("labels")
+	r.mdb.labels[newLabel.ID] = newLabel
+
+	return &newLabel, nil
+}
+
+// GetLabelByID retrieves a label by its ID from the datastore.
+func (r *MemoryLabelRepository) GetLabelByID(labelID uint) (*Label, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	label, ok := r.mdb.labels[labelID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &label, nil
+}
+
+// GetLabelByName retrieves a label of a user by its name from the datastore.
+func (r *MemoryLabelRepository) GetLabelByName(userID uint, name string) (*Label, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, label := range r.mdb.labels {
+		if label.UserID == userID && label.Name == name {
+			return &label, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateLabel updates a label in the datastore.
+func (r *MemoryLabelRepository) UpdateLabel(label *Label) (*Label, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	updatedLabel, ok := r.mdb.labels[label.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if r.findLabelByName(updatedLabel.UserID, label.Name, label.ID) {
+		return nil, ErrDuplicateLabel
+	}
+
+	updatedLabel.Name = label.Name
+	updatedLabel.Color = label.Color
+	r.mdb.labels[label.ID] = updatedLabel
+
+	return &updatedLabel, nil
+}
+
+// DeleteLabel deletes a label from the datastore, detaching it from its tasks.
+func (r *MemoryLabelRepository) DeleteLabel(labelID, userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	label, ok := r.mdb.labels[labelID]
+	if !ok || label.UserID != userID {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	r.mdb.deleteLabel(labelID)
+
+	return nil
+}
+
+// ListLabelsByUserID retrieves the labels of a user ordered by name from the datastore.
+func (r *MemoryLabelRepository) ListLabelsByUserID(userID uint) ([]Label, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	labels := []Label{}
+	for _, label := range r.mdb.labels {
+		if label.UserID == userID {
+			labels = append(labels, label)
+		}
+	}
+	sortLabels(labels)
+
+	return labels, nil
+}
+
+// AttachLabel attaches a label to a task in the datastore. Attaching it twice has no effect.
+func (r *MemoryLabelRepository) AttachLabel(taskID, labelID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.tasks[taskID]; !ok {
+		return ErrUnknownRecord
+	}
+	if _, ok := r.mdb.labels[labelID]; !ok {
+		return ErrUnknownRecord
+	}
+
+	if r.mdb.taskLabels[taskID] == nil {
+		r.mdb.taskLabels[taskID] = make(map[uint]bool)
+	}
+	r.mdb.taskLabels[taskID][labelID] = true
+
+	return nil
+}
+
+// DetachLabel detaches a label from a task in the datastore.
+func (r *MemoryLabelRepository) DetachLabel(taskID, labelID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if !r.mdb.taskLabels[taskID][labelID] {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	delete(r.mdb.taskLabels[taskID], labelID)
+
+	return nil
+}
+
+// ListLabelsByTaskID retrieves the labels attached to a task ordered by name from the datastore.
+func (r *MemoryLabelRepository) ListLabelsByTaskID(taskID uint) ([]Label, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	labels := []Label{}
+	for labelID := range r.mdb.taskLabels[taskID] {
+		labels = append(labels, r.mdb.labels[labelID])
+	}
+	sortLabels(labels)
+
+	return labels, nil
+}
+
+// findLabelByName checks if the user has a label with the name, other than the excluded one.
+// The caller must hold the lock.
+func (r *MemoryLabelRepository) findLabelByName(userID uint, name string, excludedID uint) bool {
+	for _, label := range r.mdb.labels {
+		if label.UserID == userID && label.Name == name && label.ID != excludedID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortLabels sorts labels in place by name, then by ID.
+func sortLabels(labels []Label) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Name != labels[j].Name {
+			return labels[i].Name < labels[j].Name
+		}
+		return labels[i].ID < labels[j].ID
+	})
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryDB is a thread-safe in-memory datastore shared by the in-memory repositories.
+// It mirrors the semantics of the SQL database and is meant for tests and local development.
+type MemoryDB struct {
+	mu    sync.RWMutex
+	seq   map[string]uint
+	users map[uint]User
+	tasks map[uint]Task
+	// passwords holds the password hashes keyed by user ID, which aren't part of the users.
+	passwords map[uint]string
+	labels    map[uint]Label
+	projects  map[uint]Project
+	// dependencies holds the IDs of the tasks blocking a task, keyed by the ID of the blocked task.
+	dependencies map[uint]map[uint]bool
+	taskLabels   map[uint]map[uint]bool // label IDs keyed by task ID
+	// refreshTokens holds the refresh tokens keyed by ID, and revokedTokens the expiry of the revoked
+	// access tokens keyed by jti.
+	refreshTokens        map[uint]RefreshToken
+	revokedTokens        map[string]time.Time
+	personalAccessTokens map[uint]PersonalAccessToken
+	passwordResetTokens  map[uint]PasswordResetToken
+	// emailVerificationTokens holds the email verification tokens keyed by ID.
+	emailVerificationTokens map[uint]EmailVerificationToken
+	// recoveryCodes holds the hashes of the recovery codes keyed by user ID, and mfaChallenges the MFA
+	// challenges keyed by ID.
+	recoveryCodes map[uint][]string
+	mfaChallenges map[uint]MFAChallenge
+	auditLog      []AuditEntry
+	// dataExports holds the data exports keyed by ID, and dataExportArchives their archives once ready.
+	dataExports        map[uint]DataExport
+	dataExportArchives map[uint][]byte
+	// workspaceMembers holds the members of each workspace keyed by user ID, keyed by workspace ID.
+	workspaces       map[uint]Workspace
+	workspaceMembers map[uint]map[uint]WorkspaceMember
+	workspaceInvites map[uint]WorkspaceInvite
+}
+
+// NewMemoryDB creates a new, empty instance of MemoryDB.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		seq:                     make(map[string]uint),
+		users:                   make(map[uint]User),
+		passwords:               make(map[uint]string),
+		tasks:                   make(map[uint]Task),
+		labels:                  make(map[uint]Label),
+		projects:                make(map[uint]Project),
+		dependencies:            make(map[uint]map[uint]bool),
+		taskLabels:              make(map[uint]map[uint]bool),
+		refreshTokens:           make(map[uint]RefreshToken),
+		revokedTokens:           make(map[string]time.Time),
+		personalAccessTokens:    make(map[uint]PersonalAccessToken),
+		passwordResetTokens:     make(map[uint]PasswordResetToken),
+		emailVerificationTokens: make(map[uint]EmailVerificationToken),
+		recoveryCodes:           make(map[uint][]string),
+		mfaChallenges:           make(map[uint]MFAChallenge),
+		dataExports:             make(map[uint]DataExport),
+		dataExportArchives:      make(map[uint][]byte),
+		workspaces:              make(map[uint]Workspace),
+		workspaceMembers:        make(map[uint]map[uint]WorkspaceMember),
+		workspaceInvites:        make(map[uint]WorkspaceInvite),
+	}
+}
+
+// nextID returns the next value of the table's ID sequence. The caller must hold the write lock.
+func (m *MemoryDB) nextID(table string) uint {
+	m.seq[table]++
+	return m.seq[table]
+}
+
+// deleteTask deletes a task along with the records referencing it, including its subtasks, like
+// the foreign key cascades of the SQL schema. The caller must hold the write lock.
+func (m *MemoryDB) deleteTask(taskID uint) {
+	for _, id := range append(m.descendantTaskIDs(taskID), taskID) {
+		delete(m.tasks, id)
+		delete(m.taskLabels, id)
+		delete(m.dependencies, id)
+		for _, blockerIDs := range m.dependencies {
+			delete(blockerIDs, id)
+		}
+	}
+}
+
+// deleteLabel deletes a label and detaches it from its tasks. The caller must hold the write lock.
+func (m *MemoryDB) deleteLabel(labelID uint) {
+	delete(m.labels, labelID)
+	for _, labelIDs := range m.taskLabels {
+		delete(labelIDs, labelID)
+	}
+}
+
+// deleteProject deletes a project, leaving its remaining tasks without a project like the
+// ON DELETE SET NULL of the SQL schema. The caller must hold the write lock.
+func (m *MemoryDB) deleteProject(projectID uint) {
+	delete(m.projects, projectID)
+	for taskID, task := range m.tasks {
+		if task.ProjectID != nil && *task.ProjectID == projectID {
+			task.ProjectID = nil
+			m.tasks[taskID] = task
+		}
+	}
+}
+
+// deleteUser deletes a user along with the records referencing them, like the foreign key cascades of
+// the SQL schema. The workspaces they own are deleted, and the tasks they created in the other workspaces
+// are handed over to their owner. The audit log entries are kept, but anonymized. The caller must hold
+// the write lock.
+func (m *MemoryDB) deleteUser(userID uint) {
+	for workspaceID, members := range m.workspaceMembers {
+		if member, ok := members[userID]; ok {
+			if member.Role == WorkspaceRoleOwner {
+				m.deleteWorkspace(workspaceID)
+			} else {
+				m.removeWorkspaceMember(workspaceID, userID)
+			}
+		}
+	}
+	delete(m.users, userID)
+	delete(m.passwords, userID)
+	for taskID, task := range m.tasks {
+		if task.UserID == userID {
+			m.deleteTask(taskID)
+		} else if task.AssigneeID != nil && *task.AssigneeID == userID {
+			task.AssigneeID = nil
+			m.tasks[taskID] = task
+		}
+	}
+	for labelID, label := range m.labels {
+		if label.UserID == userID {
+			m.deleteLabel(labelID)
+		}
+	}
+	for projectID, project := range m.projects {
+		if project.UserID == userID {
+			delete(m.projects, projectID)
+		}
+	}
+	for id, token := range m.refreshTokens {
+		if token.UserID == userID {
+			delete(m.refreshTokens, id)
+		}
+	}
+	for id, token := range m.personalAccessTokens {
+		if token.UserID == userID {
+			delete(m.personalAccessTokens, id)
+		}
+	}
+	for id, token := range m.passwordResetTokens {
+		if token.UserID == userID {
+			delete(m.passwordResetTokens, id)
+		}
+	}
+	for id, token := range m.emailVerificationTokens {
+		if token.UserID == userID {
+			delete(m.emailVerificationTokens, id)
+		}
+	}
+	delete(m.recoveryCodes, userID)
+	for id, challenge := range m.mfaChallenges {
+		if challenge.UserID == userID {
+			delete(m.mfaChallenges, id)
+		}
+	}
+	for id, export := range m.dataExports {
+		if export.UserID == userID {
+			delete(m.dataExports, id)
+			delete(m.dataExportArchives, id)
+		}
+	}
+	for i, entry := range m.auditLog {
+		if entry.UserID != nil && *entry.UserID == userID {
+			m.auditLog[i].UserID = nil
+			m.auditLog[i].IP = ""
+			m.auditLog[i].Details = ""
+		}
+	}
+}
+
+// taskLabelNames returns the set of the names of the labels of a user attached to a task. The caller
+// must hold the lock.
+func (m *MemoryDB) taskLabelNames(taskID, userID uint) map[string]bool {
+	names := make(map[string]bool)
+	for labelID := range m.taskLabels[taskID] {
+		if label := m.labels[labelID]; label.UserID == userID {
+			names[label.Name] = true
+		}
+	}
+
+	return names
+}
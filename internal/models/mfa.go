@@ -0,0 +1,152 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// MFAChallenge represents a login waiting for a second factor, which is identified by a short-lived
+// token only stored hashed. Attempts counts the codes tried so far.
+type MFAChallenge struct {
+	ID        uint
+	TokenHash string
+	UserID    uint
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// MFAStore provides an interface for storage operations related to the recovery codes and to the
+// logins waiting for a second factor.
+type MFAStore interface {
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uint) (int, error)
+	CreateMFAChallenge(challenge *MFAChallenge) (*MFAChallenge, error)
+	GetMFAChallengeByHash(tokenHash string) (*MFAChallenge, error)
+	AddMFAChallengeAttempt(challengeID uint) (int, error)
+	DeleteMFAChallenge(challengeID uint) (bool, error)
+}
+
+// mfaChallengeColumns lists the columns of the mfa_challenges table in the order expected by
+// scanMFAChallenge.
+const mfaChallengeColumns = "id, token_hash, userID, attempts, expires_at, created_at"
+
+// scanMFAChallenge scans a row selected with mfaChallengeColumns into an MFA challenge.
+func scanMFAChallenge(row rowScanner) (*MFAChallenge, error) {
+	var challenge MFAChallenge
+	if err := row.Scan(&challenge.ID, &challenge.TokenHash, &challenge.UserID, &challenge.Attempts, &challenge.ExpiresAt, &challenge.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// MFARepository provides an implementation of MFAStore backed by an SQL database.
+type MFARepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewMFARepository creates a new instance of MFARepository.
+func NewMFARepository(db *sql.DB, dialect database.Dialect) *MFARepository {
+	return &MFARepository{db: db, dialect: dialect}
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user in the database.
+func (r *MFARepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE userID = $1", userID); err != nil {
+		return err
+	}
+	now := r.dialect.Time(time.Now())
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (userID, code_hash, created_at) VALUES ($1, $2, $3)", userID, codeHash, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode deletes a recovery code of a user from the database. It reports false if the user
+// has no such code, e.g. because it was already used.
+func (r *MFARepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM recovery_codes WHERE id = (SELECT id FROM recovery_codes WHERE userID = $1 AND code_hash = $2 LIMIT 1)", userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// CountRecoveryCodes counts the recovery codes a user has left in the database.
+func (r *MFARepository) CountRecoveryCodes(userID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM recovery_codes WHERE userID = $1", userID).Scan(&count)
+	return count, err
+}
+
+// CreateMFAChallenge inserts a new MFA challenge into the database. The challenges that have expired
+// since are removed along the way.
+func (r *MFARepository) CreateMFAChallenge(challenge *MFAChallenge) (*MFAChallenge, error) {
+	if _, err := r.db.Exec("DELETE FROM mfa_challenges WHERE expires_at < $1", r.dialect.Time(time.Now())); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow("INSERT INTO mfa_challenges (token_hash, userID, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING "+mfaChallengeColumns,
+		challenge.TokenHash, challenge.UserID, r.dialect.Time(challenge.ExpiresAt), r.dialect.Time(time.Now()))
+
+	return scanMFAChallenge(row)
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token from the database.
+func (r *MFARepository) GetMFAChallengeByHash(tokenHash string) (*MFAChallenge, error) {
+	row := r.db.QueryRow("SELECT "+mfaChallengeColumns+" FROM mfa_challenges WHERE token_hash = $1", tokenHash)
+
+	challenge, err := scanMFAChallenge(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// AddMFAChallengeAttempt counts an attempt at an MFA challenge in the database, and returns the number
+// of attempts so far. It returns sql.ErrNoRows if the challenge doesn't exist anymore.
+func (r *MFARepository) AddMFAChallengeAttempt(challengeID uint) (int, error) {
+	var attempts int
+	err := r.db.QueryRow("UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts", challengeID).Scan(&attempts)
+	return attempts, err
+}
+
+// DeleteMFAChallenge deletes an MFA challenge from the database. It reports false if it was already
+// deleted, so that only one of concurrent uses of the same challenge succeeds.
+func (r *MFARepository) DeleteMFAChallenge(challengeID uint) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM mfa_challenges WHERE id = $1", challengeID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
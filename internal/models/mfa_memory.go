@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MemoryMFARepository provides an implementation of MFAStore backed by a MemoryDB.
+type MemoryMFARepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryMFARepository creates a new instance of MemoryMFARepository.
+func NewMemoryMFARepository(mdb *MemoryDB) *MemoryMFARepository {
+	return &MemoryMFARepository{mdb: mdb}
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user in the datastore.
+func (r *MemoryMFARepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[userID]; !ok {
+		return ErrUnknownUser
+	}
+	r.mdb.recoveryCodes[userID] = append([]string{}, codeHashes...)
+
+	return nil
+}
+
+// UseRecoveryCode deletes a recovery code of a user from the datastore. It reports false if the user
+// has no such code, e.g. because it was already used.
+func (r *MemoryMFARepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	codeHashes := r.mdb.recoveryCodes[userID]
+	for i, h := range codeHashes {
+		if h == codeHash {
+			r.mdb.recoveryCodes[userID] = append(codeHashes[:i:i], codeHashes[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CountRecoveryCodes counts the recovery codes a user has left in the datastore.
+func (r *MemoryMFARepository) CountRecoveryCodes(userID uint) (int, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	return len(r.mdb.recoveryCodes[userID]), nil
+}
+
+// CreateMFAChallenge inserts a new MFA challenge into the datastore. The challenges that have expired
+// since are removed along the way.
+func (r *MemoryMFARepository) CreateMFAChallenge(challenge *MFAChallenge) (*MFAChallenge, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[challenge.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	now := time.Now()
+	for id, c := range r.mdb.mfaChallenges {
+		if c.ExpiresAt.Before(now) {
+			delete(r.mdb.mfaChallenges, id)
+		}
+	}
+
+	newChallenge := *challenge
+	newChallenge.ID = r.mdb.nextID("mfa_challenges")
+	newChallenge.Attempts = 0
+	newChallenge.ExpiresAt = *memoryTime(&challenge.ExpiresAt)
+	newChallenge.CreatedAt = memoryNow()
+	r.mdb.mfaChallenges[newChallenge.ID] = newChallenge
+
+	return &newChallenge, nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token from the datastore.
+func (r *MemoryMFARepository) GetMFAChallengeByHash(tokenHash string) (*MFAChallenge, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, challenge := range r.mdb.mfaChallenges {
+		if challenge.TokenHash == tokenHash {
+			return &challenge, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// AddMFAChallengeAttempt counts an attempt at an MFA challenge in the datastore, and returns the number
+// of attempts so far. It returns sql.ErrNoRows if the challenge doesn't exist anymore.
+func (r *MemoryMFARepository) AddMFAChallengeAttempt(challengeID uint) (int, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	challenge, ok := r.mdb.mfaChallenges[challengeID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	challenge.Attempts++
+	r.mdb.mfaChallenges[challengeID] = challenge
+
+	return challenge.Attempts, nil
+}
+
+// DeleteMFAChallenge deletes an MFA challenge from the datastore. It reports false if it was already
+// deleted.
+func (r *MemoryMFARepository) DeleteMFAChallenge(challengeID uint) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.mfaChallenges[challengeID]; !ok {
+		return false, nil
+	}
+	delete(r.mdb.mfaChallenges, challengeID)
+
+	return true, nil
+}
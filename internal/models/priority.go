@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// TaskPriority represents the priority of a task.
+type TaskPriority string
+
+const (
+	TaskPriorityNone   TaskPriority = "none"
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityMedium TaskPriority = "medium"
+	TaskPriorityHigh   TaskPriority = "high"
+	TaskPriorityUrgent TaskPriority = "urgent"
+)
+
+// taskPriorities holds the priorities ordered by rank, which is how they are stored in the database.
+var taskPriorities = []TaskPriority{TaskPriorityNone, TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent}
+
+// Rank returns the position of the priority from the lowest to the highest, or -1 if it's unknown.
+func (p TaskPriority) Rank() int {
+	for rank, priority := range taskPriorities {
+		if priority == p {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+// Value implements driver.Valuer by storing the rank of the priority.
+func (p TaskPriority) Value() (driver.Value, error) {
+	if p == "" {
+		return int64(0), nil
+	}
+
+	rank := p.Rank()
+	if rank < 0 {
+		return nil, fmt.Errorf("unknown task priority %q", p)
+	}
+
+	return int64(rank), nil
+}
+
+// Scan implements sql.Scanner by reading the rank of the priority.
+func (p *TaskPriority) Scan(src interface{}) error {
+	rank, ok := src.(int64)
+	if !ok || rank < 0 || int(rank) >= len(taskPriorities) {
+		return fmt.Errorf("invalid task priority %v", src)
+	}
+
+	*p = taskPriorities[rank]
+	return nil
+}
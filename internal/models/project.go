@@ -0,0 +1,167 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// InboxProjectName is the name of the project every user gets at registration.
+const InboxProjectName = "Inbox"
+
+// Project represents a list grouping the tasks of a user.
+type Project struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	IsInbox   bool      `json:"is_inbox"`
+	UserID    uint      `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectStore provides an interface for project-related storage operations.
+type ProjectStore interface {
+	CreateProject(project *Project) (*Project, error)
+	GetProjectByID(projectID uint) (*Project, error)
+	GetInboxProject(userID uint) (*Project, error)
+	UpdateProject(project *Project) (*Project, error)
+	DeleteProject(projectID, userID uint, moveTasksTo *uint) error
+	ListProjectsByUserID(userID uint) ([]Project, error)
+}
+
+// InboxProject retrieves the Inbox project of a user, creating it if the user has none yet.
+func InboxProject(store ProjectStore, userID uint) (*Project, error) {
+	inbox, err := store.GetInboxProject(userID)
+	if err != nil || inbox != nil {
+		return inbox, err
+	}
+
+	inbox, err = store.CreateProject(&Project{Name: InboxProjectName, IsInbox: true, UserID: userID})
+	if err != nil {
+		// The Inbox may have been created concurrently, in which case it violates the uniqueness of
+		// the Inbox and can be retrieved.
+		if existing, getErr := store.GetInboxProject(userID); getErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return inbox, nil
+}
+
+// projectColumns lists the columns of the projects table in the order expected by scanProject.
+const projectColumns = "id, name, is_inbox, userID, created_at"
+
+// scanProject scans a row selected with projectColumns into a project.
+func scanProject(row rowScanner) (*Project, error) {
+	var project Project
+	if err := row.Scan(&project.ID, &project.Name, &project.IsInbox, &project.UserID, &project.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// ProjectRepository provides an implementation of ProjectStore backed by an SQL database.
+type ProjectRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewProjectRepository creates a new instance of ProjectRepository.
+func NewProjectRepository(db *sql.DB, dialect database.Dialect) *ProjectRepository {
+	return &ProjectRepository{db: db, dialect: dialect}
+}
+
+// CreateProject inserts a new project into the database.
+func (r *ProjectRepository) CreateProject(project *Project) (*Project, error) {
+	row := r.db.QueryRow("INSERT INTO projects (name, is_inbox, userID, created_at) VALUES ($1, $2, $3, $4) RETURNING "+projectColumns,
+		project.Name, project.IsInbox, project.UserID, r.dialect.Time(time.Now()))
+
+	return scanProject(row)
+}
+
+// GetProjectByID retrieves a project by its ID from the database.
+func (r *ProjectRepository) GetProjectByID(projectID uint) (*Project, error) {
+	project, err := scanProject(r.db.QueryRow("SELECT "+projectColumns+" FROM projects WHERE id = $1", projectID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return project, err
+}
+
+// GetInboxProject retrieves the Inbox project of a user from the database.
+func (r *ProjectRepository) GetInboxProject(userID uint) (*Project, error) {
+	project, err := scanProject(r.db.QueryRow("SELECT "+projectColumns+" FROM projects WHERE userID = $1 AND is_inbox", userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return project, err
+}
+
+// UpdateProject updates a project in the database.
+func (r *ProjectRepository) UpdateProject(project *Project) (*Project, error) {
+	row := r.db.QueryRow("UPDATE projects SET name = $1 WHERE id = $2 RETURNING "+projectColumns, project.Name, project.ID)
+
+	return scanProject(row)
+}
+
+// DeleteProject deletes a project from the database. Its tasks are moved to the project moveTasksTo
+// if set, and deleted along with it otherwise.
+func (r *ProjectRepository) DeleteProject(projectID, userID uint, moveTasksTo *uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if moveTasksTo != nil {
+		_, err = tx.Exec("UPDATE tasks SET project_id = $1, updated_at = $2 WHERE project_id = $3 AND userID = $4",
+			*moveTasksTo, r.dialect.Time(time.Now()), projectID, userID)
+	} else {
+		_, err = tx.Exec("DELETE FROM tasks WHERE project_id = $1 AND userID = $2", projectID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM projects WHERE id = $1 AND userID = $2", projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return tx.Commit()
+}
+
+// ListProjectsByUserID retrieves the projects of a user from the database, the Inbox first and
+// the others ordered by name.
+func (r *ProjectRepository) ListProjectsByUserID(userID uint) ([]Project, error) {
+	rows, err := r.db.Query("SELECT "+projectColumns+" FROM projects WHERE userID = $1 ORDER BY is_inbox DESC, name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, *project)
+	}
+
+	return projects, rows.Err()
+}
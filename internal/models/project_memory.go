@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// ErrDuplicateInbox is returned when creating an Inbox project for a user who already has one.
+var ErrDuplicateInbox = errors.New("duplicate key value violates unique constraint on inbox project")
+
+// MemoryProjectRepository provides an implementation of ProjectStore backed by a MemoryDB.
+type MemoryProjectRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryProjectRepository creates a new instance of MemoryProjectRepository.
+func NewMemoryProjectRepository(mdb *MemoryDB) *MemoryProjectRepository {
+	return &MemoryProjectRepository{mdb: mdb}
+}
+
+// CreateProject inserts a new project into the datastore.
+func (r *MemoryProjectRepository) CreateProject(project *Project) (*Project, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[project.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+	if project.IsInbox && r.findInbox(project.UserID) != nil {
+		return nil, ErrDuplicateInbox
+	}
+
+	newProject := *project
+	newProject.ID = r.mdb.nextID("projects")
+	newProject.CreatedAt = memoryNow()
+	r.mdb.projects[newProject.ID] = newProject
+
+	return &newProject, nil
+}
+
+// GetProjectByID retrieves a project by its ID from the datastore.
+func (r *MemoryProjectRepository) GetProjectByID(projectID uint) (*Project, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	project, ok := r.mdb.projects[projectID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &project, nil
+}
+
+// GetInboxProject retrieves the Inbox project of a user from the datastore.
+func (r *MemoryProjectRepository) GetInboxProject(userID uint) (*Project, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	return r.findInbox(userID), nil
+}
+
+// UpdateProject updates a project in the datastore.
+func (r *MemoryProjectRepository) UpdateProject(project *Project) (*Project, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	updatedProject, ok := r.mdb.projects[project.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	updatedProject.Name = project.Name
+	r.mdb.projects[project.ID] = updatedProject
+
+	return &updatedProject, nil
+}
+
+// DeleteProject deletes a project from the datastore. Its tasks are moved to the project moveTasksTo
+// if set, and deleted along with it otherwise.
+func (r *MemoryProjectRepository) DeleteProject(projectID, userID uint, moveTasksTo *uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	project, ok := r.mdb.projects[projectID]
+	if !ok || project.UserID != userID {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	if moveTasksTo != nil {
+		if _, ok := r.mdb.projects[*moveTasksTo]; !ok {
+			return ErrUnknownRecord
+		}
+	}
+
+	now := memoryNow()
+	for taskID, task := range r.mdb.tasks {
+		if task.ProjectID == nil || *task.ProjectID != projectID || task.UserID != userID {
+			continue
+		}
+
+		if moveTasksTo == nil {
+			r.mdb.deleteTask(taskID)
+			continue
+		}
+		destination := *moveTasksTo
+		task.ProjectID = &destination
+		task.UpdatedAt = now
+		r.mdb.tasks[taskID] = task
+	}
+	r.mdb.deleteProject(projectID)
+
+	return nil
+}
+
+// ListProjectsByUserID retrieves the projects of a user from the datastore, the Inbox first and
+// the others ordered by name.
+func (r *MemoryProjectRepository) ListProjectsByUserID(userID uint) ([]Project, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	projects := []Project{}
+	for _, project := range r.mdb.projects {
+		if project.UserID == userID {
+			projects = append(projects, project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].IsInbox != projects[j].IsInbox {
+			return projects[i].IsInbox
+		}
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID < projects[j].ID
+	})
+
+	return projects, nil
+}
+
+// findInbox returns the Inbox project of a user, or nil if there's none. The caller must hold the lock.
+func (r *MemoryProjectRepository) findInbox(userID uint) *Project {
+	for _, project := range r.mdb.projects {
+		if project.UserID == userID && project.IsInbox {
+			return &project
+		}
+	}
+
+	return nil
+}
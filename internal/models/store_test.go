@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// testStores holds the stores of one of the implementations, sharing the same datastore.
+type testStores struct {
+	users      UserStore
+	tasks      TaskStore
+	labels     LabelStore
+	exports    DataExportStore
+	workspaces WorkspaceStore
+}
+
+// forEachStore runs a test against the in-memory stores, and against the SQL ones backed by a SQLite
+// database.
+func forEachStore(t *testing.T, test func(t *testing.T, s testStores)) {
+	t.Run("memory", func(t *testing.T) {
+		mdb := NewMemoryDB()
+		test(t, testStores{
+			users:      NewMemoryUserRepository(mdb),
+			tasks:      NewMemoryTaskRepository(mdb),
+			labels:     NewMemoryLabelRepository(mdb),
+			exports:    NewMemoryDataExportRepository(mdb),
+			workspaces: NewMemoryWorkspaceRepository(mdb),
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db := newTestSQLiteDB(t)
+		test(t, testStores{
+			users:      NewUserRepository(db, database.DialectSQLite),
+			tasks:      NewTaskRepository(db, database.DialectSQLite),
+			labels:     NewLabelRepository(db),
+			exports:    NewDataExportRepository(db, database.DialectSQLite),
+			workspaces: NewWorkspaceRepository(db, database.DialectSQLite),
+		})
+	})
+}
+
+// newTestSQLiteDB creates a SQLite database in the test's temporary directory, with every migration
+// applied.
+func newTestSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	// The connection options database.Init sets.
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := sql.Open(database.DriverSQLite, dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, database.DialectSQLite)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("applying the migrations: %v", err)
+	}
+
+	return db
+}
+
+// newTestUser creates a user in the store and returns its ID.
+func newTestUser(t *testing.T, users UserStore, email string) uint {
+	t.Helper()
+
+	if err := users.CreateUser(&User{Email: email}, "hash"); err != nil {
+		t.Fatalf("CreateUser(%q): %v", email, err)
+	}
+	user, err := users.GetUserByEmail(email)
+	if err != nil || user == nil {
+		t.Fatalf("GetUserByEmail(%q): got %v, %v", email, user, err)
+	}
+
+	return user.ID
+}
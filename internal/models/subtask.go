@@ -0,0 +1,231 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrInvalidParent is returned when the parent of a task doesn't exist or belongs to another backlog.
+var ErrInvalidParent = errors.New("parent task doesn't exist or belongs to another backlog")
+
+// ErrTaskCycle is returned when a task would become a subtask of itself or of one of its subtasks.
+var ErrTaskCycle = errors.New("task can't be nested under itself or one of its subtasks")
+
+// TaskProgress represents how many of the subtasks of a task are done.
+type TaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// BuildTaskTree nests the descendants of a task under it, as returned by ListDescendantTasks, and
+// computes the progress of every task of the tree from its direct subtasks.
+func BuildTaskTree(root *Task, descendants []Task) *Task {
+	children := make(map[uint][]Task)
+	for _, task := range descendants {
+		if task.ParentID != nil {
+			children[*task.ParentID] = append(children[*task.ParentID], task)
+		}
+	}
+
+	var build func(task Task) Task
+	build = func(task Task) Task {
+		task.Progress = &TaskProgress{}
+		task.Subtasks = []Task{}
+		for _, child := range children[task.ID] {
+			task.Subtasks = append(task.Subtasks, build(child))
+			task.Progress.Total++
+			if child.Status == TaskStatusDone {
+				task.Progress.Done++
+			}
+		}
+		return task
+	}
+
+	tree := build(*root)
+	return &tree
+}
+
+// nullIDPtr converts a nullable record ID into a pointer, which is nil when the ID is NULL.
+func nullIDPtr(id sql.NullInt64) *uint {
+	if !id.Valid {
+		return nil
+	}
+
+	value := uint(id.Int64)
+	return &value
+}
+
+// queryRower is implemented by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// checkTaskParent checks that the parent of a task, if any, belongs to the same backlog and isn't the
+// task itself or one of its subtasks.
+func checkTaskParent(q queryRower, task *Task) error {
+	if task.ParentID == nil {
+		return nil
+	}
+
+	var parent Task
+	var workspaceID sql.NullInt64
+	err := q.QueryRow("SELECT userID, workspace_id FROM tasks WHERE id = $1", *task.ParentID).Scan(&parent.UserID, &workspaceID)
+	parent.WorkspaceID = nullIDPtr(workspaceID)
+	if err == sql.ErrNoRows || (err == nil && !parent.InSameBacklog(task)) {
+		return ErrInvalidParent
+	}
+	if err != nil {
+		return err
+	}
+
+	// New tasks have no subtasks yet.
+	if task.ID == 0 {
+		return nil
+	}
+
+	// Walk up the ancestors of the new parent to make sure the task isn't one of them.
+	var count int
+	err = q.QueryRow("WITH RECURSIVE ancestors (id, parent_id) AS ("+
+		"SELECT id, parent_id FROM tasks WHERE id = $1"+
+		" UNION SELECT tasks.id, tasks.parent_id FROM tasks JOIN ancestors ON tasks.id = ancestors.parent_id"+
+		") SELECT COUNT(*) FROM ancestors WHERE id = $2", *task.ParentID, task.ID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTaskCycle
+	}
+
+	return nil
+}
+
+// ListSubtasks retrieves the direct subtasks of a task from the database.
+func (r *TaskRepository) ListSubtasks(taskID uint) ([]Task, error) {
+	return r.list("SELECT "+taskColumns+" FROM tasks WHERE parent_id = $1 ORDER BY id", taskID)
+}
+
+// ListDescendantTasks retrieves the subtasks of a task at every depth from the database.
+func (r *TaskRepository) ListDescendantTasks(taskID uint) ([]Task, error) {
+	return r.list("WITH RECURSIVE descendants (id) AS ("+
+		"SELECT id FROM tasks WHERE parent_id = $1"+
+		" UNION SELECT tasks.id FROM tasks JOIN descendants ON tasks.parent_id = descendants.id"+
+		") SELECT "+taskColumns+" FROM tasks WHERE id IN (SELECT id FROM descendants) ORDER BY id", taskID)
+}
+
+// GetTaskProgress computes how many of the direct subtasks of a task are done in the database.
+func (r *TaskRepository) GetTaskProgress(taskID uint) (*TaskProgress, error) {
+	var progress TaskProgress
+	err := r.db.QueryRow("SELECT COUNT(CASE WHEN status = $1 THEN 1 END), COUNT(*) FROM tasks WHERE parent_id = $2",
+		TaskStatusDone, taskID).Scan(&progress.Done, &progress.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+// list retrieves the tasks selected by the query.
+func (r *TaskRepository) list(query string, args ...interface{}) ([]Task, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// ListSubtasks retrieves the direct subtasks of a task from the datastore.
+func (r *MemoryTaskRepository) ListSubtasks(taskID uint) ([]Task, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	tasks := []Task{}
+	for _, task := range r.mdb.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			tasks = append(tasks, r.mdb.taskView(task))
+		}
+	}
+	sortTasks(tasks, func(a, b *Task) bool { return a.ID < b.ID })
+
+	return tasks, nil
+}
+
+// ListDescendantTasks retrieves the subtasks of a task at every depth from the datastore.
+func (r *MemoryTaskRepository) ListDescendantTasks(taskID uint) ([]Task, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	tasks := []Task{}
+	for _, id := range r.mdb.descendantTaskIDs(taskID) {
+		tasks = append(tasks, r.mdb.taskView(r.mdb.tasks[id]))
+	}
+	sortTasks(tasks, func(a, b *Task) bool { return a.ID < b.ID })
+
+	return tasks, nil
+}
+
+// GetTaskProgress computes how many of the direct subtasks of a task are done in the datastore.
+func (r *MemoryTaskRepository) GetTaskProgress(taskID uint) (*TaskProgress, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	var progress TaskProgress
+	for _, task := range r.mdb.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			progress.Total++
+			if task.Status == TaskStatusDone {
+				progress.Done++
+			}
+		}
+	}
+
+	return &progress, nil
+}
+
+// checkTaskParent checks that the parent of a task, if any, belongs to the same backlog and isn't the
+// task itself or one of its subtasks. The caller must hold the lock.
+func (m *MemoryDB) checkTaskParent(task *Task) error {
+	if task.ParentID == nil {
+		return nil
+	}
+
+	parent, ok := m.tasks[*task.ParentID]
+	if !ok || !parent.InSameBacklog(task) {
+		return ErrInvalidParent
+	}
+
+	for id := parent.ID; ; {
+		if id == task.ID {
+			return ErrTaskCycle
+		}
+		ancestor := m.tasks[id]
+		if ancestor.ParentID == nil {
+			return nil
+		}
+		id = *ancestor.ParentID
+	}
+}
+
+// descendantTaskIDs returns the IDs of the subtasks of a task at every depth. The caller must hold the lock.
+func (m *MemoryDB) descendantTaskIDs(taskID uint) []uint {
+	var ids []uint
+	for id, task := range m.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			ids = append(ids, id)
+			ids = append(ids, m.descendantTaskIDs(id)...)
+		}
+	}
+
+	return ids
+}
@@ -1,16 +1,46 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
-// Task represents a task in the application.
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// Task represents a task in the application. A recurring task holds the RRULE of its series along
+// with the anchor the RRULE is evaluated from, which is the due date of the first occurrence. The
+// tasks of a workspace belong to it, and UserID is then the member who created them. A task may be
+// assigned to a user who can see it.
 type Task struct {
-	ID          uint       `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	UserID      uint       `json:"-"`
+	ID                 uint          `json:"id"`
+	Title              string        `json:"title"`
+	Description        string        `json:"description"`
+	Status             TaskStatus    `json:"status"`
+	Priority           TaskPriority  `json:"priority"`
+	Labels             []Label       `json:"labels,omitempty"`
+	ProjectID          *uint         `json:"project_id"`
+	ParentID           *uint         `json:"parent_id"`
+	WorkspaceID        *uint         `json:"workspace_id"`
+	AssigneeID         *uint         `json:"assignee_id"`
+	Progress           *TaskProgress `json:"progress,omitempty"`
+	Subtasks           []Task        `json:"subtasks,omitempty"`
+	Blocked            bool          `json:"blocked"`
+	UserID             uint          `json:"-"`
+	DueAt              *time.Time    `json:"due_at"`
+	StartAt            *time.Time    `json:"start_at"`
+	Recurrence         *string       `json:"recurrence"`
+	RecurrenceAnchor   *time.Time    `json:"-"`
+	RecurrenceTimezone *string       `json:"-"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
 }
 
+// ErrTaskAlreadyDone is returned when completing a task that is already done, e.g. by a concurrent request.
+var ErrTaskAlreadyDone = errors.New("task is already done")
+
 // TaskStatus represents the status of a task.
 type TaskStatus string
 
@@ -20,35 +50,148 @@ const (
 	TaskStatusDone       TaskStatus = "done"
 )
 
-// TaskRepository provides an interface for task-related database operations.
+// InSameBacklog checks if two tasks belong to the same backlog: the personal tasks of a user, or the
+// tasks of a workspace.
+func (t *Task) InSameBacklog(other *Task) bool {
+	if t.WorkspaceID == nil || other.WorkspaceID == nil {
+		return t.WorkspaceID == nil && other.WorkspaceID == nil && t.UserID == other.UserID
+	}
+
+	return *t.WorkspaceID == *other.WorkspaceID
+}
+
+// TaskCounts represents how many tasks a user has, in total and by status.
+type TaskCounts struct {
+	Total      int `json:"total"`
+	Todo       int `json:"todo"`
+	InProgress int `json:"in_progress"`
+	Done       int `json:"done"`
+}
+
+// add counts a task with the status.
+func (c *TaskCounts) add(status TaskStatus, n int) {
+	c.Total += n
+	switch status {
+	case TaskStatusTodo:
+		c.Todo += n
+	case TaskStatusInProgress:
+		c.InProgress += n
+	case TaskStatusDone:
+		c.Done += n
+	}
+}
+
+// TaskStore provides an interface for task-related storage operations.
+type TaskStore interface {
+	CreateTask(task *Task) (*Task, error)
+	GetTaskByID(taskID uint) (*Task, error)
+	UpdateTask(task *Task) (*Task, error)
+	CompleteTask(task *Task, next *Task) (*Task, *Task, error)
+	DeleteTask(taskID uint) error
+	ListTasksByUserID(userID uint) ([]Task, error)
+	ListTasks(userID uint, opts TaskListOptions) (*TaskPage, error)
+	CountTasksByUserIDs(userIDs []uint) (map[uint]TaskCounts, error)
+	ListNextTasks(userID uint, limit int) ([]Task, error)
+	SetTaskAssignee(taskID uint, assigneeID *uint) (*Task, error)
+	ListSubtasks(taskID uint) ([]Task, error)
+	ListDescendantTasks(taskID uint) ([]Task, error)
+	GetTaskProgress(taskID uint) (*TaskProgress, error)
+	AddDependency(taskID, blockerID uint) error
+	RemoveDependency(taskID, blockerID uint) error
+	ListBlockers(taskID uint) ([]Task, error)
+	GetUpstreamGraph(taskID uint) (*TaskGraph, error)
+}
+
+// taskColumns lists the columns of the tasks table in the order expected by scanTask, followed by
+// the computed blocked flag.
+const taskColumns = "id, title, description, status, priority, project_id, parent_id, workspace_id, assignee_id, userID, due_at, start_at, recurrence, recurrence_anchor, recurrence_timezone, created_at, updated_at, " + blockedExpression
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask scans a row selected with taskColumns into a task.
+func scanTask(row rowScanner) (*Task, error) {
+	var task Task
+	var projectID, parentID, workspaceID, assigneeID sql.NullInt64
+	var dueAt, startAt, recurrenceAnchor sql.NullTime
+	var recurrence, recurrenceTimezone sql.NullString
+	if err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &projectID, &parentID, &workspaceID, &assigneeID, &task.UserID, &dueAt, &startAt, &recurrence, &recurrenceAnchor, &recurrenceTimezone, &task.CreatedAt, &task.UpdatedAt, &task.Blocked); err != nil {
+		return nil, err
+	}
+	task.ProjectID = nullIDPtr(projectID)
+	task.ParentID = nullIDPtr(parentID)
+	task.WorkspaceID = nullIDPtr(workspaceID)
+	task.AssigneeID = nullIDPtr(assigneeID)
+	task.DueAt = nullTimePtr(dueAt)
+	task.StartAt = nullTimePtr(startAt)
+	if recurrence.Valid {
+		task.Recurrence = &recurrence.String
+	}
+	task.RecurrenceAnchor = nullTimePtr(recurrenceAnchor)
+	if recurrenceTimezone.Valid {
+		task.RecurrenceTimezone = &recurrenceTimezone.String
+	}
+
+	return &task, nil
+}
+
+// nullTimePtr converts a nullable time into a pointer, which is nil when the time is NULL.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// TaskRepository provides an implementation of TaskStore backed by an SQL database.
 type TaskRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect database.Dialect
 }
 
 // NewTaskRepository creates a new instance of TaskRepository.
-func NewTaskRepository(db *sql.DB) *TaskRepository {
-	return &TaskRepository{db: db}
+func NewTaskRepository(db *sql.DB, dialect database.Dialect) *TaskRepository {
+	return &TaskRepository{db: db, dialect: dialect}
 }
 
-// CreateTasks inserts a new task into the database.
+// CreateTasks inserts a new task into the database. The parent of the task, if any, must belong to
+// the same backlog.
 func (r *TaskRepository) CreateTask(task *Task) (*Task, error) {
-	row := r.db.QueryRow("INSERT INTO tasks (title, description, status, userID) VALUES ($1, $2, $3, $4) RETURNING *", task.Title, task.Description, task.Status, task.UserID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskParent(tx, task); err != nil {
+		return nil, err
+	}
 
-	var newTask Task
-	err := row.Scan(&newTask.ID, &newTask.Title, &newTask.Description, &newTask.Status, &newTask.UserID)
+	newTask, err := r.insertTask(tx, task)
 	if err != nil {
 		return nil, err
 	}
 
-	return &newTask, nil
+	return newTask, tx.Commit()
+}
+
+// insertTask inserts a task with the query runner, which may be a transaction.
+func (r *TaskRepository) insertTask(q queryRower, task *Task) (*Task, error) {
+	now := r.dialect.Time(time.Now())
+	row := q.QueryRow("INSERT INTO tasks (title, description, status, priority, project_id, parent_id, workspace_id, assignee_id, userID, due_at, start_at, recurrence, recurrence_anchor, recurrence_timezone, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $15) RETURNING "+taskColumns,
+		task.Title, task.Description, task.Status, task.Priority, task.ProjectID, task.ParentID, task.WorkspaceID, task.AssigneeID, task.UserID, r.dialect.NullTime(task.DueAt), r.dialect.NullTime(task.StartAt), task.Recurrence, r.dialect.NullTime(task.RecurrenceAnchor), task.RecurrenceTimezone, now)
+
+	return scanTask(row)
 }
 
 // GetTaskByID retrieves a task by its ID from the database.
 func (r *TaskRepository) GetTaskByID(taskID uint) (*Task, error) {
-	row := r.db.QueryRow("SELECT * FROM tasks WHERE id = $1", taskID)
+	row := r.db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1", taskID)
 
-	var task Task
-	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID)
+	task, err := scanTask(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -56,25 +199,99 @@ func (r *TaskRepository) GetTaskByID(taskID uint) (*Task, error) {
 		return nil, err
 	}
 
-	return &task, nil
+	return task, nil
 }
 
-// UpdateTask updates a task in the database.
+// UpdateTask updates a task in the database. The parent of the task, if any, must belong to the same
+// backlog and must not be the task itself or one of its subtasks.
 func (r *TaskRepository) UpdateTask(task *Task) (*Task, error) {
-	row := r.db.QueryRow("UPDATE tasks SET title = $1, description = $2, status = $3 WHERE id = $4 RETURNING *", task.Title, task.Description, task.Status, task.ID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskParent(tx, task); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow("UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, project_id = $5, parent_id = $6, due_at = $7, start_at = $8, recurrence = $9, recurrence_anchor = $10, recurrence_timezone = $11, updated_at = $12 WHERE id = $13 RETURNING "+taskColumns,
+		task.Title, task.Description, task.Status, task.Priority, task.ProjectID, task.ParentID, r.dialect.NullTime(task.DueAt), r.dialect.NullTime(task.StartAt), task.Recurrence, r.dialect.NullTime(task.RecurrenceAnchor), task.RecurrenceTimezone, r.dialect.Time(time.Now()), task.ID)
+
+	updatedTask, err := scanTask(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedTask, tx.Commit()
+}
+
+// CompleteTask updates a task being marked as done in the database, and creates the next occurrence of
+// its series along with its labels, if any, within the same transaction. The update only applies if the
+// task isn't done yet, otherwise ErrTaskAlreadyDone is returned, so that a concurrent completion can't
+// create the next occurrence twice.
+func (r *TaskRepository) CompleteTask(task *Task, next *Task) (*Task, *Task, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskParent(tx, task); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := tx.Exec("UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, project_id = $5, parent_id = $6, due_at = $7, start_at = $8, recurrence = $9, recurrence_anchor = $10, recurrence_timezone = $11, updated_at = $12 WHERE id = $13 AND status <> $3",
+		task.Title, task.Description, TaskStatusDone, task.Priority, task.ProjectID, task.ParentID, r.dialect.NullTime(task.DueAt), r.dialect.NullTime(task.StartAt), task.Recurrence, r.dialect.NullTime(task.RecurrenceAnchor), task.RecurrenceTimezone, r.dialect.Time(time.Now()), task.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	completedTask, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1", task.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if count < 1 {
+		return nil, nil, ErrTaskAlreadyDone
+	}
+
+	var nextTask *Task
+	if next != nil {
+		nextTask, err = r.insertTask(tx, next)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Exec("INSERT INTO task_labels (task_id, label_id) SELECT $1, label_id FROM task_labels WHERE task_id = $2", nextTask.ID, task.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return completedTask, nextTask, tx.Commit()
+}
+
+// SetTaskAssignee assigns a task to a user in the database, or unassigns it when assigneeID is nil.
+func (r *TaskRepository) SetTaskAssignee(taskID uint, assigneeID *uint) (*Task, error) {
+	row := r.db.QueryRow("UPDATE tasks SET assignee_id = $1, updated_at = $2 WHERE id = $3 RETURNING "+taskColumns,
+		assigneeID, r.dialect.Time(time.Now()), taskID)
 
-	var updatedTask Task
-	err := row.Scan(&updatedTask.ID, &updatedTask.Title, &updatedTask.Description, &updatedTask.Status, &updatedTask.UserID)
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &updatedTask, nil
+	return task, nil
 }
 
 // DeleteTask deletes a task from the database.
-func (r *TaskRepository) DeleteTask(taskID, userID uint) error {
-	res, err := r.db.Exec("DELETE FROM tasks WHERE id = $1 AND userID = $2", taskID, userID)
+func (r *TaskRepository) DeleteTask(taskID uint) error {
+	res, err := r.db.Exec("DELETE FROM tasks WHERE id = $1", taskID)
 	if err != nil {
 		return err
 	}
@@ -91,9 +308,51 @@ func (r *TaskRepository) DeleteTask(taskID, userID uint) error {
 	return nil
 }
 
+// CountTasksByUserIDs counts the tasks of each of the users in the database. Every user is present in
+// the result, even without tasks.
+func (r *TaskRepository) CountTasksByUserIDs(userIDs []uint) (map[uint]TaskCounts, error) {
+	counts := make(map[uint]TaskCounts, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		counts[userID] = TaskCounts{}
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = userID
+	}
+
+	rows, err := r.db.Query("SELECT userID, status, COUNT(*) FROM tasks WHERE userID IN ("+strings.Join(placeholders, ", ")+
+		") GROUP BY userID, status", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uint
+		var status TaskStatus
+		var n int
+		if err := rows.Scan(&userID, &status, &n); err != nil {
+			return nil, err
+		}
+
+		c := counts[userID]
+		c.add(status, n)
+		counts[userID] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
 // ListTasksByUserID retrieves a list of tasks belonging to a user in the database.
 func (r *TaskRepository) ListTasksByUserID(userID uint) ([]Task, error) {
-	rows, err := r.db.Query("SELECT * FROM tasks WHERE userID = $1", userID)
+	rows, err := r.db.Query("SELECT "+taskColumns+" FROM tasks WHERE userID = $1", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -101,14 +360,158 @@ func (r *TaskRepository) ListTasksByUserID(userID uint) ([]Task, error) {
 
 	var tasks []Task
 	for rows.Next() {
-		var task Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID)
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// ListTasks retrieves a page of the personal tasks of a user, or of the tasks of the workspace set in the
+// options, in the database, using keyset pagination.
+func (r *TaskRepository) ListTasks(userID uint, opts TaskListOptions) (*TaskPage, error) {
+	field, sort, desc := parseTaskSort(opts.Sort)
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		if t, ok := v.(time.Time); ok {
+			v = r.dialect.Time(t)
+		}
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var conditions []string
+	if opts.WorkspaceID != nil {
+		conditions = append(conditions, "workspace_id = "+arg(*opts.WorkspaceID))
+	} else if opts.AllBacklogs {
+		conditions = append(conditions, "((userID = "+arg(userID)+" AND workspace_id IS NULL) OR workspace_id IN "+
+			"(SELECT workspace_id FROM workspace_members WHERE userID = "+arg(userID)+"))")
+	} else {
+		conditions = append(conditions, "userID = "+arg(userID), "workspace_id IS NULL")
+	}
+	if opts.AssigneeID != nil {
+		conditions = append(conditions, "assignee_id = "+arg(*opts.AssigneeID))
+	}
+	if opts.Unassigned {
+		conditions = append(conditions, "assignee_id IS NULL")
+	}
+	if opts.CreatorID != nil {
+		conditions = append(conditions, "userID = "+arg(*opts.CreatorID))
+	}
+	if opts.ProjectID != nil {
+		conditions = append(conditions, "project_id = "+arg(*opts.ProjectID))
+	}
+	if opts.Status != "" {
+		conditions = append(conditions, "status = "+arg(opts.Status))
+	}
+	if opts.Query != "" {
+		pattern := arg("%" + escapeLike(opts.Query) + "%")
+		conditions = append(conditions, "(title "+r.dialect.ILike()+" "+pattern+" ESCAPE '\\' OR description "+r.dialect.ILike()+" "+pattern+" ESCAPE '\\')")
+	}
+	if len(opts.Priorities) > 0 {
+		placeholders := make([]string, len(opts.Priorities))
+		for i, priority := range opts.Priorities {
+			placeholders[i] = arg(priority)
+		}
+		conditions = append(conditions, "priority IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if len(opts.Labels) > 0 {
+		placeholders := make([]string, len(opts.Labels))
+		for i, label := range opts.Labels {
+			placeholders[i] = arg(label)
+		}
+
+		labeled := "SELECT task_labels.task_id FROM task_labels JOIN labels ON labels.id = task_labels.label_id" +
+			" AND labels.userID = " + arg(userID) + " WHERE labels.name IN (" + strings.Join(placeholders, ", ") + ")"
+		if opts.MatchAllLabels {
+			labeled += " GROUP BY task_labels.task_id HAVING COUNT(DISTINCT labels.name) = " + arg(len(opts.Labels))
+		}
+		conditions = append(conditions, "id IN ("+labeled+")")
+	}
+	if opts.DueBefore != nil {
+		conditions = append(conditions, "due_at < "+arg(*opts.DueBefore))
+	}
+	if opts.DueAfter != nil {
+		conditions = append(conditions, "due_at >= "+arg(*opts.DueAfter))
+	}
+	if opts.Overdue {
+		conditions = append(conditions, "due_at < "+arg(time.Now())+" AND status <> "+arg(TaskStatusDone))
+	}
+
+	column := field.column
+	if field.nullable {
+		column = "COALESCE(" + column + ", " + r.dialect.MaxTimeLiteral() + ")"
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	if opts.Cursor != "" {
+		value, id, err := decodeTaskCursor(opts.Cursor, sort, field)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "("+column+", id) "+comparison+" ("+arg(value)+", "+arg(id)+")")
+	}
+
+	// Fetch one more task than requested to find out if there's a next page.
+	query := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + column + " " + direction + ", id " + direction +
+		" LIMIT " + arg(opts.Limit+1)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &TaskPage{Tasks: []Task{}}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Tasks = append(page.Tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Tasks) > opts.Limit {
+		page.Tasks = page.Tasks[:opts.Limit]
+		page.NextCursor = encodeTaskCursor(sort, field, &page.Tasks[opts.Limit-1])
+	}
+
+	return page, nil
+}
+
+// ListNextTasks retrieves the personal tasks of a user that aren't done yet, ordered by what should be
+// done next: the highest priority first, then the earliest due date.
+func (r *TaskRepository) ListNextTasks(userID uint, limit int) ([]Task, error) {
+	rows, err := r.db.Query("SELECT "+taskColumns+" FROM tasks WHERE userID = $1 AND workspace_id IS NULL AND status <> $2"+
+		" ORDER BY priority DESC, COALESCE(due_at, "+r.dialect.MaxTimeLiteral()+") ASC, id ASC LIMIT $3",
+		userID, TaskStatusDone, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
 	}
 
-	return tasks, nil
+	return tasks, rows.Err()
 }
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded or doesn't match the sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskListOptions holds the filtering, sorting and pagination options for listing tasks.
+type TaskListOptions struct {
+	// WorkspaceID lists the tasks of the workspace rather than the personal tasks of the user, if set.
+	WorkspaceID *uint
+	// AllBacklogs lists the personal tasks of the user along with the tasks of every workspace they are a
+	// member of, when WorkspaceID isn't set.
+	AllBacklogs bool
+	// AssigneeID restricts the list to the tasks assigned to the user, if set.
+	AssigneeID *uint
+	// Unassigned restricts the list to the tasks that aren't assigned to anyone.
+	Unassigned bool
+	// CreatorID restricts the list to the tasks created by the user, if set.
+	CreatorID *uint
+	// ProjectID restricts the list to the tasks of the project, if set.
+	ProjectID *uint
+	// Status restricts the list to the tasks with the status, if set.
+	Status TaskStatus
+	// Query restricts the list to the tasks whose title or description contain it, if set.
+	Query string
+	// Priorities restricts the list to the tasks with one of the priorities, if set.
+	Priorities []TaskPriority
+	// Labels restricts the list to the tasks with the named labels of the user listing them, if set.
+	Labels []string
+	// MatchAllLabels requires the tasks to have all of the Labels rather than any of them.
+	MatchAllLabels bool
+	// DueBefore restricts the list to the tasks due strictly before it, if set.
+	DueBefore *time.Time
+	// DueAfter restricts the list to the tasks due at or after it, if set.
+	DueAfter *time.Time
+	// Overdue restricts the list to the tasks that are past their due date and not done yet.
+	Overdue bool
+	// Sort is the name of the field to sort by, prefixed with "-" for descending order.
+	Sort string
+	// Limit is the maximum number of tasks to return.
+	Limit int
+	// Cursor is the opaque position returned by the previous page, if any.
+	Cursor string
+}
+
+// TaskPage represents a page of tasks along with the cursor of the next one.
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// sortKind represents the type of the values of a sort field.
+type sortKind int
+
+const (
+	sortKindTime sortKind = iota
+	sortKindString
+	sortKindInt
+)
+
+// taskSortField describes a field tasks can be sorted by.
+type taskSortField struct {
+	// column is the SQL expression the field is sorted by.
+	column string
+	kind   sortKind
+	// nullable is set when the column may be NULL. NULL values are sorted as database.MaxTime.
+	nullable bool
+	// key returns the value of the field for a task, of the Go type matching kind.
+	key func(task *Task) interface{}
+}
+
+// taskSortFields holds the fields tasks can be sorted by, keyed by name.
+var taskSortFields = map[string]taskSortField{
+	"created_at": {column: "created_at", kind: sortKindTime, key: func(t *Task) interface{} { return t.CreatedAt }},
+	"updated_at": {column: "updated_at", kind: sortKindTime, key: func(t *Task) interface{} { return t.UpdatedAt }},
+	"title":      {column: "title", kind: sortKindString, key: func(t *Task) interface{} { return t.Title }},
+	"due_at":     {column: "due_at", kind: sortKindTime, nullable: true, key: func(t *Task) interface{} { return timeOrMax(t.DueAt) }},
+	"priority":   {column: "priority", kind: sortKindInt, key: func(t *Task) interface{} { return t.Priority.Rank() }},
+}
+
+// timeOrMax returns the time, or database.MaxTime when it's nil.
+func timeOrMax(t *time.Time) time.Time {
+	if t == nil {
+		return database.MaxTime
+	}
+
+	return *t
+}
+
+// IsTaskSortField checks if tasks can be sorted by the field.
+func IsTaskSortField(name string) bool {
+	_, ok := taskSortFields[name]
+	return ok
+}
+
+// parseTaskSort resolves a sort option into its field, canonical form and direction. Unknown
+// fields fall back to the newest tasks first.
+func parseTaskSort(sort string) (field taskSortField, canonical string, desc bool) {
+	field, ok := taskSortFields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return taskSortFields["created_at"], "-created_at", true
+	}
+
+	return field, sort, strings.HasPrefix(sort, "-")
+}
+
+// taskCursor represents the position of the last task of a page.
+type taskCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// encodeTaskCursor encodes the position of a task within the sort order.
+func encodeTaskCursor(sort string, field taskSortField, task *Task) string {
+	c := taskCursor{Sort: sort, ID: task.ID}
+	switch v := field.key(task).(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	case string:
+		c.Value = v
+	case int:
+		c.Value = strconv.Itoa(v)
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTaskCursor decodes a cursor and returns the sort value and task ID it points at.
+func decodeTaskCursor(cursor, sort string, field taskSortField) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var c taskCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	switch field.kind {
+	case sortKindTime:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return t, c.ID, nil
+	case sortKindInt:
+		n, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return n, c.ID, nil
+	}
+
+	return c.Value, c.ID, nil
+}
+
+// compareSortValues compares two values returned by the key of a sort field.
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	case int:
+		b := b.(int)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// inBacklog checks if a task belongs to the backlog listed with the options: the workspace if set, and
+// the personal tasks of the user otherwise. The workspaces listed along with AllBacklogs are left to the
+// caller, which knows the memberships of the user.
+func (o *TaskListOptions) inBacklog(task *Task, userID uint) bool {
+	if o.WorkspaceID != nil {
+		return task.WorkspaceID != nil && *task.WorkspaceID == *o.WorkspaceID
+	}
+
+	return task.WorkspaceID == nil && task.UserID == userID
+}
+
+// matches checks if a task satisfies the filters of the options at the given time.
+func (o *TaskListOptions) matches(task *Task, now time.Time) bool {
+	if o.ProjectID != nil && (task.ProjectID == nil || *task.ProjectID != *o.ProjectID) {
+		return false
+	}
+	if o.AssigneeID != nil && (task.AssigneeID == nil || *task.AssigneeID != *o.AssigneeID) {
+		return false
+	}
+	if o.Unassigned && task.AssigneeID != nil {
+		return false
+	}
+	if o.CreatorID != nil && task.UserID != *o.CreatorID {
+		return false
+	}
+	if o.Status != "" && task.Status != o.Status {
+		return false
+	}
+	if o.Query != "" {
+		query := strings.ToLower(o.Query)
+		if !strings.Contains(strings.ToLower(task.Title), query) && !strings.Contains(strings.ToLower(task.Description), query) {
+			return false
+		}
+	}
+	if len(o.Priorities) > 0 && !containsPriority(o.Priorities, task.Priority) {
+		return false
+	}
+	if o.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*o.DueBefore)) {
+		return false
+	}
+	if o.DueAfter != nil && (task.DueAt == nil || task.DueAt.Before(*o.DueAfter)) {
+		return false
+	}
+	if o.Overdue && (task.DueAt == nil || !task.DueAt.Before(now) || task.Status == TaskStatusDone) {
+		return false
+	}
+
+	return true
+}
+
+// matchesLabels checks if a task with the set of label names satisfies the label filter of the options.
+func (o *TaskListOptions) matchesLabels(names map[string]bool) bool {
+	if len(o.Labels) == 0 {
+		return true
+	}
+
+	for _, label := range o.Labels {
+		if names[label] && !o.MatchAllLabels {
+			return true
+		}
+		if !names[label] && o.MatchAllLabels {
+			return false
+		}
+	}
+
+	return o.MatchAllLabels
+}
+
+// containsPriority checks if the priority is one of the priorities.
+func containsPriority(priorities []TaskPriority, priority TaskPriority) bool {
+	for _, p := range priorities {
+		if p == priority {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
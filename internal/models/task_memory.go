@@ -0,0 +1,356 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrUnknownUser is returned when a record references a user that doesn't exist.
+var ErrUnknownUser = errors.New("insert or update violates foreign key constraint on user")
+
+// MemoryTaskRepository provides an implementation of TaskStore backed by a MemoryDB.
+type MemoryTaskRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryTaskRepository creates a new instance of MemoryTaskRepository.
+func NewMemoryTaskRepository(mdb *MemoryDB) *MemoryTaskRepository {
+	return &MemoryTaskRepository{mdb: mdb}
+}
+
+// CreateTask inserts a new task into the datastore.
+func (r *MemoryTaskRepository) CreateTask(task *Task) (*Task, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	return r.mdb.insertTask(task)
+}
+
+// insertTask inserts a new task, like the INSERT statement of TaskRepository. The caller must hold the
+// write lock.
+func (m *MemoryDB) insertTask(task *Task) (*Task, error) {
+	if _, ok := m.users[task.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+	if task.ProjectID != nil {
+		if _, ok := m.projects[*task.ProjectID]; !ok {
+			return nil, ErrUnknownRecord
+		}
+	}
+	if task.WorkspaceID != nil {
+		if _, ok := m.workspaces[*task.WorkspaceID]; !ok {
+			return nil, ErrUnknownRecord
+		}
+	}
+	if task.AssigneeID != nil {
+		if _, ok := m.users[*task.AssigneeID]; !ok {
+			return nil, ErrUnknownUser
+		}
+	}
+	if err := m.checkTaskParent(task); err != nil {
+		return nil, err
+	}
+
+	newTask := *task
+	newTask.ID = m.nextID("tasks")
+	newTask.Labels = nil
+	newTask.Progress = nil
+	newTask.Subtasks = nil
+	newTask.Blocked = false
+	if newTask.Priority == "" {
+		newTask.Priority = TaskPriorityNone
+	}
+	newTask.DueAt = memoryTime(task.DueAt)
+	newTask.StartAt = memoryTime(task.StartAt)
+	newTask.RecurrenceAnchor = memoryTime(task.RecurrenceAnchor)
+	newTask.CreatedAt = memoryNow()
+	newTask.UpdatedAt = newTask.CreatedAt
+	m.tasks[newTask.ID] = newTask
+
+	return &newTask, nil
+}
+
+// GetTaskByID retrieves a task by its ID from the datastore.
+func (r *MemoryTaskRepository) GetTaskByID(taskID uint) (*Task, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	task, ok := r.mdb.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+	task = r.mdb.taskView(task)
+
+	return &task, nil
+}
+
+// UpdateTask updates a task in the datastore.
+func (r *MemoryTaskRepository) UpdateTask(task *Task) (*Task, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	return r.mdb.updateTask(task)
+}
+
+// CompleteTask updates a task being marked as done in the datastore, and creates the next occurrence of
+// its series along with its labels, if any. The update only applies if the task isn't done yet,
+// otherwise ErrTaskAlreadyDone is returned, so that a concurrent completion can't create the next
+// occurrence twice.
+func (r *MemoryTaskRepository) CompleteTask(task *Task, next *Task) (*Task, *Task, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	stored, ok := r.mdb.tasks[task.ID]
+	if !ok {
+		return nil, nil, sql.ErrNoRows
+	}
+	if stored.Status == TaskStatusDone {
+		return nil, nil, ErrTaskAlreadyDone
+	}
+
+	completed := *task
+	completed.Status = TaskStatusDone
+	completedTask, err := r.mdb.updateTask(&completed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nextTask *Task
+	if next != nil {
+		nextTask, err = r.mdb.insertTask(next)
+		if err != nil {
+			return nil, nil, err
+		}
+		for labelID := range r.mdb.taskLabels[task.ID] {
+			if r.mdb.taskLabels[nextTask.ID] == nil {
+				r.mdb.taskLabels[nextTask.ID] = make(map[uint]bool)
+			}
+			r.mdb.taskLabels[nextTask.ID][labelID] = true
+		}
+	}
+
+	return completedTask, nextTask, nil
+}
+
+// updateTask updates a task, like the UPDATE statement of TaskRepository. The caller must hold the write
+// lock.
+func (m *MemoryDB) updateTask(task *Task) (*Task, error) {
+	updatedTask, ok := m.tasks[task.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if task.ProjectID != nil {
+		if _, ok := m.projects[*task.ProjectID]; !ok {
+			return nil, ErrUnknownRecord
+		}
+	}
+	if err := m.checkTaskParent(&Task{ID: task.ID, UserID: updatedTask.UserID, WorkspaceID: updatedTask.WorkspaceID, ParentID: task.ParentID}); err != nil {
+		return nil, err
+	}
+
+	updatedTask.Title = task.Title
+	updatedTask.Description = task.Description
+	updatedTask.Status = task.Status
+	updatedTask.Priority = task.Priority
+	updatedTask.ProjectID = task.ProjectID
+	updatedTask.ParentID = task.ParentID
+	updatedTask.DueAt = memoryTime(task.DueAt)
+	updatedTask.StartAt = memoryTime(task.StartAt)
+	updatedTask.Recurrence = task.Recurrence
+	updatedTask.RecurrenceAnchor = memoryTime(task.RecurrenceAnchor)
+	updatedTask.RecurrenceTimezone = task.RecurrenceTimezone
+	updatedTask.UpdatedAt = memoryNow()
+	m.tasks[task.ID] = updatedTask
+	updatedTask = m.taskView(updatedTask)
+
+	return &updatedTask, nil
+}
+
+// SetTaskAssignee assigns a task to a user in the datastore, or unassigns it when assigneeID is nil.
+func (r *MemoryTaskRepository) SetTaskAssignee(taskID uint, assigneeID *uint) (*Task, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	task, ok := r.mdb.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+	if assigneeID != nil {
+		if _, ok := r.mdb.users[*assigneeID]; !ok {
+			return nil, ErrUnknownUser
+		}
+	}
+
+	task.AssigneeID = assigneeID
+	task.UpdatedAt = memoryNow()
+	r.mdb.tasks[taskID] = task
+	task = r.mdb.taskView(task)
+
+	return &task, nil
+}
+
+// DeleteTask deletes a task from the datastore.
+func (r *MemoryTaskRepository) DeleteTask(taskID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.tasks[taskID]; !ok {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	r.mdb.deleteTask(taskID)
+
+	return nil
+}
+
+// ListTasksByUserID retrieves a list of tasks belonging to a user in the datastore.
+func (r *MemoryTaskRepository) ListTasksByUserID(userID uint) ([]Task, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	var tasks []Task
+	for _, task := range r.mdb.tasks {
+		if task.UserID == userID {
+			tasks = append(tasks, r.mdb.taskView(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks, nil
+}
+
+// CountTasksByUserIDs counts the tasks of each of the users in the datastore. Every user is present in
+// the result, even without tasks.
+func (r *MemoryTaskRepository) CountTasksByUserIDs(userIDs []uint) (map[uint]TaskCounts, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	counts := make(map[uint]TaskCounts, len(userIDs))
+	for _, userID := range userIDs {
+		counts[userID] = TaskCounts{}
+	}
+	for _, task := range r.mdb.tasks {
+		if c, ok := counts[task.UserID]; ok {
+			c.add(task.Status, 1)
+			counts[task.UserID] = c
+		}
+	}
+
+	return counts, nil
+}
+
+// ListTasks retrieves a page of the personal tasks of a user, or of the tasks of the workspace set in the
+// options, in the datastore.
+func (r *MemoryTaskRepository) ListTasks(userID uint, opts TaskListOptions) (*TaskPage, error) {
+	field, sort, desc := parseTaskSort(opts.Sort)
+
+	// compare orders two tasks by the sort field, then by ID, in the requested direction.
+	compare := func(aKey interface{}, aID uint, bKey interface{}, bID uint) int {
+		c := compareSortValues(aKey, bKey)
+		if c == 0 && aID != bID {
+			c = 1
+			if aID < bID {
+				c = -1
+			}
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	}
+
+	var cursorKey interface{}
+	var cursorID uint
+	if opts.Cursor != "" {
+		var err error
+		cursorKey, cursorID, err = decodeTaskCursor(opts.Cursor, sort, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := memoryNow()
+
+	r.mdb.mu.RLock()
+	tasks := []Task{}
+	for _, task := range r.mdb.tasks {
+		task := task
+		if !opts.inBacklog(&task, userID) && !(opts.AllBacklogs && opts.WorkspaceID == nil && r.mdb.isWorkspaceMember(task.WorkspaceID, userID)) {
+			continue
+		}
+		if !opts.matches(&task, now) || !opts.matchesLabels(r.mdb.taskLabelNames(task.ID, userID)) {
+			continue
+		}
+		if cursorKey != nil && compare(field.key(&task), task.ID, cursorKey, cursorID) <= 0 {
+			continue
+		}
+
+		tasks = append(tasks, r.mdb.taskView(task))
+	}
+	r.mdb.mu.RUnlock()
+
+	sortTasks(tasks, func(a, b *Task) bool {
+		return compare(field.key(a), a.ID, field.key(b), b.ID) < 0
+	})
+
+	page := &TaskPage{Tasks: tasks}
+	if len(page.Tasks) > opts.Limit {
+		page.Tasks = page.Tasks[:opts.Limit]
+		page.NextCursor = encodeTaskCursor(sort, field, &page.Tasks[opts.Limit-1])
+	}
+
+	return page, nil
+}
+
+// sortTasks sorts tasks in place using the less function.
+func sortTasks(tasks []Task, less func(a, b *Task) bool) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return less(&tasks[i], &tasks[j])
+	})
+}
+
+// memoryNow returns the current time with the precision kept by the SQL databases.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// memoryTime converts an optional time to the precision and location kept by the SQL databases.
+func memoryTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	converted := t.UTC().Truncate(time.Microsecond)
+	return &converted
+}
+
+// ListNextTasks retrieves the personal tasks of a user that aren't done yet, ordered by what should be
+// done next: the highest priority first, then the earliest due date.
+func (r *MemoryTaskRepository) ListNextTasks(userID uint, limit int) ([]Task, error) {
+	r.mdb.mu.RLock()
+	tasks := []Task{}
+	for _, task := range r.mdb.tasks {
+		if task.UserID == userID && task.WorkspaceID == nil && task.Status != TaskStatusDone {
+			tasks = append(tasks, r.mdb.taskView(task))
+		}
+	}
+	r.mdb.mu.RUnlock()
+
+	sortTasks(tasks, func(a, b *Task) bool {
+		if a.Priority.Rank() != b.Priority.Rank() {
+			return a.Priority.Rank() > b.Priority.Rank()
+		}
+		if c := timeOrMax(a.DueAt).Compare(timeOrMax(b.DueAt)); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	return tasks, nil
+}
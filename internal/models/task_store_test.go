@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestTask creates a task in the datastore.
+func newTestTask(t *testing.T, tasks TaskStore, task Task) *Task {
+	t.Helper()
+
+	if task.Status == "" {
+		task.Status = TaskStatusTodo
+	}
+	created, err := tasks.CreateTask(&task)
+	if err != nil {
+		t.Fatalf("CreateTask(%q): %v", task.Title, err)
+	}
+
+	return created
+}
+
+func TestListTasksPagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		userID := newTestUser(t, s.users, "alice@example.com")
+		otherID := newTestUser(t, s.users, "bob@example.com")
+
+		day := func(n int) *time.Time {
+			d := time.Date(2030, 1, n, 0, 0, 0, 0, time.UTC)
+			return &d
+		}
+		for _, task := range []Task{
+			{Title: "c", DueAt: day(2), Priority: TaskPriorityHigh},
+			{Title: "a", Priority: TaskPriorityLow},
+			{Title: "e", DueAt: day(1), Priority: TaskPriorityMedium},
+			{Title: "b", Priority: TaskPriorityHigh},
+			{Title: "d", DueAt: day(3), Priority: TaskPriorityLow},
+		} {
+			task.UserID = userID
+			newTestTask(t, s.tasks, task)
+		}
+		newTestTask(t, s.tasks, Task{Title: "other", UserID: otherID})
+
+		tests := []struct {
+			sort string
+			want []uint
+		}{
+			{sort: "", want: []uint{5, 4, 3, 2, 1}},
+			{sort: "created_at", want: []uint{1, 2, 3, 4, 5}},
+			{sort: "title", want: []uint{2, 4, 1, 5, 3}},
+			{sort: "-title", want: []uint{3, 5, 1, 4, 2}},
+			{sort: "due_at", want: []uint{3, 1, 5, 2, 4}},
+			{sort: "-due_at", want: []uint{4, 2, 5, 1, 3}},
+			{sort: "priority", want: []uint{2, 5, 3, 1, 4}},
+			{sort: "-priority", want: []uint{4, 1, 3, 5, 2}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.sort, func(t *testing.T) {
+				var got []uint
+				opts := TaskListOptions{Sort: tt.sort, Limit: 2}
+				for pages := 0; ; pages++ {
+					if pages > len(tt.want) {
+						t.Fatalf("pagination doesn't end, got %v", got)
+					}
+
+					page, err := s.tasks.ListTasks(userID, opts)
+					if err != nil {
+						t.Fatalf("ListTasks: %v", err)
+					}
+					if len(page.Tasks) > opts.Limit {
+						t.Fatalf("got %d tasks, want at most %d", len(page.Tasks), opts.Limit)
+					}
+					for _, task := range page.Tasks {
+						got = append(got, task.ID)
+					}
+					if page.NextCursor == "" {
+						break
+					}
+					opts.Cursor = page.NextCursor
+				}
+
+				if !equalIDs(got, tt.want) {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestListTasksInvalidCursor(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		userID := newTestUser(t, s.users, "alice@example.com")
+		for _, title := range []string{"a", "b"} {
+			newTestTask(t, s.tasks, Task{Title: title, UserID: userID})
+		}
+
+		page, err := s.tasks.ListTasks(userID, TaskListOptions{Sort: "title", Limit: 1})
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			cursor string
+		}{
+			{name: "garbage", cursor: "not a cursor"},
+			{name: "other sort", cursor: page.NextCursor},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := s.tasks.ListTasks(userID, TaskListOptions{Sort: "-title", Limit: 1, Cursor: tt.cursor})
+				if err != ErrInvalidCursor {
+					t.Errorf("got error %v, want %v", err, ErrInvalidCursor)
+				}
+			})
+		}
+	})
+}
+
+func TestCompleteTask(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		userID := newTestUser(t, s.users, "alice@example.com")
+
+		rule, timezone := "FREQ=DAILY", "Europe/Berlin"
+		due := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+		task := newTestTask(t, s.tasks, Task{Title: "Water the plants", UserID: userID, DueAt: &due, Recurrence: &rule, RecurrenceAnchor: &due, RecurrenceTimezone: &timezone})
+		label, err := s.labels.CreateLabel(&Label{Name: "home", Color: "#112233", UserID: userID})
+		if err != nil {
+			t.Fatalf("CreateLabel: %v", err)
+		}
+		if err := s.labels.AttachLabel(task.ID, label.ID); err != nil {
+			t.Fatalf("AttachLabel: %v", err)
+		}
+
+		nextDue := due.AddDate(0, 0, 1)
+		next := &Task{Title: task.Title, Status: TaskStatusTodo, UserID: userID, DueAt: &nextDue, Recurrence: &rule, RecurrenceAnchor: &due, RecurrenceTimezone: &timezone}
+		completing := *task
+		completing.Status = TaskStatusDone
+		completing.Recurrence, completing.RecurrenceAnchor, completing.RecurrenceTimezone = nil, nil, nil
+
+		completed, created, err := s.tasks.CompleteTask(&completing, next)
+		if err != nil {
+			t.Fatalf("CompleteTask: %v", err)
+		}
+		if completed.Status != TaskStatusDone || completed.Recurrence != nil {
+			t.Errorf("completed task has status %q and recurrence %v, want done without recurrence", completed.Status, completed.Recurrence)
+		}
+		if created == nil || created.ID == task.ID || !created.DueAt.Equal(nextDue) {
+			t.Fatalf("got next occurrence %+v, want a new task due at %v", created, nextDue)
+		}
+		if created.RecurrenceAnchor == nil || !created.RecurrenceAnchor.Equal(due) || created.RecurrenceTimezone == nil || *created.RecurrenceTimezone != timezone {
+			t.Errorf("got next occurrence anchored at %v in %v, want %v in %q", created.RecurrenceAnchor, created.RecurrenceTimezone, due, timezone)
+		}
+		nextLabels, err := s.labels.ListLabelsByTaskID(created.ID)
+		if err != nil {
+			t.Fatalf("ListLabelsByTaskID: %v", err)
+		}
+		if len(nextLabels) != 1 || nextLabels[0].ID != label.ID {
+			t.Errorf("got labels %+v on the next occurrence, want %q", nextLabels, label.Name)
+		}
+
+		// Completing it again, e.g. by a concurrent request, doesn't create another occurrence.
+		if _, _, err := s.tasks.CompleteTask(&completing, next); !errors.Is(err, ErrTaskAlreadyDone) {
+			t.Errorf("completing again: got error %v, want %v", err, ErrTaskAlreadyDone)
+		}
+		page, err := s.tasks.ListTasks(userID, TaskListOptions{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+		if len(page.Tasks) != 2 {
+			t.Errorf("got %d tasks, want the completed task and one next occurrence", len(page.Tasks))
+		}
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		userID := newTestUser(t, s.users, "alice@example.com")
+
+		// design blocks build, which blocks release.
+		design := newTestTask(t, s.tasks, Task{Title: "design", UserID: userID})
+		build := newTestTask(t, s.tasks, Task{Title: "build", UserID: userID})
+		release := newTestTask(t, s.tasks, Task{Title: "release", UserID: userID})
+		if err := s.tasks.AddDependency(build.ID, design.ID); err != nil {
+			t.Fatalf("AddDependency: %v", err)
+		}
+		if err := s.tasks.AddDependency(release.ID, build.ID); err != nil {
+			t.Fatalf("AddDependency: %v", err)
+		}
+
+		tests := []struct {
+			name      string
+			taskID    uint
+			blockerID uint
+			want      error
+		}{
+			{name: "itself", taskID: design.ID, blockerID: design.ID, want: ErrDependencyCycle},
+			{name: "direct cycle", taskID: design.ID, blockerID: build.ID, want: ErrDependencyCycle},
+			{name: "indirect cycle", taskID: design.ID, blockerID: release.ID, want: ErrDependencyCycle},
+			{name: "unknown blocker", taskID: design.ID, blockerID: 42, want: ErrUnknownRecord},
+			{name: "twice", taskID: build.ID, blockerID: design.ID, want: nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := s.tasks.AddDependency(tt.taskID, tt.blockerID); err != tt.want {
+					t.Errorf("got error %v, want %v", err, tt.want)
+				}
+			})
+		}
+
+		blocked := func(taskID uint) bool {
+			t.Helper()
+			task, err := s.tasks.GetTaskByID(taskID)
+			if err != nil {
+				t.Fatalf("GetTaskByID: %v", err)
+			}
+			return task.Blocked
+		}
+		if blocked(design.ID) || !blocked(build.ID) || !blocked(release.ID) {
+			t.Fatalf("got blocked design %v, build %v, release %v, want false, true, true", blocked(design.ID), blocked(build.ID), blocked(release.ID))
+		}
+
+		// Completing a blocker unblocks the tasks only it blocked.
+		design.Status = TaskStatusDone
+		if _, err := s.tasks.UpdateTask(design); err != nil {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+		if blocked(build.ID) || !blocked(release.ID) {
+			t.Errorf("got blocked build %v, release %v, want false, true", blocked(build.ID), blocked(release.ID))
+		}
+	})
+}
+
+// equalIDs checks if two lists of IDs hold the same IDs in the same order.
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
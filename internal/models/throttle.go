@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// LoginThrottle represents the failed login attempts of an account or of an IP address, identified by
+// a key, and until when further attempts are refused.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// LoginThrottleStore provides an interface for storage operations related to failed login attempts.
+type LoginThrottleStore interface {
+	GetLoginThrottle(key string) (*LoginThrottle, error)
+	AddLoginFailure(key string, window time.Duration) (int, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginThrottle(key string) error
+}
+
+// LoginThrottleRepository provides an implementation of LoginThrottleStore backed by an SQL database,
+// which is shared by all the replicas of the API.
+type LoginThrottleRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewLoginThrottleRepository creates a new instance of LoginThrottleRepository.
+func NewLoginThrottleRepository(db *sql.DB, dialect database.Dialect) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db, dialect: dialect}
+}
+
+// GetLoginThrottle retrieves the failed login attempts of a key from the database.
+func (r *LoginThrottleRepository) GetLoginThrottle(key string) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRow("SELECT key, failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1", key).
+		Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil // Return nil when no records are found
+	}
+	if err != nil {
+		return nil, err
+	}
+	throttle.LockedUntil = nullTimePtr(lockedUntil)
+
+	return &throttle, nil
+}
+
+// AddLoginFailure counts a failed login attempt of a key in the database, and returns the number of
+// failures so far. The count starts over when the previous failure is older than the window.
+func (r *LoginThrottleRepository) AddLoginFailure(key string, window time.Duration) (int, error) {
+	now := time.Now()
+
+	var failures int
+	err := r.db.QueryRow(`INSERT INTO login_throttles (key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = $2
+		RETURNING failures`, key, r.dialect.Time(now), r.dialect.Time(now.Add(-window))).Scan(&failures)
+	return failures, err
+}
+
+// LockLogin refuses the login attempts of a key until the given time in the database.
+func (r *LoginThrottleRepository) LockLogin(key string, until time.Time) error {
+	_, err := r.db.Exec("UPDATE login_throttles SET locked_until = $1 WHERE key = $2", r.dialect.Time(until), key)
+	return err
+}
+
+// ResetLoginThrottle forgets the failed login attempts of a key in the database, which unlocks it.
+func (r *LoginThrottleRepository) ResetLoginThrottle(key string) error {
+	_, err := r.db.Exec("DELETE FROM login_throttles WHERE key = $1", key)
+	return err
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryLoginThrottlePruneSize is the number of tracked keys above which the stale ones are pruned.
+const memoryLoginThrottlePruneSize = 1024
+
+// MemoryLoginThrottleRepository provides an implementation of LoginThrottleStore backed by a map, which
+// is local to each replica of the API. It is independent of the storage backend of the other data.
+type MemoryLoginThrottleRepository struct {
+	mu        sync.Mutex
+	throttles map[string]LoginThrottle
+}
+
+// NewMemoryLoginThrottleRepository creates a new instance of MemoryLoginThrottleRepository.
+func NewMemoryLoginThrottleRepository() *MemoryLoginThrottleRepository {
+	return &MemoryLoginThrottleRepository{throttles: make(map[string]LoginThrottle)}
+}
+
+// GetLoginThrottle retrieves the failed login attempts of a key.
+func (r *MemoryLoginThrottleRepository) GetLoginThrottle(key string) (*LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, ok := r.throttles[key]
+	if !ok {
+		return nil, nil // Return nil when no records are found
+	}
+
+	return &throttle, nil
+}
+
+// AddLoginFailure counts a failed login attempt of a key, and returns the number of failures so far.
+// The count starts over when the previous failure is older than the window.
+func (r *MemoryLoginThrottleRepository) AddLoginFailure(key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if len(r.throttles) >= memoryLoginThrottlePruneSize {
+		r.prune(now, window)
+	}
+
+	throttle, ok := r.throttles[key]
+	if !ok || throttle.LastFailureAt.Before(now.Add(-window)) {
+		throttle.Key = key
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	r.throttles[key] = throttle
+
+	return throttle.Failures, nil
+}
+
+// LockLogin refuses the login attempts of a key until the given time.
+func (r *MemoryLoginThrottleRepository) LockLogin(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if throttle, ok := r.throttles[key]; ok {
+		throttle.LockedUntil = &until
+		r.throttles[key] = throttle
+	}
+
+	return nil
+}
+
+// ResetLoginThrottle forgets the failed login attempts of a key, which unlocks it.
+func (r *MemoryLoginThrottleRepository) ResetLoginThrottle(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttles, key)
+
+	return nil
+}
+
+// prune forgets the keys which aren't locked and whose failures are older than the window, so that
+// the map doesn't grow without bound. The caller must hold the lock.
+func (r *MemoryLoginThrottleRepository) prune(now time.Time, window time.Duration) {
+	for key, throttle := range r.throttles {
+		locked := throttle.LockedUntil != nil && throttle.LockedUntil.After(now)
+		if !locked && throttle.LastFailureAt.Before(now.Add(-window)) {
+			delete(r.throttles, key)
+		}
+	}
+}
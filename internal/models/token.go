@@ -0,0 +1,435 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// RefreshToken represents a refresh token issued to a user, which is only stored hashed. The refresh
+// tokens rotated from the same login share a family, so that they can be revoked together.
+type RefreshToken struct {
+	ID        uint
+	TokenHash string
+	FamilyID  string
+	UserID    uint
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TokenStore provides an interface for storage operations related to refresh tokens, to the revocation
+// of access tokens, to personal access tokens, to password reset tokens and to email verification tokens.
+type TokenStore interface {
+	CreateRefreshToken(token *RefreshToken) (*RefreshToken, error)
+	GetRefreshTokenByHash(tokenHash string) (*RefreshToken, error)
+	UseRefreshToken(tokenID uint) (bool, error)
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	CreatePersonalAccessToken(token *PersonalAccessToken) (*PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(tokenHash string) (*PersonalAccessToken, error)
+	ListPersonalAccessTokens(userID uint) ([]PersonalAccessToken, error)
+	TouchPersonalAccessToken(tokenID uint) error
+	DeletePersonalAccessToken(tokenID, userID uint) error
+	CreatePasswordResetToken(token *PasswordResetToken) (*PasswordResetToken, error)
+	GetPasswordResetTokenByHash(tokenHash string) (*PasswordResetToken, error)
+	ResetPasswordWithToken(tokenID, userID uint, password string) (bool, error)
+	DeleteUserPasswordResetTokens(userID uint) error
+	CreateEmailVerificationToken(token *EmailVerificationToken) (*EmailVerificationToken, error)
+	GetEmailVerificationTokenByHash(tokenHash string) (*EmailVerificationToken, error)
+	GetLatestEmailVerificationToken(userID uint) (*EmailVerificationToken, error)
+	UseEmailVerificationToken(tokenID uint) (bool, error)
+	DeleteUserEmailVerificationTokens(userID uint) error
+}
+
+// refreshTokenColumns lists the columns of the refresh_tokens table in the order expected by scanRefreshToken.
+const refreshTokenColumns = "id, token_hash, family_id, userID, expires_at, used_at, revoked_at, created_at"
+
+// scanRefreshToken scans a row selected with refreshTokenColumns into a refresh token.
+func scanRefreshToken(row rowScanner) (*RefreshToken, error) {
+	var token RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.TokenHash, &token.FamilyID, &token.UserID, &token.ExpiresAt, &usedAt, &revokedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+	token.UsedAt = nullTimePtr(usedAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+
+	return &token, nil
+}
+
+// TokenRepository provides an implementation of TokenStore backed by an SQL database.
+type TokenRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewTokenRepository creates a new instance of TokenRepository.
+func NewTokenRepository(db *sql.DB, dialect database.Dialect) *TokenRepository {
+	return &TokenRepository{db: db, dialect: dialect}
+}
+
+// CreateRefreshToken inserts a new refresh token into the database.
+func (r *TokenRepository) CreateRefreshToken(token *RefreshToken) (*RefreshToken, error) {
+	row := r.db.QueryRow("INSERT INTO refresh_tokens (token_hash, family_id, userID, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING "+refreshTokenColumns,
+		token.TokenHash, token.FamilyID, token.UserID, r.dialect.Time(token.ExpiresAt), r.dialect.Time(time.Now()))
+
+	return scanRefreshToken(row)
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash from the database.
+func (r *TokenRepository) GetRefreshTokenByHash(tokenHash string) (*RefreshToken, error) {
+	row := r.db.QueryRow("SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = $1", tokenHash)
+
+	token, err := scanRefreshToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// UseRefreshToken marks a refresh token as used. It reports false if the token was already used or
+// revoked, so that only one of concurrent rotations of the same token succeeds.
+func (r *TokenRepository) UseRefreshToken(tokenID uint) (bool, error) {
+	res, err := r.db.Exec("UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL AND revoked_at IS NULL",
+		r.dialect.Time(time.Now()), tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// RevokeRefreshTokenFamily revokes all the refresh tokens of a family in the database.
+func (r *TokenRepository) RevokeRefreshTokenFamily(familyID string) error {
+	_, err := r.db.Exec("UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		r.dialect.Time(time.Now()), familyID)
+	return err
+}
+
+// RevokeUserRefreshTokens revokes all the refresh tokens of a user in the database.
+func (r *TokenRepository) RevokeUserRefreshTokens(userID uint) error {
+	_, err := r.db.Exec("UPDATE refresh_tokens SET revoked_at = $1 WHERE userID = $2 AND revoked_at IS NULL",
+		r.dialect.Time(time.Now()), userID)
+	return err
+}
+
+// RevokeAccessToken adds an access token to the revocation list in the database until it expires.
+// The entries of the tokens that have expired since are removed along the way.
+func (r *TokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if _, err := r.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < $1", r.dialect.Time(time.Now())); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec("INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, r.dialect.Time(expiresAt))
+	return err
+}
+
+// IsAccessTokenRevoked checks if an access token is in the revocation list in the database.
+func (r *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked)
+	return revoked, err
+}
+
+// PersonalAccessToken represents a long-lived token created by a user for scripts and CI, which is
+// only stored hashed. A token without scopes is granted every scope.
+type PersonalAccessToken struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenHash   string     `json:"-"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	UserID      uint       `json:"-"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// personalAccessTokenColumns lists the columns of the personal_access_tokens table in the order
+// expected by scanPersonalAccessToken.
+const personalAccessTokenColumns = "id, name, token_hash, token_prefix, scopes, userID, expires_at, last_used_at, created_at"
+
+// scanPersonalAccessToken scans a row selected with personalAccessTokenColumns into a personal access token.
+func scanPersonalAccessToken(row rowScanner) (*PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.Name, &token.TokenHash, &token.TokenPrefix, &scopes, &token.UserID, &expiresAt, &lastUsedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Fields(scopes)
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+
+	return &token, nil
+}
+
+// CreatePersonalAccessToken inserts a new personal access token into the database.
+func (r *TokenRepository) CreatePersonalAccessToken(token *PersonalAccessToken) (*PersonalAccessToken, error) {
+	row := r.db.QueryRow("INSERT INTO personal_access_tokens (name, token_hash, token_prefix, scopes, userID, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING "+personalAccessTokenColumns,
+		token.Name, token.TokenHash, token.TokenPrefix, strings.Join(token.Scopes, " "), token.UserID, r.dialect.NullTime(token.ExpiresAt), r.dialect.Time(time.Now()))
+
+	return scanPersonalAccessToken(row)
+}
+
+// GetPersonalAccessTokenByHash retrieves a personal access token by its hash from the database.
+func (r *TokenRepository) GetPersonalAccessTokenByHash(tokenHash string) (*PersonalAccessToken, error) {
+	row := r.db.QueryRow("SELECT "+personalAccessTokenColumns+" FROM personal_access_tokens WHERE token_hash = $1", tokenHash)
+
+	token, err := scanPersonalAccessToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// ListPersonalAccessTokens retrieves the personal access tokens of a user from the database.
+func (r *TokenRepository) ListPersonalAccessTokens(userID uint) ([]PersonalAccessToken, error) {
+	rows, err := r.db.Query("SELECT "+personalAccessTokenColumns+" FROM personal_access_tokens WHERE userID = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// TouchPersonalAccessToken records that a personal access token was just used in the database.
+func (r *TokenRepository) TouchPersonalAccessToken(tokenID uint) error {
+	_, err := r.db.Exec("UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2", r.dialect.Time(time.Now()), tokenID)
+	return err
+}
+
+// DeletePersonalAccessToken deletes a personal access token from the database, which revokes it.
+func (r *TokenRepository) DeletePersonalAccessToken(tokenID, userID uint) error {
+	res, err := r.db.Exec("DELETE FROM personal_access_tokens WHERE id = $1 AND userID = $2", tokenID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// PasswordResetToken represents a token sent to a user to reset their password, which is only stored
+// hashed.
+type PasswordResetToken struct {
+	ID        uint
+	TokenHash string
+	UserID    uint
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// passwordResetTokenColumns lists the columns of the password_reset_tokens table in the order expected
+// by scanPasswordResetToken.
+const passwordResetTokenColumns = "id, token_hash, userID, expires_at, created_at"
+
+// scanPasswordResetToken scans a row selected with passwordResetTokenColumns into a password reset token.
+func scanPasswordResetToken(row rowScanner) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	if err := row.Scan(&token.ID, &token.TokenHash, &token.UserID, &token.ExpiresAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CreatePasswordResetToken inserts a new password reset token into the database. The tokens that have
+// expired since are removed along the way.
+func (r *TokenRepository) CreatePasswordResetToken(token *PasswordResetToken) (*PasswordResetToken, error) {
+	if _, err := r.db.Exec("DELETE FROM password_reset_tokens WHERE expires_at < $1", r.dialect.Time(time.Now())); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow("INSERT INTO password_reset_tokens (token_hash, userID, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING "+passwordResetTokenColumns,
+		token.TokenHash, token.UserID, r.dialect.Time(token.ExpiresAt), r.dialect.Time(time.Now()))
+
+	return scanPasswordResetToken(row)
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by its hash from the database.
+func (r *TokenRepository) GetPasswordResetTokenByHash(tokenHash string) (*PasswordResetToken, error) {
+	row := r.db.QueryRow("SELECT "+passwordResetTokenColumns+" FROM password_reset_tokens WHERE token_hash = $1", tokenHash)
+
+	token, err := scanPasswordResetToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// ResetPasswordWithToken deletes a password reset token from the database and, along with it, sets the
+// password hash of its user, which revokes the access tokens issued until now, and deletes the other
+// reset tokens of the user. It reports false, changing nothing, if the token was already used, so that
+// only one of concurrent uses of the same token succeeds.
+func (r *TokenRepository) ResetPasswordWithToken(tokenID, userID uint, password string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM password_reset_tokens WHERE id = $1 AND userID = $2", tokenID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if count != 1 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = $1, sessions_revoked_at = $2 WHERE id = $3",
+		password, r.dialect.Time(time.Now()), userID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("DELETE FROM password_reset_tokens WHERE userID = $1", userID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// DeleteUserPasswordResetTokens deletes all the password reset tokens of a user from the database.
+func (r *TokenRepository) DeleteUserPasswordResetTokens(userID uint) error {
+	_, err := r.db.Exec("DELETE FROM password_reset_tokens WHERE userID = $1", userID)
+	return err
+}
+
+// EmailVerificationToken represents a token sent to a user to verify an email, which is only stored
+// hashed.
+type EmailVerificationToken struct {
+	ID        uint
+	TokenHash string
+	UserID    uint
+	Email     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// emailVerificationTokenColumns lists the columns of the email_verification_tokens table in the order
+// expected by scanEmailVerificationToken.
+const emailVerificationTokenColumns = "id, token_hash, userID, email, expires_at, created_at"
+
+// scanEmailVerificationToken scans a row selected with emailVerificationTokenColumns into an email
+// verification token.
+func scanEmailVerificationToken(row rowScanner) (*EmailVerificationToken, error) {
+	var token EmailVerificationToken
+	if err := row.Scan(&token.ID, &token.TokenHash, &token.UserID, &token.Email, &token.ExpiresAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CreateEmailVerificationToken inserts a new email verification token into the database. The tokens
+// that have expired since are removed along the way.
+func (r *TokenRepository) CreateEmailVerificationToken(token *EmailVerificationToken) (*EmailVerificationToken, error) {
+	if _, err := r.db.Exec("DELETE FROM email_verification_tokens WHERE expires_at < $1", r.dialect.Time(time.Now())); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow("INSERT INTO email_verification_tokens (token_hash, userID, email, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING "+emailVerificationTokenColumns,
+		token.TokenHash, token.UserID, token.Email, r.dialect.Time(token.ExpiresAt), r.dialect.Time(time.Now()))
+
+	return scanEmailVerificationToken(row)
+}
+
+// GetEmailVerificationTokenByHash retrieves an email verification token by its hash from the database.
+func (r *TokenRepository) GetEmailVerificationTokenByHash(tokenHash string) (*EmailVerificationToken, error) {
+	row := r.db.QueryRow("SELECT "+emailVerificationTokenColumns+" FROM email_verification_tokens WHERE token_hash = $1", tokenHash)
+
+	token, err := scanEmailVerificationToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetLatestEmailVerificationToken retrieves the email verification token last created for a user from
+// the database.
+func (r *TokenRepository) GetLatestEmailVerificationToken(userID uint) (*EmailVerificationToken, error) {
+	row := r.db.QueryRow("SELECT "+emailVerificationTokenColumns+" FROM email_verification_tokens WHERE userID = $1 ORDER BY created_at DESC, id DESC LIMIT 1", userID)
+
+	token, err := scanEmailVerificationToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// UseEmailVerificationToken deletes an email verification token from the database. It reports false
+// if the token was already used, so that only one of concurrent uses of the same token succeeds.
+func (r *TokenRepository) UseEmailVerificationToken(tokenID uint) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM email_verification_tokens WHERE id = $1", tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// DeleteUserEmailVerificationTokens deletes all the email verification tokens of a user from the database.
+func (r *TokenRepository) DeleteUserEmailVerificationTokens(userID uint) error {
+	_, err := r.db.Exec("DELETE FROM email_verification_tokens WHERE userID = $1", userID)
+	return err
+}
@@ -0,0 +1,375 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// MemoryTokenRepository provides an implementation of TokenStore backed by a MemoryDB.
+type MemoryTokenRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryTokenRepository creates a new instance of MemoryTokenRepository.
+func NewMemoryTokenRepository(mdb *MemoryDB) *MemoryTokenRepository {
+	return &MemoryTokenRepository{mdb: mdb}
+}
+
+// CreateRefreshToken inserts a new refresh token into the datastore.
+func (r *MemoryTokenRepository) CreateRefreshToken(token *RefreshToken) (*RefreshToken, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[token.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	newToken := *token
+	newToken.ID = r.mdb.nextID("refresh_tokens")
+	newToken.ExpiresAt = *memoryTime(&token.ExpiresAt)
+	newToken.UsedAt = nil
+	newToken.RevokedAt = nil
+	newToken.CreatedAt = memoryNow()
+	r.mdb.refreshTokens[newToken.ID] = newToken
+
+	return &newToken, nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash from the datastore.
+func (r *MemoryTokenRepository) GetRefreshTokenByHash(tokenHash string) (*RefreshToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, token := range r.mdb.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UseRefreshToken marks a refresh token as used. It reports false if the token was already used or revoked.
+func (r *MemoryTokenRepository) UseRefreshToken(tokenID uint) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	token, ok := r.mdb.refreshTokens[tokenID]
+	if !ok || token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+
+	now := memoryNow()
+	token.UsedAt = &now
+	r.mdb.refreshTokens[tokenID] = token
+
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily revokes all the refresh tokens of a family in the datastore.
+func (r *MemoryTokenRepository) RevokeRefreshTokenFamily(familyID string) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	r.mdb.revokeRefreshTokens(func(token RefreshToken) bool { return token.FamilyID == familyID })
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes all the refresh tokens of a user in the datastore.
+func (r *MemoryTokenRepository) RevokeUserRefreshTokens(userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	r.mdb.revokeRefreshTokens(func(token RefreshToken) bool { return token.UserID == userID })
+
+	return nil
+}
+
+// RevokeAccessToken adds an access token to the revocation list in the datastore until it expires.
+// The entries of the tokens that have expired since are removed along the way.
+func (r *MemoryTokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range r.mdb.revokedTokens {
+		if exp.Before(now) {
+			delete(r.mdb.revokedTokens, id)
+		}
+	}
+	if _, ok := r.mdb.revokedTokens[jti]; !ok {
+		r.mdb.revokedTokens[jti] = expiresAt
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked checks if an access token is in the revocation list in the datastore.
+func (r *MemoryTokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	_, revoked := r.mdb.revokedTokens[jti]
+
+	return revoked, nil
+}
+
+// revokeRefreshTokens revokes the refresh tokens that aren't revoked yet and match the predicate.
+// The caller must hold the write lock.
+func (m *MemoryDB) revokeRefreshTokens(match func(token RefreshToken) bool) {
+	now := memoryNow()
+	for id, token := range m.refreshTokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			m.refreshTokens[id] = token
+		}
+	}
+}
+
+// CreatePersonalAccessToken inserts a new personal access token into the datastore.
+func (r *MemoryTokenRepository) CreatePersonalAccessToken(token *PersonalAccessToken) (*PersonalAccessToken, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[token.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	newToken := *token
+	newToken.ID = r.mdb.nextID("personal_access_tokens")
+	newToken.Scopes = append([]string{}, token.Scopes...)
+	newToken.ExpiresAt = memoryTime(token.ExpiresAt)
+	newToken.LastUsedAt = nil
+	newToken.CreatedAt = memoryNow()
+	r.mdb.personalAccessTokens[newToken.ID] = newToken
+
+	return &newToken, nil
+}
+
+// GetPersonalAccessTokenByHash retrieves a personal access token by its hash from the datastore.
+func (r *MemoryTokenRepository) GetPersonalAccessTokenByHash(tokenHash string) (*PersonalAccessToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, token := range r.mdb.personalAccessTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ListPersonalAccessTokens retrieves the personal access tokens of a user from the datastore.
+func (r *MemoryTokenRepository) ListPersonalAccessTokens(userID uint) ([]PersonalAccessToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	tokens := []PersonalAccessToken{}
+	for _, token := range r.mdb.personalAccessTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+
+	return tokens, nil
+}
+
+// TouchPersonalAccessToken records that a personal access token was just used in the datastore.
+func (r *MemoryTokenRepository) TouchPersonalAccessToken(tokenID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if token, ok := r.mdb.personalAccessTokens[tokenID]; ok {
+		now := memoryNow()
+		token.LastUsedAt = &now
+		r.mdb.personalAccessTokens[tokenID] = token
+	}
+
+	return nil
+}
+
+// DeletePersonalAccessToken deletes a personal access token from the datastore, which revokes it.
+func (r *MemoryTokenRepository) DeletePersonalAccessToken(tokenID, userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	token, ok := r.mdb.personalAccessTokens[tokenID]
+	if !ok || token.UserID != userID {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	delete(r.mdb.personalAccessTokens, tokenID)
+
+	return nil
+}
+
+// CreatePasswordResetToken inserts a new password reset token into the datastore. The tokens that have
+// expired since are removed along the way.
+func (r *MemoryTokenRepository) CreatePasswordResetToken(token *PasswordResetToken) (*PasswordResetToken, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[token.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	now := time.Now()
+	for id, t := range r.mdb.passwordResetTokens {
+		if t.ExpiresAt.Before(now) {
+			delete(r.mdb.passwordResetTokens, id)
+		}
+	}
+
+	newToken := *token
+	newToken.ID = r.mdb.nextID("password_reset_tokens")
+	newToken.ExpiresAt = *memoryTime(&token.ExpiresAt)
+	newToken.CreatedAt = memoryNow()
+	r.mdb.passwordResetTokens[newToken.ID] = newToken
+
+	return &newToken, nil
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by its hash from the datastore.
+func (r *MemoryTokenRepository) GetPasswordResetTokenByHash(tokenHash string) (*PasswordResetToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, token := range r.mdb.passwordResetTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ResetPasswordWithToken deletes a password reset token from the datastore and, along with it, sets
+// the password hash of its user, which revokes the access tokens issued until now, and deletes the
+// other reset tokens of the user. It reports false, changing nothing, if the token was already used.
+func (r *MemoryTokenRepository) ResetPasswordWithToken(tokenID, userID uint, password string) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	token, ok := r.mdb.passwordResetTokens[tokenID]
+	if !ok || token.UserID != userID {
+		return false, nil
+	}
+	user, ok := r.mdb.users[userID]
+	if !ok {
+		return false, nil
+	}
+
+	now := memoryNow()
+	user.SessionsRevokedAt = &now
+	r.mdb.users[userID] = user
+	r.mdb.passwords[userID] = password
+	for id, token := range r.mdb.passwordResetTokens {
+		if token.UserID == userID {
+			delete(r.mdb.passwordResetTokens, id)
+		}
+	}
+
+	return true, nil
+}
+
+// DeleteUserPasswordResetTokens deletes all the password reset tokens of a user from the datastore.
+func (r *MemoryTokenRepository) DeleteUserPasswordResetTokens(userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	for id, token := range r.mdb.passwordResetTokens {
+		if token.UserID == userID {
+			delete(r.mdb.passwordResetTokens, id)
+		}
+	}
+
+	return nil
+}
+
+// CreateEmailVerificationToken inserts a new email verification token into the datastore. The tokens
+// that have expired since are removed along the way.
+func (r *MemoryTokenRepository) CreateEmailVerificationToken(token *EmailVerificationToken) (*EmailVerificationToken, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[token.UserID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	now := time.Now()
+	for id, t := range r.mdb.emailVerificationTokens {
+		if t.ExpiresAt.Before(now) {
+			delete(r.mdb.emailVerificationTokens, id)
+		}
+	}
+
+	newToken := *token
+	newToken.ID = r.mdb.nextID("email_verification_tokens")
+	newToken.ExpiresAt = *memoryTime(&token.ExpiresAt)
+	newToken.CreatedAt = memoryNow()
+	r.mdb.emailVerificationTokens[newToken.ID] = newToken
+
+	return &newToken, nil
+}
+
+// GetEmailVerificationTokenByHash retrieves an email verification token by its hash from the datastore.
+func (r *MemoryTokenRepository) GetEmailVerificationTokenByHash(tokenHash string) (*EmailVerificationToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, token := range r.mdb.emailVerificationTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetLatestEmailVerificationToken retrieves the email verification token last created for a user from
+// the datastore.
+func (r *MemoryTokenRepository) GetLatestEmailVerificationToken(userID uint) (*EmailVerificationToken, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	var latest *EmailVerificationToken
+	for _, token := range r.mdb.emailVerificationTokens {
+		if token.UserID == userID && (latest == nil || token.ID > latest.ID) {
+			token := token
+			latest = &token
+		}
+	}
+
+	return latest, nil
+}
+
+// UseEmailVerificationToken deletes an email verification token from the datastore. It reports false
+// if the token was already used.
+func (r *MemoryTokenRepository) UseEmailVerificationToken(tokenID uint) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.emailVerificationTokens[tokenID]; !ok {
+		return false, nil
+	}
+	delete(r.mdb.emailVerificationTokens, tokenID)
+
+	return true, nil
+}
+
+// DeleteUserEmailVerificationTokens deletes all the email verification tokens of a user from the datastore.
+func (r *MemoryTokenRepository) DeleteUserEmailVerificationTokens(userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	for id, token := range r.mdb.emailVerificationTokens {
+		if token.UserID == userID {
+			delete(r.mdb.emailVerificationTokens, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRefreshTokenRotation(t *testing.T) {
+	mdb := NewMemoryDB()
+	tokens := NewMemoryTokenRepository(mdb)
+	userID := newTestUser(t, NewMemoryUserRepository(mdb), "alice@example.com")
+
+	newToken := func(hash, familyID string) *RefreshToken {
+		t.Helper()
+		token, err := tokens.CreateRefreshToken(&RefreshToken{TokenHash: hash, FamilyID: familyID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("CreateRefreshToken: %v", err)
+		}
+		return token
+	}
+	first := newToken("first", "laptop")
+	rotated := newToken("rotated", "laptop")
+	other := newToken("other", "phone")
+
+	used, err := tokens.UseRefreshToken(first.ID)
+	if err != nil || !used {
+		t.Fatalf("first use: got %v, %v, want true", used, err)
+	}
+	used, err = tokens.UseRefreshToken(first.ID)
+	if err != nil || used {
+		t.Fatalf("second use: got %v, %v, want false", used, err)
+	}
+
+	// Reusing a token revokes its family only.
+	if err := tokens.RevokeRefreshTokenFamily(first.FamilyID); err != nil {
+		t.Fatalf("RevokeRefreshTokenFamily: %v", err)
+	}
+	tests := []struct {
+		hash    string
+		revoked bool
+	}{
+		{hash: first.TokenHash, revoked: true},
+		{hash: rotated.TokenHash, revoked: true},
+		{hash: other.TokenHash, revoked: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hash, func(t *testing.T) {
+			token, err := tokens.GetRefreshTokenByHash(tt.hash)
+			if err != nil || token == nil {
+				t.Fatalf("GetRefreshTokenByHash: got %v, %v", token, err)
+			}
+			if revoked := token.RevokedAt != nil; revoked != tt.revoked {
+				t.Errorf("got revoked %v, want %v", revoked, tt.revoked)
+			}
+		})
+	}
+}
+
+func TestMemoryResetPasswordWithToken(t *testing.T) {
+	mdb := NewMemoryDB()
+	tokens := NewMemoryTokenRepository(mdb)
+	userID := newTestUser(t, NewMemoryUserRepository(mdb), "alice@example.com")
+	otherID := newTestUser(t, NewMemoryUserRepository(mdb), "bob@example.com")
+
+	var resetTokens []*PasswordResetToken
+	for _, hash := range []string{"first", "second"} {
+		token, err := tokens.CreatePasswordResetToken(&PasswordResetToken{TokenHash: hash, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("CreatePasswordResetToken: %v", err)
+		}
+		resetTokens = append(resetTokens, token)
+	}
+
+	tests := []struct {
+		name    string
+		tokenID uint
+		userID  uint
+		want    bool
+	}{
+		{name: "other user", tokenID: resetTokens[0].ID, userID: otherID, want: false},
+		{name: "first use", tokenID: resetTokens[0].ID, userID: userID, want: true},
+		{name: "second use", tokenID: resetTokens[0].ID, userID: userID, want: false},
+		{name: "other link", tokenID: resetTokens[1].ID, userID: userID, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, err := tokens.ResetPasswordWithToken(tt.tokenID, tt.userID, "new hash")
+			if err != nil {
+				t.Fatalf("ResetPasswordWithToken: %v", err)
+			}
+			if used != tt.want {
+				t.Errorf("got %v, want %v", used, tt.want)
+			}
+		})
+	}
+
+	user, err := NewMemoryUserRepository(mdb).GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.SessionsRevokedAt == nil {
+		t.Error("resetting the password didn't revoke the sessions")
+	}
+	if mdb.passwords[userID] != "new hash" || mdb.passwords[otherID] != "hash" {
+		t.Errorf("got password hashes %q and %q, want only the first one reset", mdb.passwords[userID], mdb.passwords[otherID])
+	}
+}
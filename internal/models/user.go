@@ -1,34 +1,111 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
-// User represents a user in the application.
+	"github.com/milanvthakor/task-manager-api/internal/database"
+	"github.com/milanvthakor/task-manager-api/internal/password"
+)
+
+// User represents a user in the application. The hash of the password isn't part of it, and is only
+// checked by VerifyUserPassword. VerifiedAt is nil until the user verifies their email, and the access tokens issued before
+// SessionsRevokedAt are rejected. The user can't log in while DisabledAt is set. Two-factor
+// authentication is enabled once TOTPEnabledAt is set, and TOTPLastStep is the time step of the last code
+// used.
 type User struct {
-	ID       uint   `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	ID                uint       `json:"id"`
+	Email             string     `json:"email"`
+	DisplayName       string     `json:"display_name"`
+	Timezone          string     `json:"timezone"`
+	Locale            string     `json:"locale"`
+	Role              UserRole   `json:"role"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	DisabledAt        *time.Time `json:"disabled_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	SessionsRevokedAt *time.Time `json:"-"`
+	TOTPSecret        *string    `json:"-"`
+	TOTPEnabledAt     *time.Time `json:"-"`
+	TOTPLastStep      int64      `json:"-"`
+}
+
+// UserRole represents the role of a user, which grants access to the admin API.
+type UserRole string
+
+// Constants for user roles.
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin"
+)
+
+// UserStore provides an interface for user-related storage operations.
+type UserStore interface {
+	CreateUser(user *User, passwordHash string) error
+	GetUserByEmail(email string) (*User, error)
+	GetUserByID(userID uint) (*User, error)
+	ListUsers(opts UserListOptions) (*UserPage, error)
+	UpdateUserProfile(user *User) error
+	UpdateUserRole(userID uint, role UserRole) error
+	SetUserDisabled(userID uint, disabled bool) error
+	RevokeUserSessions(userID uint) error
+	UpdateUserPassword(userID uint, password string) error
+	VerifyUserPassword(userID uint, plaintext string, hasher *password.Hasher) (bool, error)
+	VerifyUserEmail(userID uint, email string) error
+	UpdateUserTOTP(userID uint, secret *string, enabled bool) error
+	UseUserTOTPStep(userID uint, step int64) (bool, error)
+	DeleteUser(userID uint) error
+}
+
+// userColumns lists the columns of the users table in the order expected by scanUser.
+const userColumns = "id, email, display_name, timezone, locale, role, verified_at, disabled_at, created_at, " +
+	"sessions_revoked_at, totp_secret, totp_enabled_at, totp_last_step"
+
+// scanUser scans a row selected with userColumns into a user.
+func scanUser(row rowScanner) (*User, error) {
+	var user User
+	var verifiedAt, disabledAt, sessionsRevokedAt, totpEnabledAt sql.NullTime
+	var totpSecret sql.NullString
+	if err := row.Scan(&user.ID, &user.Email, &user.DisplayName, &user.Timezone, &user.Locale, &user.Role,
+		&verifiedAt, &disabledAt, &user.CreatedAt, &sessionsRevokedAt, &totpSecret, &totpEnabledAt, &user.TOTPLastStep); err != nil {
+		return nil, err
+	}
+	user.VerifiedAt = nullTimePtr(verifiedAt)
+	user.DisabledAt = nullTimePtr(disabledAt)
+	user.SessionsRevokedAt = nullTimePtr(sessionsRevokedAt)
+	if totpSecret.Valid {
+		user.TOTPSecret = &totpSecret.String
+	}
+	user.TOTPEnabledAt = nullTimePtr(totpEnabledAt)
+
+	return &user, nil
 }
 
-// UserRepository provides an interface for user-related database operations.
+// UserRepository provides an implementation of UserStore backed by an SQL database.
 type UserRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect database.Dialect
 }
 
 // NewUserRepository creates a new instance of UserRepository.
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sql.DB, dialect database.Dialect) *UserRepository {
+	return &UserRepository{db: db, dialect: dialect}
 }
 
-// CreateUser inserts a new user into the database.
-func (r *UserRepository) CreateUser(user *User) error {
-	_, err := r.db.Exec("INSERT INTO users (email, password) VALUES ($1, $2)", user.Email, user.Password)
-	return err
+// CreateUser inserts a new user into the database, along with the hash of its password, and sets its
+// ID. The profile settings and role left empty get their default values.
+func (r *UserRepository) CreateUser(user *User, passwordHash string) error {
+	user.setProfileDefaults()
+	user.CreatedAt = time.Now()
+
+	return r.db.QueryRow("INSERT INTO users (email, password, display_name, timezone, locale, role, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		user.Email, passwordHash, user.DisplayName, user.Timezone, user.Locale, user.Role, r.dialect.Time(user.CreatedAt)).Scan(&user.ID)
 }
 
 // GetUserByEmail retrieves a user by email from the database.
 func (r *UserRepository) GetUserByEmail(email string) (*User, error) {
-	var user User
-	err := r.db.QueryRow("SELECT * FROM users WHERE email = $1", email).Scan(&user.ID, &user.Email, &user.Password)
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = $1", email))
 	if err == sql.ErrNoRows {
 		return nil, nil // Return nil when no records are found
 	}
@@ -36,5 +113,228 @@ func (r *UserRepository) GetUserByEmail(email string) (*User, error) {
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// GetUserByID retrieves a user by ID from the database.
+func (r *UserRepository) GetUserByID(userID uint) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", userID))
+	if err == sql.ErrNoRows {
+		return nil, nil // Return nil when no records are found
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListUsers retrieves a page of the users in the database, ordered by ID.
+func (r *UserRepository) ListUsers(opts UserListOptions) (*UserPage, error) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var conditions []string
+	if opts.Query != "" {
+		pattern := arg("%" + escapeLike(opts.Query) + "%")
+		conditions = append(conditions, "(email "+r.dialect.ILike()+" "+pattern+" ESCAPE '\\' OR display_name "+r.dialect.ILike()+" "+pattern+" ESCAPE '\\')")
+	}
+	if opts.Role != "" {
+		conditions = append(conditions, "role = "+arg(opts.Role))
+	}
+	if opts.Disabled != nil {
+		if *opts.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+	if opts.Cursor != "" {
+		id, err := decodeUserCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "id > "+arg(id))
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Fetch one more user than requested to find out if there's a next page.
+	rows, err := r.db.Query(query+" ORDER BY id LIMIT "+arg(opts.Limit+1), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &UserPage{Users: []User{}}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Users = append(page.Users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Users) > opts.Limit {
+		page.Users = page.Users[:opts.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[opts.Limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// UpdateUserProfile updates the display name, timezone and locale of a user in the database.
+func (r *UserRepository) UpdateUserProfile(user *User) error {
+	_, err := r.db.Exec("UPDATE users SET display_name = $1, timezone = $2, locale = $3 WHERE id = $4",
+		user.DisplayName, user.Timezone, user.Locale, user.ID)
+	return err
+}
+
+// UpdateUserRole sets the role of a user in the database.
+func (r *UserRepository) UpdateUserRole(userID uint, role UserRole) error {
+	_, err := r.db.Exec("UPDATE users SET role = $1 WHERE id = $2", role, userID)
+	return err
+}
+
+// SetUserDisabled disables or enables the account of a user in the database. Disabling it also revokes
+// the access tokens issued until now.
+func (r *UserRepository) SetUserDisabled(userID uint, disabled bool) error {
+	if !disabled {
+		_, err := r.db.Exec("UPDATE users SET disabled_at = NULL WHERE id = $1", userID)
+		return err
+	}
+
+	now := r.dialect.Time(time.Now())
+	_, err := r.db.Exec("UPDATE users SET disabled_at = $1, sessions_revoked_at = $1 WHERE id = $2", now, userID)
+	return err
+}
+
+// RevokeUserSessions revokes the access tokens issued to a user until now in the database.
+func (r *UserRepository) RevokeUserSessions(userID uint) error {
+	_, err := r.db.Exec("UPDATE users SET sessions_revoked_at = $1 WHERE id = $2", r.dialect.Time(time.Now()), userID)
+	return err
+}
+
+// UpdateUserPassword updates the password hash of a user in the database and revokes the access
+// tokens issued until now.
+func (r *UserRepository) UpdateUserPassword(userID uint, password string) error {
+	_, err := r.db.Exec("UPDATE users SET password = $1, sessions_revoked_at = $2 WHERE id = $3",
+		password, r.dialect.Time(time.Now()), userID)
+	return err
+}
+
+// VerifyUserPassword checks a password against the hash stored for a user in the database, and reports
+// false for unknown users. Once verified, a password hashed with another algorithm or other parameters
+// than those of the hasher is hashed again, unless it changed meanwhile, which doesn't revoke sessions.
+// An error along with a match means that hashing it again failed, and is tried again on the next check.
+func (r *UserRepository) VerifyUserPassword(userID uint, plaintext string, hasher *password.Hasher) (bool, error) {
+	var hash string
+	err := r.db.QueryRow("SELECT password FROM users WHERE id = $1", userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	match, err := hasher.Verify(hash, plaintext)
+	if !match || err != nil || !hasher.NeedsRehash(hash) {
+		return match, err
+	}
+
+	newHash, err := hasher.Hash(plaintext)
+	if err != nil {
+		return true, err
+	}
+	_, err = r.db.Exec("UPDATE users SET password = $1 WHERE id = $2 AND password = $3", newHash, userID, hash)
+	return true, err
+}
+
+// VerifyUserEmail sets the email of a user in the database, which is marked as verified.
+func (r *UserRepository) VerifyUserEmail(userID uint, email string) error {
+	_, err := r.db.Exec("UPDATE users SET email = $1, verified_at = $2 WHERE id = $3",
+		email, r.dialect.Time(time.Now()), userID)
+	return err
+}
+
+// UpdateUserTOTP sets the TOTP secret of a user in the database, and whether two-factor authentication
+// is enabled. The time step of the last code used is reset.
+func (r *UserRepository) UpdateUserTOTP(userID uint, secret *string, enabled bool) error {
+	var enabledAt *time.Time
+	if enabled {
+		now := time.Now()
+		enabledAt = &now
+	}
+
+	_, err := r.db.Exec("UPDATE users SET totp_secret = $1, totp_enabled_at = $2, totp_last_step = 0 WHERE id = $3",
+		secret, r.dialect.NullTime(enabledAt), userID)
+	return err
+}
+
+// UseUserTOTPStep records the time step of a TOTP code used by a user in the database. It reports false
+// if a code of the same or a later time step was already used, so that codes can't be replayed.
+func (r *UserRepository) UseUserTOTPStep(userID uint, step int64) (bool, error) {
+	res, err := r.db.Exec("UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1", step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// DeleteUser erases a user from the database within a transaction: their tasks, labels, projects, tokens
+// and data exports are deleted by cascade, and their audit log entries are kept, but anonymized.
+func (r *UserRepository) DeleteUser(userID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE audit_log SET userID = NULL, ip = '', details = '' WHERE userID = $1", userID); err != nil {
+		return err
+	}
+	// Delete the workspaces the user owns, and hand the tasks they created in the other ones over to
+	// their owner.
+	if _, err := tx.Exec("DELETE FROM workspaces WHERE id IN (SELECT workspace_id FROM workspace_members WHERE userID = $1 AND role = $2)",
+		userID, WorkspaceRoleOwner); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET userID = (SELECT workspace_members.userID FROM workspace_members WHERE workspace_id = tasks.workspace_id AND role = $1)"+
+		" WHERE userID = $2 AND workspace_id IS NOT NULL", WorkspaceRoleOwner, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = $1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setProfileDefaults sets the default values of the profile settings and role left empty.
+func (u *User) setProfileDefaults() {
+	if u.Role == "" {
+		u.Role = UserRoleUser
+	}
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+	if u.Locale == "" {
+		u.Locale = "en"
+	}
 }
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// UserListOptions holds the filtering and pagination options for listing users.
+type UserListOptions struct {
+	// Query restricts the list to the users whose email or display name contain it, if set.
+	Query string
+	// Role restricts the list to the users with the role, if set.
+	Role UserRole
+	// Disabled restricts the list to the disabled users, or to the active ones, if set.
+	Disabled *bool
+	// Limit is the maximum number of users to return.
+	Limit int
+	// Cursor is the opaque position returned by the previous page, if any.
+	Cursor string
+}
+
+// UserPage represents a page of users along with the cursor of the next one.
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// encodeUserCursor encodes the position of a user, as users are listed by ID.
+func encodeUserCursor(userID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(userID), 10)))
+}
+
+// decodeUserCursor decodes a cursor and returns the ID of the user it points at.
+func decodeUserCursor(cursor string) (uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(string(data), 10, 32)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	return uint(id), nil
+}
+
+// matches checks if a user satisfies the filters of the options.
+func (o *UserListOptions) matches(user *User) bool {
+	if o.Query != "" {
+		query := strings.ToLower(o.Query)
+		if !strings.Contains(strings.ToLower(user.Email), query) && !strings.Contains(strings.ToLower(user.DisplayName), query) {
+			return false
+		}
+	}
+	if o.Role != "" && user.Role != o.Role {
+		return false
+	}
+	if o.Disabled != nil && (user.DisabledAt != nil) != *o.Disabled {
+		return false
+	}
+
+	return true
+}
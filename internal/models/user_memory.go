@@ -0,0 +1,285 @@
+package models
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/milanvthakor/task-manager-api/internal/password"
+)
+
+// ErrDuplicateEmail is returned when creating a user with an email that is already taken.
+var ErrDuplicateEmail = errors.New("duplicate key value violates unique constraint on email")
+
+// MemoryUserRepository provides an implementation of UserStore backed by a MemoryDB.
+type MemoryUserRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryUserRepository creates a new instance of MemoryUserRepository.
+func NewMemoryUserRepository(mdb *MemoryDB) *MemoryUserRepository {
+	return &MemoryUserRepository{mdb: mdb}
+}
+
+// CreateUser inserts a new user into the datastore, along with the hash of its password, and sets its
+// ID. The profile settings and role left empty get their default values.
+func (r *MemoryUserRepository) CreateUser(user *User, passwordHash string) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	for _, u := range r.mdb.users {
+		if u.Email == user.Email {
+			return ErrDuplicateEmail
+		}
+	}
+
+	user.setProfileDefaults()
+	user.CreatedAt = memoryNow()
+	newUser := *user
+	newUser.ID = r.mdb.nextID("users")
+	r.mdb.users[newUser.ID] = newUser
+	r.mdb.passwords[newUser.ID] = passwordHash
+	user.ID = newUser.ID
+
+	return nil
+}
+
+// GetUserByEmail retrieves a user by email from the datastore.
+func (r *MemoryUserRepository) GetUserByEmail(email string) (*User, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, u := range r.mdb.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+
+	return nil, nil // Return nil when no records are found
+}
+
+// GetUserByID retrieves a user by ID from the datastore.
+func (r *MemoryUserRepository) GetUserByID(userID uint) (*User, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	user, ok := r.mdb.users[userID]
+	if !ok {
+		return nil, nil // Return nil when no records are found
+	}
+
+	return &user, nil
+}
+
+// ListUsers retrieves a page of the users in the datastore, ordered by ID.
+func (r *MemoryUserRepository) ListUsers(opts UserListOptions) (*UserPage, error) {
+	var cursorID uint
+	if opts.Cursor != "" {
+		var err error
+		cursorID, err = decodeUserCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mdb.mu.RLock()
+	users := []User{}
+	for _, user := range r.mdb.users {
+		user := user
+		if user.ID > cursorID && opts.matches(&user) {
+			users = append(users, user)
+		}
+	}
+	r.mdb.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	page := &UserPage{Users: users}
+	if len(page.Users) > opts.Limit {
+		page.Users = page.Users[:opts.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[opts.Limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// UpdateUserProfile updates the display name, timezone and locale of a user in the datastore.
+func (r *MemoryUserRepository) UpdateUserProfile(user *User) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if u, ok := r.mdb.users[user.ID]; ok {
+		u.DisplayName = user.DisplayName
+		u.Timezone = user.Timezone
+		u.Locale = user.Locale
+		r.mdb.users[user.ID] = u
+	}
+
+	return nil
+}
+
+// UpdateUserRole sets the role of a user in the datastore.
+func (r *MemoryUserRepository) UpdateUserRole(userID uint, role UserRole) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if user, ok := r.mdb.users[userID]; ok {
+		user.Role = role
+		r.mdb.users[userID] = user
+	}
+
+	return nil
+}
+
+// SetUserDisabled disables or enables the account of a user in the datastore. Disabling it also revokes
+// the access tokens issued until now.
+func (r *MemoryUserRepository) SetUserDisabled(userID uint, disabled bool) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if user, ok := r.mdb.users[userID]; ok {
+		user.DisabledAt = nil
+		if disabled {
+			now := memoryNow()
+			user.DisabledAt = &now
+			user.SessionsRevokedAt = &now
+		}
+		r.mdb.users[userID] = user
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes the access tokens issued to a user until now in the datastore.
+func (r *MemoryUserRepository) RevokeUserSessions(userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if user, ok := r.mdb.users[userID]; ok {
+		now := memoryNow()
+		user.SessionsRevokedAt = &now
+		r.mdb.users[userID] = user
+	}
+
+	return nil
+}
+
+// UpdateUserPassword updates the password hash of a user in the datastore and revokes the access
+// tokens issued until now.
+func (r *MemoryUserRepository) UpdateUserPassword(userID uint, password string) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if user, ok := r.mdb.users[userID]; ok {
+		now := memoryNow()
+		user.SessionsRevokedAt = &now
+		r.mdb.users[userID] = user
+		r.mdb.passwords[userID] = password
+	}
+
+	return nil
+}
+
+// VerifyUserPassword checks a password against the hash stored for a user in the datastore, and reports
+// false for unknown users. Once verified, a password hashed with another algorithm or other parameters
+// than those of the hasher is hashed again, unless it changed meanwhile.
+func (r *MemoryUserRepository) VerifyUserPassword(userID uint, plaintext string, hasher *password.Hasher) (bool, error) {
+	r.mdb.mu.RLock()
+	hash, ok := r.mdb.passwords[userID]
+	r.mdb.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	// Hashing is slow, so it's done without holding the lock.
+	match, err := hasher.Verify(hash, plaintext)
+	if !match || err != nil || !hasher.NeedsRehash(hash) {
+		return match, err
+	}
+
+	newHash, err := hasher.Hash(plaintext)
+	if err != nil {
+		return true, err
+	}
+
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if r.mdb.passwords[userID] == hash {
+		r.mdb.passwords[userID] = newHash
+	}
+
+	return true, nil
+}
+
+// VerifyUserEmail sets the email of a user in the datastore, which is marked as verified.
+func (r *MemoryUserRepository) VerifyUserEmail(userID uint, email string) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	for _, u := range r.mdb.users {
+		if u.Email == email && u.ID != userID {
+			return ErrDuplicateEmail
+		}
+	}
+
+	if user, ok := r.mdb.users[userID]; ok {
+		now := memoryNow()
+		user.Email = email
+		user.VerifiedAt = &now
+		r.mdb.users[userID] = user
+	}
+
+	return nil
+}
+
+// UpdateUserTOTP sets the TOTP secret of a user in the datastore, and whether two-factor authentication
+// is enabled. The time step of the last code used is reset.
+func (r *MemoryUserRepository) UpdateUserTOTP(userID uint, secret *string, enabled bool) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if user, ok := r.mdb.users[userID]; ok {
+		user.TOTPSecret = nil
+		if secret != nil {
+			s := *secret
+			user.TOTPSecret = &s
+		}
+		user.TOTPEnabledAt = nil
+		if enabled {
+			now := memoryNow()
+			user.TOTPEnabledAt = &now
+		}
+		user.TOTPLastStep = 0
+		r.mdb.users[userID] = user
+	}
+
+	return nil
+}
+
+// UseUserTOTPStep records the time step of a TOTP code used by a user in the datastore. It reports false
+// if a code of the same or a later time step was already used.
+func (r *MemoryUserRepository) UseUserTOTPStep(userID uint, step int64) (bool, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	user, ok := r.mdb.users[userID]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	r.mdb.users[userID] = user
+
+	return true, nil
+}
+
+// DeleteUser erases a user from the datastore: their tasks, labels, projects, tokens and data exports are
+// deleted, and their audit log entries are kept, but anonymized.
+func (r *MemoryUserRepository) DeleteUser(userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	r.mdb.deleteUser(userID)
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/milanvthakor/task-manager-api/internal/password"
+)
+
+func TestMemoryVerifyUserPassword(t *testing.T) {
+	mdb := NewMemoryDB()
+	users := NewMemoryUserRepository(mdb)
+
+	bcryptHasher, err := password.NewHasher(password.AlgorithmBcrypt, 4, password.Argon2Params{}, 1)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+	argon2Hasher, err := password.NewHasher(password.AlgorithmArgon2id, 4, password.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}, 1)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+	hash, err := bcryptHasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	user := &User{Email: "alice@example.com"}
+	if err := users.CreateUser(user, hash); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// The hash is only replaced once the password is verified with a hasher configured otherwise.
+	tests := []struct {
+		name     string
+		userID   uint
+		password string
+		hasher   *password.Hasher
+		want     bool
+		prefix   string
+	}{
+		{name: "unknown user", userID: 42, password: "correct horse", hasher: bcryptHasher, want: false, prefix: "$2a$"},
+		{name: "wrong password", userID: user.ID, password: "battery staple", hasher: argon2Hasher, want: false, prefix: "$2a$"},
+		{name: "same algorithm", userID: user.ID, password: "correct horse", hasher: bcryptHasher, want: true, prefix: "$2a$"},
+		{name: "other algorithm", userID: user.ID, password: "correct horse", hasher: argon2Hasher, want: true, prefix: "$argon2id$"},
+		{name: "rehashed", userID: user.ID, password: "correct horse", hasher: bcryptHasher, want: true, prefix: "$2a$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := users.VerifyUserPassword(tt.userID, tt.password, tt.hasher)
+			if err != nil {
+				t.Fatalf("VerifyUserPassword: %v", err)
+			}
+			if match != tt.want {
+				t.Errorf("got match %v, want %v", match, tt.want)
+			}
+			if hash := mdb.passwords[user.ID]; !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("got hash %q, want it prefixed with %q", hash, tt.prefix)
+			}
+		})
+	}
+
+	user, err = users.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.SessionsRevokedAt != nil {
+		t.Error("rehashing the password revoked the sessions")
+	}
+}
+
+func TestMemoryUseUserTOTPStep(t *testing.T) {
+	mdb := NewMemoryDB()
+	users := NewMemoryUserRepository(mdb)
+	userID := newTestUser(t, NewMemoryUserRepository(mdb), "alice@example.com")
+
+	tests := []struct {
+		name   string
+		userID uint
+		step   int64
+		want   bool
+	}{
+		{name: "first code", userID: userID, step: 100, want: true},
+		{name: "replayed code", userID: userID, step: 100, want: false},
+		{name: "earlier code", userID: userID, step: 99, want: false},
+		{name: "next code", userID: userID, step: 101, want: true},
+		{name: "unknown user", userID: 42, step: 102, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, err := users.UseUserTOTPStep(tt.userID, tt.step)
+			if err != nil {
+				t.Fatalf("UseUserTOTPStep: %v", err)
+			}
+			if used != tt.want {
+				t.Errorf("got %v, want %v", used, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/milanvthakor/task-manager-api/internal/database"
+)
+
+// ErrAlreadyMember is returned when adding a user to a workspace they're already a member of.
+var ErrAlreadyMember = errors.New("user is already a member of the workspace")
+
+// Workspace represents a team sharing a backlog of tasks.
+type Workspace struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceRole represents the role of a member in a workspace.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleMember WorkspaceRole = "member"
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+)
+
+// Rank returns the rank of the role, which is higher the more the role allows. Unknown roles rank 0.
+func (r WorkspaceRole) Rank() int {
+	switch r {
+	case WorkspaceRoleOwner:
+		return 4
+	case WorkspaceRoleAdmin:
+		return 3
+	case WorkspaceRoleMember:
+		return 2
+	case WorkspaceRoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// WorkspaceMembership represents a workspace along with the role of a user in it.
+type WorkspaceMembership struct {
+	Workspace
+	Role WorkspaceRole `json:"role"`
+}
+
+// WorkspaceMember represents a member of a workspace along with their role.
+type WorkspaceMember struct {
+	WorkspaceID uint          `json:"-"`
+	UserID      uint          `json:"user_id"`
+	Email       string        `json:"email"`
+	DisplayName string        `json:"display_name"`
+	Role        WorkspaceRole `json:"role"`
+	CreatedAt   time.Time     `json:"joined_at"`
+}
+
+// WorkspaceInvite represents an invite to join a workspace sent by email, whose token is only stored
+// hashed.
+type WorkspaceInvite struct {
+	ID          uint          `json:"id"`
+	WorkspaceID uint          `json:"workspace_id"`
+	Email       string        `json:"email"`
+	Role        WorkspaceRole `json:"role"`
+	TokenHash   string        `json:"-"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// WorkspaceStore provides an interface for storage operations related to the workspaces, their members
+// and their invites.
+type WorkspaceStore interface {
+	CreateWorkspace(workspace *Workspace, ownerID uint) (*Workspace, error)
+	GetWorkspaceByID(workspaceID uint) (*Workspace, error)
+	UpdateWorkspace(workspace *Workspace) (*Workspace, error)
+	DeleteWorkspace(workspaceID uint) error
+	ListWorkspaceMemberships(userID uint) ([]WorkspaceMembership, error)
+	GetWorkspaceMember(workspaceID, userID uint) (*WorkspaceMember, error)
+	ListWorkspaceMembers(workspaceID uint) ([]WorkspaceMember, error)
+	UpdateWorkspaceMemberRole(workspaceID, userID uint, role WorkspaceRole) error
+	TransferWorkspaceOwnership(workspaceID, fromUserID, toUserID uint) error
+	RemoveWorkspaceMember(workspaceID, userID uint) error
+	CreateWorkspaceInvite(invite *WorkspaceInvite) (*WorkspaceInvite, error)
+	GetWorkspaceInviteByID(inviteID uint) (*WorkspaceInvite, error)
+	GetWorkspaceInviteByHash(tokenHash string) (*WorkspaceInvite, error)
+	ListWorkspaceInvites(workspaceID uint) ([]WorkspaceInvite, error)
+	DeleteWorkspaceInvite(inviteID uint) error
+	AcceptWorkspaceInvite(inviteID, userID uint) error
+}
+
+// workspaceColumns lists the columns of the workspaces table in the order expected by scanWorkspace.
+const workspaceColumns = "id, name, created_at"
+
+// scanWorkspace scans a row selected with workspaceColumns into a workspace.
+func scanWorkspace(row rowScanner) (*Workspace, error) {
+	var workspace Workspace
+	if err := row.Scan(&workspace.ID, &workspace.Name, &workspace.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// workspaceMemberColumns lists the columns of the workspace_members table joined with the users table,
+// in the order expected by scanWorkspaceMember.
+const workspaceMemberColumns = "workspace_members.workspace_id, workspace_members.userID, users.email, users.display_name, workspace_members.role, workspace_members.created_at"
+
+// scanWorkspaceMember scans a row selected with workspaceMemberColumns into a workspace member.
+func scanWorkspaceMember(row rowScanner) (*WorkspaceMember, error) {
+	var member WorkspaceMember
+	if err := row.Scan(&member.WorkspaceID, &member.UserID, &member.Email, &member.DisplayName, &member.Role, &member.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// workspaceInviteColumns lists the columns of the workspace_invites table in the order expected by
+// scanWorkspaceInvite.
+const workspaceInviteColumns = "id, workspace_id, email, role, token_hash, expires_at, created_at"
+
+// scanWorkspaceInvite scans a row selected with workspaceInviteColumns into a workspace invite.
+func scanWorkspaceInvite(row rowScanner) (*WorkspaceInvite, error) {
+	var invite WorkspaceInvite
+	if err := row.Scan(&invite.ID, &invite.WorkspaceID, &invite.Email, &invite.Role, &invite.TokenHash, &invite.ExpiresAt, &invite.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// WorkspaceRepository provides an implementation of WorkspaceStore backed by an SQL database.
+type WorkspaceRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewWorkspaceRepository creates a new instance of WorkspaceRepository.
+func NewWorkspaceRepository(db *sql.DB, dialect database.Dialect) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db, dialect: dialect}
+}
+
+// CreateWorkspace inserts a new workspace into the database, with the user as its owner.
+func (r *WorkspaceRepository) CreateWorkspace(workspace *Workspace, ownerID uint) (*Workspace, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := r.dialect.Time(time.Now())
+	newWorkspace, err := scanWorkspace(tx.QueryRow("INSERT INTO workspaces (name, created_at) VALUES ($1, $2) RETURNING "+workspaceColumns,
+		workspace.Name, now))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("INSERT INTO workspace_members (workspace_id, userID, role, created_at) VALUES ($1, $2, $3, $4)",
+		newWorkspace.ID, ownerID, WorkspaceRoleOwner, now); err != nil {
+		return nil, err
+	}
+
+	return newWorkspace, tx.Commit()
+}
+
+// GetWorkspaceByID retrieves a workspace by its ID from the database.
+func (r *WorkspaceRepository) GetWorkspaceByID(workspaceID uint) (*Workspace, error) {
+	workspace, err := scanWorkspace(r.db.QueryRow("SELECT "+workspaceColumns+" FROM workspaces WHERE id = $1", workspaceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return workspace, err
+}
+
+// UpdateWorkspace updates a workspace in the database.
+func (r *WorkspaceRepository) UpdateWorkspace(workspace *Workspace) (*Workspace, error) {
+	row := r.db.QueryRow("UPDATE workspaces SET name = $1 WHERE id = $2 RETURNING "+workspaceColumns, workspace.Name, workspace.ID)
+
+	return scanWorkspace(row)
+}
+
+// DeleteWorkspace deletes a workspace from the database, along with its members, invites and tasks.
+func (r *WorkspaceRepository) DeleteWorkspace(workspaceID uint) error {
+	res, err := r.db.Exec("DELETE FROM workspaces WHERE id = $1", workspaceID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// ListWorkspaceMemberships retrieves the workspaces a user is a member of from the database, along with
+// their role in each.
+func (r *WorkspaceRepository) ListWorkspaceMemberships(userID uint) ([]WorkspaceMembership, error) {
+	rows, err := r.db.Query("SELECT workspaces.id, workspaces.name, workspaces.created_at, workspace_members.role"+
+		" FROM workspaces JOIN workspace_members ON workspace_members.workspace_id = workspaces.id"+
+		" WHERE workspace_members.userID = $1 ORDER BY workspaces.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []WorkspaceMembership{}
+	for rows.Next() {
+		var membership WorkspaceMembership
+		if err := rows.Scan(&membership.ID, &membership.Name, &membership.CreatedAt, &membership.Role); err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+// GetWorkspaceMember retrieves the membership of a user in a workspace from the database. It returns nil
+// if the user isn't a member.
+func (r *WorkspaceRepository) GetWorkspaceMember(workspaceID, userID uint) (*WorkspaceMember, error) {
+	row := r.db.QueryRow("SELECT "+workspaceMemberColumns+" FROM workspace_members JOIN users ON users.id = workspace_members.userID"+
+		" WHERE workspace_members.workspace_id = $1 AND workspace_members.userID = $2", workspaceID, userID)
+
+	member, err := scanWorkspaceMember(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return member, err
+}
+
+// ListWorkspaceMembers retrieves the members of a workspace from the database, in the order they joined.
+func (r *WorkspaceRepository) ListWorkspaceMembers(workspaceID uint) ([]WorkspaceMember, error) {
+	rows, err := r.db.Query("SELECT "+workspaceMemberColumns+" FROM workspace_members JOIN users ON users.id = workspace_members.userID"+
+		" WHERE workspace_members.workspace_id = $1 ORDER BY workspace_members.created_at, workspace_members.userID", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []WorkspaceMember{}
+	for rows.Next() {
+		member, err := scanWorkspaceMember(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, *member)
+	}
+
+	return members, rows.Err()
+}
+
+// UpdateWorkspaceMemberRole changes the role of a member of a workspace in the database.
+func (r *WorkspaceRepository) UpdateWorkspaceMemberRole(workspaceID, userID uint, role WorkspaceRole) error {
+	res, err := r.db.Exec("UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND userID = $3", role, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// TransferWorkspaceOwnership makes a member the owner of a workspace in the database, and its previous
+// owner an admin.
+func (r *WorkspaceRepository) TransferWorkspaceOwnership(workspaceID, fromUserID, toUserID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, change := range []struct {
+		userID uint
+		role   WorkspaceRole
+	}{{toUserID, WorkspaceRoleOwner}, {fromUserID, WorkspaceRoleAdmin}} {
+		res, err := tx.Exec("UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND userID = $3", change.role, workspaceID, change.userID)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count < 1 {
+			return sql.ErrNoRows
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveWorkspaceMember removes a member from a workspace in the database. The tasks of the workspace
+// assigned to them are unassigned, and the tasks they created in it are handed over to its owner.
+func (r *WorkspaceRepository) RemoveWorkspaceMember(workspaceID, userID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM workspace_members WHERE workspace_id = $1 AND userID = $2", workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("UPDATE tasks SET assignee_id = NULL WHERE workspace_id = $1 AND assignee_id = $2", workspaceID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET userID = (SELECT workspace_members.userID FROM workspace_members WHERE workspace_id = $1 AND role = $2)"+
+		" WHERE workspace_id = $1 AND userID = $3", workspaceID, WorkspaceRoleOwner, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateWorkspaceInvite inserts a new invite into the database, replacing any previous invite of the same
+// email to the workspace. The expired invites are deleted along the way.
+func (r *WorkspaceRepository) CreateWorkspaceInvite(invite *WorkspaceInvite) (*WorkspaceInvite, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec("DELETE FROM workspace_invites WHERE expires_at < $1 OR (workspace_id = $2 AND email = $3)",
+		r.dialect.Time(now), invite.WorkspaceID, invite.Email); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow("INSERT INTO workspace_invites (workspace_id, email, role, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "+workspaceInviteColumns,
+		invite.WorkspaceID, invite.Email, invite.Role, invite.TokenHash, r.dialect.Time(invite.ExpiresAt), r.dialect.Time(now))
+	newInvite, err := scanWorkspaceInvite(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return newInvite, tx.Commit()
+}
+
+// GetWorkspaceInviteByID retrieves an invite by its ID from the database.
+func (r *WorkspaceRepository) GetWorkspaceInviteByID(inviteID uint) (*WorkspaceInvite, error) {
+	invite, err := scanWorkspaceInvite(r.db.QueryRow("SELECT "+workspaceInviteColumns+" FROM workspace_invites WHERE id = $1", inviteID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return invite, err
+}
+
+// GetWorkspaceInviteByHash retrieves an invite by the hash of its token from the database.
+func (r *WorkspaceRepository) GetWorkspaceInviteByHash(tokenHash string) (*WorkspaceInvite, error) {
+	invite, err := scanWorkspaceInvite(r.db.QueryRow("SELECT "+workspaceInviteColumns+" FROM workspace_invites WHERE token_hash = $1", tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return invite, err
+}
+
+// ListWorkspaceInvites retrieves the pending invites of a workspace from the database, leaving out the
+// expired ones.
+func (r *WorkspaceRepository) ListWorkspaceInvites(workspaceID uint) ([]WorkspaceInvite, error) {
+	rows, err := r.db.Query("SELECT "+workspaceInviteColumns+" FROM workspace_invites WHERE workspace_id = $1 AND expires_at >= $2 ORDER BY id",
+		workspaceID, r.dialect.Time(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []WorkspaceInvite{}
+	for rows.Next() {
+		invite, err := scanWorkspaceInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		invites = append(invites, *invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// DeleteWorkspaceInvite deletes an invite from the database.
+func (r *WorkspaceRepository) DeleteWorkspaceInvite(inviteID uint) error {
+	res, err := r.db.Exec("DELETE FROM workspace_invites WHERE id = $1", inviteID)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count < 1 {
+		return sql.ErrNoRows // No rows were deleted
+	}
+
+	return nil
+}
+
+// AcceptWorkspaceInvite deletes an invite from the database and adds the user to its workspace with the
+// role of the invite. It returns sql.ErrNoRows if the invite was already used, and ErrAlreadyMember if the
+// user is already a member of the workspace.
+func (r *WorkspaceRepository) AcceptWorkspaceInvite(inviteID, userID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	invite, err := scanWorkspaceInvite(tx.QueryRow("DELETE FROM workspace_invites WHERE id = $1 RETURNING "+workspaceInviteColumns, inviteID))
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND userID = $2", invite.WorkspaceID, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAlreadyMember
+	}
+
+	if _, err := tx.Exec("INSERT INTO workspace_members (workspace_id, userID, role, created_at) VALUES ($1, $2, $3, $4)",
+		invite.WorkspaceID, userID, invite.Role, r.dialect.Time(time.Now())); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
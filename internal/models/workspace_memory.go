@@ -0,0 +1,358 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// MemoryWorkspaceRepository provides an implementation of WorkspaceStore backed by a MemoryDB.
+type MemoryWorkspaceRepository struct {
+	mdb *MemoryDB
+}
+
+// NewMemoryWorkspaceRepository creates a new instance of MemoryWorkspaceRepository.
+func NewMemoryWorkspaceRepository(mdb *MemoryDB) *MemoryWorkspaceRepository {
+	return &MemoryWorkspaceRepository{mdb: mdb}
+}
+
+// CreateWorkspace inserts a new workspace into the datastore, with the user as its owner.
+func (r *MemoryWorkspaceRepository) CreateWorkspace(workspace *Workspace, ownerID uint) (*Workspace, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.users[ownerID]; !ok {
+		return nil, ErrUnknownUser
+	}
+
+	newWorkspace := *workspace
+	newWorkspace.ID = r.mdb.nextID("workspaces")
+	newWorkspace.CreatedAt = memoryNow()
+	r.mdb.workspaces[newWorkspace.ID] = newWorkspace
+	r.mdb.workspaceMembers[newWorkspace.ID] = map[uint]WorkspaceMember{
+		ownerID: {WorkspaceID: newWorkspace.ID, UserID: ownerID, Role: WorkspaceRoleOwner, CreatedAt: newWorkspace.CreatedAt},
+	}
+
+	return &newWorkspace, nil
+}
+
+// GetWorkspaceByID retrieves a workspace by its ID from the datastore.
+func (r *MemoryWorkspaceRepository) GetWorkspaceByID(workspaceID uint) (*Workspace, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	workspace, ok := r.mdb.workspaces[workspaceID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &workspace, nil
+}
+
+// UpdateWorkspace updates a workspace in the datastore.
+func (r *MemoryWorkspaceRepository) UpdateWorkspace(workspace *Workspace) (*Workspace, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	updatedWorkspace, ok := r.mdb.workspaces[workspace.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	updatedWorkspace.Name = workspace.Name
+	r.mdb.workspaces[workspace.ID] = updatedWorkspace
+
+	return &updatedWorkspace, nil
+}
+
+// DeleteWorkspace deletes a workspace from the datastore, along with its members, invites and tasks.
+func (r *MemoryWorkspaceRepository) DeleteWorkspace(workspaceID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.workspaces[workspaceID]; !ok {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	r.mdb.deleteWorkspace(workspaceID)
+
+	return nil
+}
+
+// ListWorkspaceMemberships retrieves the workspaces a user is a member of from the datastore, along with
+// their role in each.
+func (r *MemoryWorkspaceRepository) ListWorkspaceMemberships(userID uint) ([]WorkspaceMembership, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	memberships := []WorkspaceMembership{}
+	for workspaceID, members := range r.mdb.workspaceMembers {
+		if member, ok := members[userID]; ok {
+			memberships = append(memberships, WorkspaceMembership{Workspace: r.mdb.workspaces[workspaceID], Role: member.Role})
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		return memberships[i].ID < memberships[j].ID
+	})
+
+	return memberships, nil
+}
+
+// GetWorkspaceMember retrieves the membership of a user in a workspace from the datastore. It returns nil
+// if the user isn't a member.
+func (r *MemoryWorkspaceRepository) GetWorkspaceMember(workspaceID, userID uint) (*WorkspaceMember, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	member, ok := r.mdb.workspaceMembers[workspaceID][userID]
+	if !ok {
+		return nil, nil
+	}
+	member = r.mdb.workspaceMemberView(member)
+
+	return &member, nil
+}
+
+// ListWorkspaceMembers retrieves the members of a workspace from the datastore, in the order they joined.
+func (r *MemoryWorkspaceRepository) ListWorkspaceMembers(workspaceID uint) ([]WorkspaceMember, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	members := []WorkspaceMember{}
+	for _, member := range r.mdb.workspaceMembers[workspaceID] {
+		members = append(members, r.mdb.workspaceMemberView(member))
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+
+	return members, nil
+}
+
+// UpdateWorkspaceMemberRole changes the role of a member of a workspace in the datastore.
+func (r *MemoryWorkspaceRepository) UpdateWorkspaceMemberRole(workspaceID, userID uint, role WorkspaceRole) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	member, ok := r.mdb.workspaceMembers[workspaceID][userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	member.Role = role
+	r.mdb.workspaceMembers[workspaceID][userID] = member
+
+	return nil
+}
+
+// TransferWorkspaceOwnership makes a member the owner of a workspace in the datastore, and its previous
+// owner an admin.
+func (r *MemoryWorkspaceRepository) TransferWorkspaceOwnership(workspaceID, fromUserID, toUserID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	members := r.mdb.workspaceMembers[workspaceID]
+	from, ok := members[fromUserID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	to, ok := members[toUserID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	to.Role = WorkspaceRoleOwner
+	members[toUserID] = to
+	from.Role = WorkspaceRoleAdmin
+	members[fromUserID] = from
+
+	return nil
+}
+
+// RemoveWorkspaceMember removes a member from a workspace in the datastore. The tasks of the workspace
+// assigned to them are unassigned, and the tasks they created in it are handed over to its owner.
+func (r *MemoryWorkspaceRepository) RemoveWorkspaceMember(workspaceID, userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.workspaceMembers[workspaceID][userID]; !ok {
+		return sql.ErrNoRows
+	}
+	r.mdb.removeWorkspaceMember(workspaceID, userID)
+
+	return nil
+}
+
+// CreateWorkspaceInvite inserts a new invite into the datastore, replacing any previous invite of the same
+// email to the workspace. The expired invites are deleted along the way.
+func (r *MemoryWorkspaceRepository) CreateWorkspaceInvite(invite *WorkspaceInvite) (*WorkspaceInvite, error) {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.workspaces[invite.WorkspaceID]; !ok {
+		return nil, ErrUnknownRecord
+	}
+
+	now := time.Now()
+	for id, i := range r.mdb.workspaceInvites {
+		if i.ExpiresAt.Before(now) || (i.WorkspaceID == invite.WorkspaceID && i.Email == invite.Email) {
+			delete(r.mdb.workspaceInvites, id)
+		}
+	}
+
+	newInvite := *invite
+	newInvite.ID = r.mdb.nextID("workspace_invites")
+	newInvite.ExpiresAt = *memoryTime(&invite.ExpiresAt)
+	newInvite.CreatedAt = memoryNow()
+	r.mdb.workspaceInvites[newInvite.ID] = newInvite
+
+	return &newInvite, nil
+}
+
+// GetWorkspaceInviteByID retrieves an invite by its ID from the datastore.
+func (r *MemoryWorkspaceRepository) GetWorkspaceInviteByID(inviteID uint) (*WorkspaceInvite, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	invite, ok := r.mdb.workspaceInvites[inviteID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &invite, nil
+}
+
+// GetWorkspaceInviteByHash retrieves an invite by the hash of its token from the datastore.
+func (r *MemoryWorkspaceRepository) GetWorkspaceInviteByHash(tokenHash string) (*WorkspaceInvite, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	for _, invite := range r.mdb.workspaceInvites {
+		if invite.TokenHash == tokenHash {
+			return &invite, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ListWorkspaceInvites retrieves the pending invites of a workspace from the datastore, leaving out the
+// expired ones.
+func (r *MemoryWorkspaceRepository) ListWorkspaceInvites(workspaceID uint) ([]WorkspaceInvite, error) {
+	r.mdb.mu.RLock()
+	defer r.mdb.mu.RUnlock()
+
+	now := time.Now()
+	invites := []WorkspaceInvite{}
+	for _, invite := range r.mdb.workspaceInvites {
+		if invite.WorkspaceID == workspaceID && !invite.ExpiresAt.Before(now) {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].ID < invites[j].ID
+	})
+
+	return invites, nil
+}
+
+// DeleteWorkspaceInvite deletes an invite from the datastore.
+func (r *MemoryWorkspaceRepository) DeleteWorkspaceInvite(inviteID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	if _, ok := r.mdb.workspaceInvites[inviteID]; !ok {
+		return sql.ErrNoRows // No rows were deleted
+	}
+	delete(r.mdb.workspaceInvites, inviteID)
+
+	return nil
+}
+
+// AcceptWorkspaceInvite deletes an invite from the datastore and adds the user to its workspace with the
+// role of the invite. It returns sql.ErrNoRows if the invite was already used, and ErrAlreadyMember if the
+// user is already a member of the workspace.
+func (r *MemoryWorkspaceRepository) AcceptWorkspaceInvite(inviteID, userID uint) error {
+	r.mdb.mu.Lock()
+	defer r.mdb.mu.Unlock()
+
+	invite, ok := r.mdb.workspaceInvites[inviteID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if _, ok := r.mdb.users[userID]; !ok {
+		return ErrUnknownUser
+	}
+	members := r.mdb.workspaceMembers[invite.WorkspaceID]
+	if _, ok := members[userID]; ok {
+		return ErrAlreadyMember
+	}
+
+	delete(r.mdb.workspaceInvites, inviteID)
+	members[userID] = WorkspaceMember{WorkspaceID: invite.WorkspaceID, UserID: userID, Role: invite.Role, CreatedAt: memoryNow()}
+
+	return nil
+}
+
+// workspaceMemberView returns a member of a workspace along with the email and display name of the user,
+// like the join of the SQL queries. The caller must hold the lock.
+func (m *MemoryDB) workspaceMemberView(member WorkspaceMember) WorkspaceMember {
+	user := m.users[member.UserID]
+	member.Email = user.Email
+	member.DisplayName = user.DisplayName
+
+	return member
+}
+
+// deleteWorkspace deletes a workspace along with its members, invites and tasks, like the foreign key
+// cascades of the SQL schema. The caller must hold the write lock.
+func (m *MemoryDB) deleteWorkspace(workspaceID uint) {
+	delete(m.workspaces, workspaceID)
+	delete(m.workspaceMembers, workspaceID)
+	for id, invite := range m.workspaceInvites {
+		if invite.WorkspaceID == workspaceID {
+			delete(m.workspaceInvites, id)
+		}
+	}
+	for taskID, task := range m.tasks {
+		if task.WorkspaceID != nil && *task.WorkspaceID == workspaceID {
+			m.deleteTask(taskID)
+		}
+	}
+}
+
+// isWorkspaceMember checks if a user is a member of a workspace, which is false when workspaceID is nil.
+// The caller must hold the lock.
+func (m *MemoryDB) isWorkspaceMember(workspaceID *uint, userID uint) bool {
+	if workspaceID == nil {
+		return false
+	}
+
+	_, ok := m.workspaceMembers[*workspaceID][userID]
+	return ok
+}
+
+// removeWorkspaceMember removes a member from a workspace, unassigns the tasks of the workspace assigned
+// to them and hands the tasks they created in it over to its owner. The caller must hold the write lock.
+func (m *MemoryDB) removeWorkspaceMember(workspaceID, userID uint) {
+	members := m.workspaceMembers[workspaceID]
+	delete(members, userID)
+
+	for taskID, task := range m.tasks {
+		if task.WorkspaceID != nil && *task.WorkspaceID == workspaceID && task.AssigneeID != nil && *task.AssigneeID == userID {
+			task.AssigneeID = nil
+			m.tasks[taskID] = task
+		}
+	}
+
+	for _, member := range members {
+		if member.Role != WorkspaceRoleOwner {
+			continue
+		}
+		for taskID, task := range m.tasks {
+			if task.WorkspaceID != nil && *task.WorkspaceID == workspaceID && task.UserID == userID {
+				task.UserID = member.UserID
+				m.tasks[taskID] = task
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkspaceMembers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStores) {
+		alice := newTestUser(t, s.users, "alice@example.com")
+		bob := newTestUser(t, s.users, "bob@example.com")
+		workspace, err := s.workspaces.CreateWorkspace(&Workspace{Name: "Team"}, alice)
+		if err != nil {
+			t.Fatalf("CreateWorkspace: %v", err)
+		}
+
+		invite, err := s.workspaces.CreateWorkspaceInvite(&WorkspaceInvite{
+			WorkspaceID: workspace.ID,
+			Email:       "bob@example.com",
+			Role:        WorkspaceRoleMember,
+			TokenHash:   "hash",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateWorkspaceInvite: %v", err)
+		}
+		if err := s.workspaces.AcceptWorkspaceInvite(invite.ID, bob); err != nil {
+			t.Fatalf("AcceptWorkspaceInvite: %v", err)
+		}
+
+		members, err := s.workspaces.ListWorkspaceMembers(workspace.ID)
+		if err != nil || len(members) != 2 || members[0].UserID != alice || members[1].UserID != bob || members[1].Role != WorkspaceRoleMember {
+			t.Fatalf("ListWorkspaceMembers: got %+v, %v, want alice then bob", members, err)
+		}
+		memberships, err := s.workspaces.ListWorkspaceMemberships(bob)
+		if err != nil || len(memberships) != 1 || memberships[0].ID != workspace.ID {
+			t.Errorf("ListWorkspaceMemberships: got %+v, %v", memberships, err)
+		}
+
+		if err := s.workspaces.UpdateWorkspaceMemberRole(workspace.ID, bob, WorkspaceRoleAdmin); err != nil {
+			t.Fatalf("UpdateWorkspaceMemberRole: %v", err)
+		}
+		if err := s.workspaces.TransferWorkspaceOwnership(workspace.ID, alice, bob); err != nil {
+			t.Fatalf("TransferWorkspaceOwnership: %v", err)
+		}
+		if member, err := s.workspaces.GetWorkspaceMember(workspace.ID, bob); err != nil || member == nil || member.Role != WorkspaceRoleOwner {
+			t.Errorf("GetWorkspaceMember(bob): got %+v, %v, want the owner", member, err)
+		}
+
+		// The tasks of a removed member are handed over to the owner.
+		task := newTestTask(t, s.tasks, Task{Title: "Write the report", UserID: alice, WorkspaceID: &workspace.ID})
+		if err := s.workspaces.RemoveWorkspaceMember(workspace.ID, alice); err != nil {
+			t.Fatalf("RemoveWorkspaceMember: %v", err)
+		}
+		if member, err := s.workspaces.GetWorkspaceMember(workspace.ID, alice); err != nil || member != nil {
+			t.Errorf("GetWorkspaceMember(alice): got %+v, %v, want nil", member, err)
+		}
+		if got, err := s.tasks.GetTaskByID(task.ID); err != nil || got == nil || got.UserID != bob {
+			t.Errorf("GetTaskByID: got %+v, %v, want a task of bob", got, err)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The algorithms passwords can be hashed with.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// The lengths in bytes of the argon2id salts and keys.
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrUnsupportedHash is returned when verifying a password against a hash of an unknown format.
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Argon2Params holds the cost parameters of argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// Hasher hashes passwords with the configured algorithm, and verifies them against the hashes of any
+// supported algorithm, so that the algorithm and its parameters can change over time.
+type Hasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+	// slots bounds the number of passwords hashed or verified at once, as each argon2id hash takes
+	// Argon2.Memory.
+	slots chan struct{}
+}
+
+// NewHasher creates a new Hasher hashing or verifying at most maxConcurrent passwords at once, the
+// other ones waiting for their turn. It fails if the algorithm or its parameters are unsupported.
+func NewHasher(algorithm string, bcryptCost int, argon2Params Argon2Params, maxConcurrent int) (*Hasher, error) {
+	if maxConcurrent < 1 {
+		return nil, errors.New("the number of passwords hashed at once must be at least 1")
+	}
+
+	switch algorithm {
+	case AlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if argon2Params.Memory < 8*uint32(argon2Params.Parallelism) || argon2Params.Iterations < 1 || argon2Params.Parallelism < 1 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", algorithm)
+	}
+
+	return &Hasher{Algorithm: algorithm, BcryptCost: bcryptCost, Argon2: argon2Params, slots: make(chan struct{}, maxConcurrent)}, nil
+}
+
+// acquire waits for a slot to hash or verify a password, and returns the function releasing it.
+func (h *Hasher) acquire() func() {
+	h.slots <- struct{}{}
+	return func() { <-h.slots }
+}
+
+// Hash hashes a password with the configured algorithm. Argon2id hashes are encoded in the PHC string
+// format, e.g. "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>".
+func (h *Hasher) Hash(password string) (string, error) {
+	defer h.acquire()()
+
+	if h.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(hash), err
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Argon2.Iterations, h.Argon2.Memory, h.Argon2.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Argon2.Memory, h.Argon2.Iterations,
+		h.Argon2.Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks if a password matches a hash of any supported algorithm.
+func (h *Hasher) Verify(hash, password string) (bool, error) {
+	defer h.acquire()()
+
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash checks if a hash was made with another algorithm or other parameters than the configured
+// ones, in which case the password should be hashed again once verified.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if isBcryptHash(hash) {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return h.Algorithm != AlgorithmBcrypt || err != nil || cost != h.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2Hash(hash)
+	return h.Algorithm != AlgorithmArgon2id || err != nil || params != h.Argon2
+}
+
+// isBcryptHash checks if a hash was made with bcrypt, whose hashes start with "$2a$", "$2b$" or "$2y$".
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2")
+}
+
+// decodeArgon2Hash decodes the parameters, salt and key of an argon2id hash in the PHC string format.
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	var version int
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	return params, salt, key, nil
+}
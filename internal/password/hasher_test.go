@@ -0,0 +1,181 @@
+package password
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params are cheap argon2id parameters that keep the tests fast.
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// newTestHasher creates a hasher, failing the test if its parameters are invalid.
+func newTestHasher(t *testing.T, algorithm string, bcryptCost int, argon2Params Argon2Params) *Hasher {
+	t.Helper()
+
+	h, err := NewHasher(algorithm, bcryptCost, argon2Params, 1)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+
+	return h
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name          string
+		algorithm     string
+		cost          int
+		argon2        Argon2Params
+		maxConcurrent int
+		wantErr       bool
+	}{
+		{name: "bcrypt", algorithm: AlgorithmBcrypt, cost: bcrypt.MinCost, maxConcurrent: 1},
+		{name: "bcrypt cost too low", algorithm: AlgorithmBcrypt, cost: bcrypt.MinCost - 1, maxConcurrent: 1, wantErr: true},
+		{name: "bcrypt cost too high", algorithm: AlgorithmBcrypt, cost: bcrypt.MaxCost + 1, maxConcurrent: 1, wantErr: true},
+		{name: "argon2id", algorithm: AlgorithmArgon2id, argon2: testArgon2Params, maxConcurrent: 1},
+		{name: "argon2id without iterations", algorithm: AlgorithmArgon2id, argon2: Argon2Params{Memory: 64, Parallelism: 1}, maxConcurrent: 1, wantErr: true},
+		{name: "argon2id memory too low", algorithm: AlgorithmArgon2id, argon2: Argon2Params{Memory: 15, Iterations: 1, Parallelism: 2}, maxConcurrent: 1, wantErr: true},
+		{name: "unknown", algorithm: "md5", maxConcurrent: 1, wantErr: true},
+		{name: "no concurrency", algorithm: AlgorithmArgon2id, argon2: testArgon2Params, maxConcurrent: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHasher(tt.algorithm, tt.cost, tt.argon2, tt.maxConcurrent); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want an error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasherHashVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		prefix string
+	}{
+		{name: "bcrypt", hasher: newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, Argon2Params{}), prefix: "$2a$04$"},
+		{name: "argon2id", hasher: newTestHasher(t, AlgorithmArgon2id, 0, testArgon2Params), prefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("tr0ub4dor&3")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("got hash %q, want it to start with %q", hash, tt.prefix)
+			}
+			if other, _ := tt.hasher.Hash("tr0ub4dor&3"); other == hash {
+				t.Errorf("got the same hash twice, want a random salt")
+			}
+
+			if ok, err := tt.hasher.Verify(hash, "tr0ub4dor&3"); !ok || err != nil {
+				t.Errorf("verifying the password: got %v, %v, want true", ok, err)
+			}
+			if ok, err := tt.hasher.Verify(hash, "Tr0ub4dor&3"); ok || err != nil {
+				t.Errorf("verifying another password: got %v, %v, want false", ok, err)
+			}
+		})
+	}
+}
+
+func TestHasherVerifyOtherAlgorithm(t *testing.T) {
+	bcryptHasher := newTestHasher(t, AlgorithmBcrypt, bcrypt.MinCost, Argon2Params{})
+	argon2Hasher := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2Params)
+
+	// Hashes of the previous algorithm keep verifying once the algorithm changes.
+	bcryptHash, err := bcryptHasher.Hash("tr0ub4dor&3")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	argon2Hash, err := argon2Hasher.Hash("tr0ub4dor&3")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if ok, err := argon2Hasher.Verify(bcryptHash, "tr0ub4dor&3"); !ok || err != nil {
+		t.Errorf("verifying a bcrypt hash with argon2id configured: got %v, %v, want true", ok, err)
+	}
+	if ok, err := bcryptHasher.Verify(argon2Hash, "tr0ub4dor&3"); !ok || err != nil {
+		t.Errorf("verifying an argon2id hash with bcrypt configured: got %v, %v, want true", ok, err)
+	}
+
+	for _, hash := range []string{"", "plaintext", "$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$"} {
+		if ok, err := argon2Hasher.Verify(hash, "tr0ub4dor&3"); ok || err != ErrUnsupportedHash {
+			t.Errorf("verifying %q: got %v, %v, want false, %v", hash, ok, err, ErrUnsupportedHash)
+		}
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	hash := func(h *Hasher) string {
+		t.Helper()
+		hash, err := h.Hash("tr0ub4dor&3")
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		return hash
+	}
+	bcrypt4 := newTestHasher(t, AlgorithmBcrypt, 4, Argon2Params{})
+	bcrypt5 := newTestHasher(t, AlgorithmBcrypt, 5, Argon2Params{})
+	argon2 := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2Params)
+	argon2Stronger := newTestHasher(t, AlgorithmArgon2id, 0, Argon2Params{Memory: 128, Iterations: 2, Parallelism: 1})
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   string
+		want   bool
+	}{
+		{name: "same bcrypt cost", hasher: bcrypt4, hash: hash(bcrypt4), want: false},
+		{name: "other bcrypt cost", hasher: bcrypt5, hash: hash(bcrypt4), want: true},
+		{name: "bcrypt to argon2id", hasher: argon2, hash: hash(bcrypt4), want: true},
+		{name: "same argon2id parameters", hasher: argon2, hash: hash(argon2), want: false},
+		{name: "other argon2id parameters", hasher: argon2Stronger, hash: hash(argon2), want: true},
+		{name: "argon2id to bcrypt", hasher: bcrypt4, hash: hash(argon2), want: true},
+		{name: "unsupported", hasher: argon2, hash: "plaintext", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasherConcurrency(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2Params)
+	hash, err := h.Hash("tr0ub4dor&3")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	// Hashing and verifying wait while the only slot is taken.
+	release := h.acquire()
+	done := make(chan struct{}, 2)
+	go func() {
+		h.Hash("tr0ub4dor&3")
+		done <- struct{}{}
+	}()
+	go func() {
+		h.Verify(hash, "tr0ub4dor&3")
+		done <- struct{}{}
+	}()
+	select {
+	case <-done:
+		t.Fatal("a password was hashed while no slot was free")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the passwords weren't hashed once the slot was released")
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxInputLength is the length in bytes beyond which passwords are refused without being hashed, whatever
+// the policy, so that logging in with huge passwords can't be used to exhaust the server.
+const MaxInputLength = 1024
+
+// BcryptMaxLength is the length in bytes beyond which bcrypt refuses to hash passwords.
+const BcryptMaxLength = 72
+
+// commonPasswords is the bundled list of common passwords, one per line.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// denylist holds the common passwords, in lowercase.
+var denylist = func() map[string]struct{} {
+	list := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswords))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			list[strings.ToLower(line)] = struct{}{}
+		}
+	}
+
+	return list
+}()
+
+// Policy holds the rules passwords must follow. Lengths are counted in characters, except MaxBytes.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MaxBytes caps the length in bytes when the hashing algorithm does, e.g. BcryptMaxLength. It
+	// defaults to MaxInputLength.
+	MaxBytes         int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// Denylist refuses the passwords of the bundled list of common passwords, whatever their case.
+	Denylist bool
+}
+
+// Validate checks that a password follows the policy. The error names the first rule it breaks, and is
+// meant to be shown to the user.
+func (p Policy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("Invalid password. It must be at least %d characters long", p.MinLength)
+	}
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 || maxBytes > MaxInputLength {
+		maxBytes = MaxInputLength
+	}
+	if length > p.MaxLength {
+		return fmt.Errorf("Invalid password. It must be at most %d characters long", p.MaxLength)
+	}
+	if len(password) > maxBytes {
+		return fmt.Errorf("Invalid password. It must be at most %d bytes long, accented letters and symbols taking up to 4 bytes", maxBytes)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		return errors.New("Invalid password. It must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !lower {
+		return errors.New("Invalid password. It must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		return errors.New("Invalid password. It must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		return errors.New("Invalid password. It must contain a symbol")
+	}
+
+	if p.Denylist {
+		if _, ok := denylist[strings.ToLower(password)]; ok {
+			return errors.New("Invalid password. It is too common, please choose another one")
+		}
+	}
+
+	return nil
+}
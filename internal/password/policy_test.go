@@ -0,0 +1,45 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := Policy{MinLength: 8, MaxLength: 64, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSymbol: true, Denylist: true}
+	bcrypt := Policy{MinLength: 8, MaxLength: 128, MaxBytes: BcryptMaxLength}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		wantErr  string
+	}{
+		{name: "valid", policy: strict, password: "Tr0ub4dor&3"},
+		{name: "too short", policy: strict, password: "Tr0u&3", wantErr: "at least 8 characters"},
+		{name: "too long", policy: strict, password: "Tr0ub4dor&3" + strings.Repeat("a", 60), wantErr: "at most 64 characters"},
+		{name: "length in characters", policy: Policy{MinLength: 8, MaxLength: 8}, password: "éééééééé"},
+		{name: "too many bytes", policy: bcrypt, password: strings.Repeat("é", 40), wantErr: "at most 72 bytes"},
+		{name: "bytes capped by default", policy: Policy{MaxLength: 2000}, password: strings.Repeat("a", MaxInputLength+1), wantErr: "at most 1024 bytes"},
+		{name: "no uppercase", policy: strict, password: "tr0ub4dor&3", wantErr: "uppercase letter"},
+		{name: "no lowercase", policy: strict, password: "TR0UB4DOR&3", wantErr: "lowercase letter"},
+		{name: "no digit", policy: strict, password: "Troubador&x", wantErr: "digit"},
+		{name: "no symbol", policy: strict, password: "Tr0ub4dor33", wantErr: "symbol"},
+		{name: "common", policy: Policy{MinLength: 8, MaxLength: 64, Denylist: true}, password: "PassWord", wantErr: "too common"},
+		{name: "common allowed without denylist", policy: Policy{MinLength: 8, MaxLength: 64}, password: "password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
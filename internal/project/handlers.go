@@ -0,0 +1,193 @@
+package project
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// projectData holds the project details.
+type projectData struct {
+	Name string `json:"name"`
+}
+
+// invalidNameMessage is the error returned when a project name is blank or too long.
+const invalidNameMessage = "Invalid name. It must not be empty nor longer than 100 characters"
+
+// Deletion modes deciding what happens to the tasks of a deleted project.
+const (
+	deleteModeInbox   = "inbox"
+	deleteModeCascade = "cascade"
+)
+
+// GetProjectsHandler handles retrieval of the projects of the authenticated user.
+func GetProjectsHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	projects, err := app.ProjectRepository.ListProjectsByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve projects: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve projects"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, projects)
+}
+
+// CreateProjectHandler handles the creation of a new project.
+func CreateProjectHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	var pd projectData
+	if err := ctx.ShouldBindJSON(&pd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	pd.Name = strings.TrimSpace(pd.Name)
+	if !validator.IsValidProjectName(pd.Name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+		return
+	}
+
+	// Store project details in the database.
+	project := &models.Project{
+		Name:   pd.Name,
+		UserID: userID,
+	}
+	newProject, err := app.ProjectRepository.CreateProject(project)
+	if err != nil {
+		log.Printf("Warning: Failed to create project: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully",
+		"project": newProject,
+	})
+}
+
+// GetProjectByIDHandler handles the retrieval of a project by ID only if it belongs to the authenticated user.
+func GetProjectByIDHandler(ctx *gin.Context, app *config.Application) {
+	project := getOwnedProject(ctx, app)
+	if project == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+// UpdateProjectByIDHandler handles the renaming of a project by ID only if it belongs to the authenticated user.
+func UpdateProjectByIDHandler(ctx *gin.Context, app *config.Application) {
+	project := getOwnedProject(ctx, app)
+	if project == nil {
+		return
+	}
+
+	// Parse request body to get updated details
+	var pd projectData
+	if err := ctx.ShouldBindJSON(&pd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Update only provided fields
+	if !validator.IsBlank(pd.Name) {
+		pd.Name = strings.TrimSpace(pd.Name)
+		if !validator.IsValidProjectName(pd.Name) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+			return
+		}
+
+		project.Name = pd.Name
+	}
+
+	// Update the project in the database
+	updatedProject, err := app.ProjectRepository.UpdateProject(project)
+	if err != nil {
+		log.Printf("Warning: Failed to update project: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": updatedProject,
+	})
+}
+
+// DeleteProjectByIDHandler handles the deletion of a project by ID only if it belongs to the authenticated user.
+// Depending on the "mode" query parameter, its tasks are either moved to the Inbox (the default) or deleted.
+// The Inbox itself can't be deleted.
+func DeleteProjectByIDHandler(ctx *gin.Context, app *config.Application) {
+	mode := ctx.DefaultQuery("mode", deleteModeInbox)
+	if mode != deleteModeInbox && mode != deleteModeCascade {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": `Invalid mode. It can be either "inbox" or "cascade"`})
+		return
+	}
+
+	project := getOwnedProject(ctx, app)
+	if project == nil {
+		return
+	}
+	if project.IsInbox {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The Inbox project can't be deleted"})
+		return
+	}
+
+	var moveTasksTo *uint
+	if mode == deleteModeInbox {
+		inbox, err := models.InboxProject(app.ProjectRepository, project.UserID)
+		if err != nil {
+			log.Printf("Warning: Failed to get the Inbox project: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+			return
+		}
+		moveTasksTo = &inbox.ID
+	}
+
+	// Delete the project from the database
+	err := app.ProjectRepository.DeleteProject(project.ID, project.UserID, moveTasksTo)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to delete project from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// getOwnedProject retrieves the project identified in the URL. If it doesn't belong to the authenticated
+// user, or can't be retrieved, it writes the error response and returns nil.
+func getOwnedProject(ctx *gin.Context, app *config.Application) *models.Project {
+	userID := ctx.MustGet("userID").(uint)
+	projectID := ctx.MustGet("projectID").(uint)
+
+	// Retrieve the project from the database
+	project, err := app.ProjectRepository.GetProjectByID(projectID)
+	if err != nil {
+		log.Printf("Warning: Failed to get project details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+		return nil
+	}
+
+	// Check if the project is associated with the authenticated user
+	if project == nil || project.UserID != userID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return nil
+	}
+
+	return project
+}
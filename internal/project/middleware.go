@@ -0,0 +1,22 @@
+package project
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractProjectIDMiddleware extract the project ID from URL parameters.
+func ExtractProjectIDMiddleware(ctx *gin.Context) {
+	projectIDStr := ctx.Param("projectID")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	// Store the project ID in the context
+	ctx.Set("projectID", uint(projectID))
+	ctx.Next()
+}
@@ -0,0 +1,239 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// maxEmptyPeriods bounds the number of consecutive periods without any occurrence before the
+// iteration gives up, so that rules that can never match, e.g. BYMONTH=2;BYMONTHDAY=30, terminate.
+const maxEmptyPeriods = 1000
+
+// date represents a calendar day.
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// Each calls fn with every occurrence of the rule in order, along with its 1-based index. The first
+// occurrence is always start, which anchors the series and sets the time of day and location of the
+// occurrences. The iteration stops once fn returns false or the series ends.
+func (r *Rule) Each(start time.Time, fn func(t time.Time, n int) bool) {
+	n := 0
+	emit := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		n++
+		if !fn(t, n) {
+			return false
+		}
+		return r.Count == 0 || n < r.Count
+	}
+
+	if !emit(start) {
+		return
+	}
+
+	hour, min, sec := start.Clock()
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period += r.Interval {
+		found := false
+		for _, d := range r.expand(start, period) {
+			t := localTime(d, hour, min, sec, start.Nanosecond(), start.Location())
+			if !t.After(start) {
+				continue
+			}
+			if t.Year() > 9999 {
+				return
+			}
+
+			found = true
+			if !emit(t) {
+				return
+			}
+		}
+
+		if found {
+			empty = 0
+		} else {
+			empty++
+		}
+	}
+}
+
+// localTime returns the time of day on a day in the location. A time skipped by a daylight saving time
+// change, e.g. 02:30 when clocks jump from 02:00 to 03:00, is shifted forward by the length of the gap,
+// as RFC 5545 requires, i.e. it's interpreted with the offset in effect before the change.
+func localTime(d date, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(d.year, d.month, d.day, hour, min, sec, nsec, loc)
+	if h, m, s := t.Clock(); h == hour && m == min && s == sec {
+		return t
+	}
+
+	_, offset := t.Add(-12 * time.Hour).Zone()
+	wall := time.Date(d.year, d.month, d.day, hour, min, sec, nsec, time.UTC)
+	return wall.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// Next returns the first occurrence of the series anchored at start that comes strictly after the
+// given time, along with its 1-based index. It reports false when the series ends before then.
+func (r *Rule) Next(start, after time.Time) (time.Time, int, bool) {
+	var next time.Time
+	var index int
+	r.Each(start, func(t time.Time, n int) bool {
+		if t.After(after) {
+			next, index = t, n
+			return false
+		}
+		return true
+	})
+
+	return next, index, index > 0
+}
+
+// Occurrences returns up to limit occurrences of the series anchored at start that come strictly
+// after the given time.
+func (r *Rule) Occurrences(start, after time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+	if limit <= 0 {
+		return occurrences
+	}
+
+	r.Each(start, func(t time.Time, n int) bool {
+		if t.After(after) {
+			occurrences = append(occurrences, t)
+		}
+		return len(occurrences) < limit
+	})
+
+	return occurrences
+}
+
+// expand returns the candidate days of a period, in order. The period is the number of days, weeks,
+// months or years, depending on the frequency, since the one of start.
+func (r *Rule) expand(start time.Time, period int) []date {
+	y, m, d := start.Date()
+
+	switch r.Freq {
+	case Daily:
+		day := time.Date(y, m, d+period, 0, 0, 0, 0, time.UTC)
+		return r.filter(day, day, start, false)
+
+	case Weekly:
+		// Weeks start on Monday.
+		offset := (int(start.Weekday()) + 6) % 7
+		monday := time.Date(y, m, d-offset+7*period, 0, 0, 0, 0, time.UTC)
+		days := []date{}
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			days = append(days, r.filter(day, day, start, false)...)
+		}
+		return days
+
+	case Monthly:
+		first := time.Date(y, m+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+		return r.filter(first, first.AddDate(0, 1, -1), start, false)
+
+	default: // Yearly
+		if len(r.ByMonth) == 0 {
+			first := time.Date(y+period, time.January, 1, 0, 0, 0, 0, time.UTC)
+			return r.filter(first, first.AddDate(1, 0, -1), start, true)
+		}
+
+		// BYDAY ordinals are relative to each of the months.
+		days := []date{}
+		months := append([]time.Month{}, r.ByMonth...)
+		sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+		for _, month := range months {
+			first := time.Date(y+period, month, 1, 0, 0, 0, 0, time.UTC)
+			days = append(days, r.filter(first, first.AddDate(0, 1, -1), start, false)...)
+		}
+		return days
+	}
+}
+
+// filter returns the days between first and last, inclusive, that match the BY* parts of the rule.
+// BYDAY ordinals are relative to the span. For the monthly and yearly frequencies without BYDAY nor
+// BYMONTHDAY, only the day of the month of start matches, as well as its month when the span is a
+// whole year.
+func (r *Rule) filter(first, last, start time.Time, wholeYear bool) []date {
+	sameDay := (r.Freq == Monthly || r.Freq == Yearly) && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0
+
+	var days []date
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if sameDay && (day.Day() != start.Day() || (wholeYear && day.Month() != start.Month())) {
+			continue
+		}
+		if r.matches(day, first, last) {
+			days = append(days, date{day.Year(), day.Month(), day.Day()})
+		}
+	}
+
+	return days
+}
+
+// matches checks if a day within the span from first to last matches the BY* parts of the rule.
+func (r *Rule) matches(day, first, last time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !r.matchesWeekday(day, first, last) {
+		return false
+	}
+
+	return true
+}
+
+// matchesMonthDay checks if the day is one of the BYMONTHDAY values, which count from the end of the
+// month when negative.
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && daysInMonth+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWeekday checks if the day is one of the BYDAY values, whose ordinals are relative to the
+// span from first to last.
+func (r *Rule) matchesWeekday(day, first, last time.Time) bool {
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday != day.Weekday() {
+			continue
+		}
+		if weekday.Ordinal == 0 {
+			return true
+		}
+
+		// The position of the day among the same weekdays of the span, from the start and the end.
+		fromStart := int(day.Sub(first).Hours()/24)/7 + 1
+		fromEnd := -(int(last.Sub(day).Hours()/24)/7 + 1)
+		if weekday.Ordinal == fromStart || weekday.Ordinal == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsMonth checks if the month is one of the months.
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+
+	return false
+}
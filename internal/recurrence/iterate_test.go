@@ -0,0 +1,206 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+// mustParse parses a rule, failing the test if it's invalid.
+func mustParse(t *testing.T, s string) *Rule {
+	t.Helper()
+
+	rule, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+
+	return rule
+}
+
+// mustLoadLocation loads a location, failing the test if it's unknown.
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+
+	return loc
+}
+
+// equalTimes checks if two lists of times hold the same instants in the same order.
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestOccurrences(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "daily",
+			rule:  "FREQ=DAILY",
+			start: day(2026, 1, 30),
+			want:  []time.Time{day(2026, 1, 31), day(2026, 2, 1), day(2026, 2, 2)},
+		},
+		{
+			name:  "every other week on two days",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			start: day(2026, 1, 5),
+			want:  []time.Time{day(2026, 1, 7), day(2026, 1, 19), day(2026, 1, 21)},
+		},
+		{
+			name:  "last Friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: day(2026, 1, 30),
+			want:  []time.Time{day(2026, 2, 27), day(2026, 3, 27), day(2026, 4, 24)},
+		},
+		{
+			name:  "second Tuesday of March and September",
+			rule:  "FREQ=YEARLY;BYMONTH=3,9;BYDAY=2TU",
+			start: day(2026, 3, 10),
+			want:  []time.Time{day(2026, 9, 8), day(2027, 3, 9), day(2027, 9, 14)},
+		},
+		{
+			name:  "months without day 31 are skipped",
+			rule:  "FREQ=MONTHLY",
+			start: day(2026, 1, 31),
+			want:  []time.Time{day(2026, 3, 31), day(2026, 5, 31), day(2026, 7, 31)},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: day(2026, 1, 31),
+			want:  []time.Time{day(2026, 2, 28), day(2026, 3, 31), day(2026, 4, 30)},
+		},
+		{
+			name:  "leap day",
+			rule:  "FREQ=YEARLY",
+			start: day(2024, 2, 29),
+			want:  []time.Time{day(2028, 2, 29), day(2032, 2, 29), day(2036, 2, 29)},
+		},
+		{
+			name:  "count includes the start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: day(2026, 1, 1),
+			want:  []time.Time{day(2026, 1, 2), day(2026, 1, 3)},
+		},
+		{
+			name:  "until date includes the whole day",
+			rule:  "FREQ=DAILY;UNTIL=20260103",
+			start: day(2026, 1, 1),
+			want:  []time.Time{day(2026, 1, 2), day(2026, 1, 3)},
+		},
+		{
+			name:  "until date-time",
+			rule:  "FREQ=DAILY;UNTIL=20260103T080000Z",
+			start: day(2026, 1, 1),
+			want:  []time.Time{day(2026, 1, 2)},
+		},
+		{
+			name:  "never matches",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: day(2026, 1, 1),
+			want:  []time.Time{},
+		},
+		{
+			name:  "weekday in the timezone of the start",
+			rule:  "FREQ=WEEKLY;BYDAY=MO",
+			start: time.Date(2026, 10, 19, 0, 30, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 10, 26, 0, 30, 0, 0, berlin),
+				time.Date(2026, 11, 2, 0, 30, 0, 0, berlin),
+				time.Date(2026, 11, 9, 0, 30, 0, 0, berlin),
+			},
+		},
+		{
+			name:  "time of day kept when clocks go back",
+			rule:  "FREQ=WEEKLY",
+			start: time.Date(2026, 10, 19, 9, 0, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 10, 26, 9, 0, 0, 0, berlin),
+				time.Date(2026, 11, 2, 9, 0, 0, 0, berlin),
+				time.Date(2026, 11, 9, 9, 0, 0, 0, berlin),
+			},
+		},
+		{
+			// 02:30 doesn't exist on 2026-03-08, when clocks jump from 02:00 to 03:00.
+			name:  "time skipped when clocks go forward",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, 3, 7, 2, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+				time.Date(2026, 3, 10, 2, 30, 0, 0, newYork),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParse(t, tt.rule).Occurrences(tt.start, tt.start, 3)
+			if !equalTimes(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rule      string
+		after     time.Time
+		want      time.Time
+		wantIndex int
+		wantOK    bool
+	}{
+		{name: "second", rule: "FREQ=DAILY", after: start, want: start.AddDate(0, 0, 1), wantIndex: 2, wantOK: true},
+		{name: "between occurrences", rule: "FREQ=WEEKLY", after: start.AddDate(0, 0, 10), want: start.AddDate(0, 0, 14), wantIndex: 3, wantOK: true},
+		{name: "before start", rule: "FREQ=DAILY", after: start.Add(-time.Hour), want: start, wantIndex: 1, wantOK: true},
+		{name: "last of count", rule: "FREQ=DAILY;COUNT=3", after: start.AddDate(0, 0, 1), want: start.AddDate(0, 0, 2), wantIndex: 3, wantOK: true},
+		{name: "after count", rule: "FREQ=DAILY;COUNT=3", after: start.AddDate(0, 0, 2), wantOK: false},
+		{name: "after until", rule: "FREQ=DAILY;UNTIL=20260103", after: start.AddDate(0, 0, 2), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, index, ok := mustParse(t, tt.rule).Next(start, tt.after)
+			if ok != tt.wantOK || index != tt.wantIndex || (ok && !got.Equal(tt.want)) {
+				t.Errorf("got %v, %d, %v, want %v, %d, %v", got, index, ok, tt.want, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEachStopsWhenFnReturnsFalse(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var got []int
+	mustParse(t, "FREQ=DAILY").Each(start, func(_ time.Time, n int) bool {
+		got = append(got, n)
+		return n < 3
+	})
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("got indexes %v, want 1, 2, 3", got)
+	}
+}
@@ -0,0 +1,260 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency represents the base interval a rule repeats at.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// weekdays maps the iCalendar weekday codes to their time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum represents a BYDAY value, e.g. "MO" for every Monday or "-1FR" for the last Friday.
+type WeekdayNum struct {
+	// Ordinal is the position of the weekday within the month or year, counting from the end when
+	// negative. Zero means every such weekday.
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// String returns the iCalendar form of the weekday.
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Weekday.String()[:2])
+	if w.Ordinal == 0 {
+		return code
+	}
+
+	return strconv.Itoa(w.Ordinal) + code
+}
+
+// Rule represents a recurrence rule, a subset of the iCalendar RRULE of RFC 5545. It supports the
+// FREQ, INTERVAL, BYDAY, BYMONTHDAY, BYMONTH, COUNT and UNTIL parts, with weeks starting on Monday.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// Count is the total number of occurrences, including the first one. Zero means no limit.
+	Count int
+	// Until is the time after which there are no more occurrences, if set.
+	Until *time.Time
+}
+
+// Parse parses a rule in the iCalendar RRULE syntax, e.g. "FREQ=WEEKLY;BYDAY=MO,WE". An "RRULE:"
+// prefix is allowed.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "RRULE:") {
+		s = s[len("RRULE:"):]
+	}
+	if s == "" {
+		return nil, errors.New("rule is empty")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, found := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is set more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly && rule.Freq != Yearly {
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(name, value, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(name, value, 1, 10000)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				var day int
+				if day, err = parseInt(name, v, -31, 31); err == nil && day == 0 {
+					err = errors.New("BYMONTHDAY must not be 0")
+				}
+				if err != nil {
+					break
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				var month int
+				if month, err = parseInt(name, v, 1, 12); err != nil {
+					break
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// validate checks the consistency of the parts of the rule.
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return errors.New("COUNT and UNTIL must not both be set")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return errors.New("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+
+	for _, day := range r.ByDay {
+		if day.Ordinal == 0 {
+			continue
+		}
+		switch {
+		case r.Freq != Monthly && r.Freq != Yearly:
+			return errors.New("BYDAY ordinals can only be used with FREQ=MONTHLY or FREQ=YEARLY")
+		case r.Freq == Monthly && (day.Ordinal > 5 || day.Ordinal < -5):
+			return fmt.Errorf("BYDAY ordinal %d is out of range", day.Ordinal)
+		}
+	}
+
+	return nil
+}
+
+// String returns the canonical RRULE form of the rule.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, month := range r.ByMonth {
+			months[i] = strconv.Itoa(int(month))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// untilLayout is the layout of the UNTIL date-time form.
+const untilLayout = "20060102T150405Z"
+
+// parseUntil parses an UNTIL value, either a UTC date-time or a date, which includes the whole day.
+func parseUntil(value string) (*time.Time, error) {
+	if t, err := time.Parse(untilLayout, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		endOfDay := t.Add(24*time.Hour - time.Nanosecond)
+		return &endOfDay, nil
+	}
+
+	return nil, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// parseByDay parses a comma-separated list of BYDAY values.
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", v)
+		}
+
+		weekday, ok := weekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", v)
+		}
+
+		day := WeekdayNum{Weekday: weekday}
+		if ordinal := v[:len(v)-2]; ordinal != "" {
+			n, err := parseInt("BYDAY", ordinal, -53, 53)
+			if err != nil || n == 0 {
+				return nil, fmt.Errorf("invalid BYDAY %q", v)
+			}
+			day.Ordinal = n
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// parseInt parses the integer value of a part and checks it's within bounds.
+func parseInt(name, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return n, nil
+}
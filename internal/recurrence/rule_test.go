@@ -0,0 +1,54 @@
+package recurrence
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{rule: "FREQ=WEEKLY;BYDAY=MO,WE", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{rule: "rrule:freq=monthly;byday=-1fr", want: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{rule: "FREQ=DAILY;INTERVAL=1", want: "FREQ=DAILY"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;WKST=MO", want: "FREQ=WEEKLY;INTERVAL=2"},
+		{rule: "BYMONTHDAY=29;BYMONTH=2;FREQ=YEARLY", want: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1", want: "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{rule: "FREQ=DAILY;COUNT=3", want: "FREQ=DAILY;COUNT=3"},
+		{rule: "FREQ=DAILY;UNTIL=20260104", want: "FREQ=DAILY;UNTIL=20260104T235959Z"},
+		{rule: "FREQ=DAILY;UNTIL=20260104T120000Z", want: "FREQ=DAILY;UNTIL=20260104T120000Z"},
+		{rule: "", wantErr: true},
+		{rule: "FREQ", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=HOURLY", wantErr: true},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=3;UNTIL=20260104", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+		{rule: "FREQ=DAILY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYDAY=6MO", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{rule: "FREQ=YEARLY;BYMONTH=13", wantErr: true},
+		{rule: "FREQ=WEEKLY;WKST=SU", wantErr: true},
+		{rule: "FREQ=DAILY;BYSETPOS=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// minRSAKeyBits is the minimum size of the RSA keys.
+const minRSAKeyBits = 2048
+
+// Key represents a key tokens are signed or verified with, identified by the kid header of the tokens.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private is nil when the key can only verify tokens, e.g. a retired key.
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the keys tokens are signed and verified with. Tokens are signed with the first key of
+// the set, and verified with the key matching their kid. A set without asymmetric keys signs and
+// verifies tokens with an HMAC secret instead.
+type KeySet struct {
+	keys   []Key
+	secret []byte
+}
+
+// NewHMACKeySet creates a key set signing and verifying tokens with HS256 and a shared secret.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{secret: []byte(secret)}
+}
+
+// LoadKeySet loads the keys of a set from PEM files. The spec is a comma-separated list of kid=path
+// entries, e.g. "2024-06=/etc/keys/current.pem,2024-01=/etc/keys/previous.pem". The first key, which
+// tokens are signed with, must be a private key. The other ones may be public keys.
+//
+// RSA keys sign with RS256, ECDSA keys with ES256, ES384 or ES512 depending on their curve, and
+// Ed25519 keys with EdDSA.
+func LoadKeySet(spec string) (*KeySet, error) {
+	set := &KeySet{}
+	for _, entry := range strings.Split(spec, ",") {
+		id, path, found := strings.Cut(strings.TrimSpace(entry), "=")
+		id, path = strings.TrimSpace(id), strings.TrimSpace(path)
+		if !found || id == "" || path == "" {
+			return nil, fmt.Errorf("invalid signing key %q, expected kid=path", entry)
+		}
+		if set.Key(id) != nil {
+			return nil, fmt.Errorf("duplicate signing key ID %q", id)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %q: %w", id, err)
+		}
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %q: %w", id, err)
+		}
+
+		set.keys = append(set.keys, *key)
+	}
+
+	if set.keys[0].Private == nil {
+		return nil, fmt.Errorf("signing key %q must be a private key", set.keys[0].ID)
+	}
+
+	return set, nil
+}
+
+// parseKey parses a PEM-encoded private or public key.
+func parseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public = k, &k.PublicKey
+	case *ecdsa.PrivateKey:
+		key.Private, key.Public = k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Private, key.Public = k, k.Public()
+	default:
+		key.Public = parsed
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Public)
+	}
+
+	return key, nil
+}
+
+// Key returns the key with the given ID, or nil if the set has none.
+func (s *KeySet) Key(id string) *Key {
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			return &s.keys[i]
+		}
+	}
+
+	return nil
+}
+
+// Sign signs the claims into a token with the first key of the set.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if len(s.keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+
+	key := s.keys[0]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// Verify parses a token and verifies its signature with the key matching its kid, and that the
+// algorithm of the token is the one of the key.
+func (s *KeySet) Verify(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if len(s.keys) == 0 {
+			// Validate the signing method
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return s.secret, nil
+		}
+
+		id, _ := t.Header["kid"].(string)
+		key := s.Key(id)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", id)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return key.Public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, jwt.ErrSignatureInvalid
+}
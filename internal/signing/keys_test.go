@@ -0,0 +1,282 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// writePEM writes a PEM block to a file of the test's temporary directory and returns its path.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+// writePrivateKey writes a private key in the PKCS #8 form and returns its path.
+func writePrivateKey(t *testing.T, name string, key crypto.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+
+	return writePEM(t, name, "PRIVATE KEY", der)
+}
+
+// writePublicKey writes a public key in the PKIX form and returns its path.
+func writePublicKey(t *testing.T, name string, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	return writePEM(t, name, "PUBLIC KEY", der)
+}
+
+// newECDSAKey generates an ECDSA key on the curve, failing the test on error.
+func newECDSAKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey: %v", err)
+	}
+
+	return key
+}
+
+// newRSAKey generates an RSA key of the size, failing the test on error.
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	return key
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	ecKey := newECDSAKey(t, elliptic.P256())
+	ecKeyDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantAlg string
+		wantErr string
+	}{
+		{name: "RSA PKCS #8", spec: "a=" + writePrivateKey(t, "rsa", rsaKey), wantAlg: "RS256"},
+		{name: "RSA PKCS #1", spec: "a=" + writePEM(t, "rsa", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), wantAlg: "RS256"},
+		{name: "ECDSA P-256", spec: "a=" + writePEM(t, "ec", "EC PRIVATE KEY", ecKeyDER), wantAlg: "ES256"},
+		{name: "ECDSA P-384", spec: "a=" + writePrivateKey(t, "ec", newECDSAKey(t, elliptic.P384())), wantAlg: "ES384"},
+		{name: "ECDSA P-521", spec: "a=" + writePrivateKey(t, "ec", newECDSAKey(t, elliptic.P521())), wantAlg: "ES512"},
+		{name: "Ed25519", spec: "a=" + writePrivateKey(t, "ed", edKey), wantAlg: "EdDSA"},
+		{name: "retired public key", spec: " a = " + writePrivateKey(t, "ed", edKey) + ", b=" + writePublicKey(t, "rsa", &rsaKey.PublicKey), wantAlg: "EdDSA"},
+		{name: "public signing key", spec: "a=" + writePublicKey(t, "rsa", &rsaKey.PublicKey), wantErr: "must be a private key"},
+		{name: "RSA key too small", spec: "a=" + writePrivateKey(t, "rsa", newRSAKey(t, 1024)), wantErr: "at least 2048 bits"},
+		{name: "invalid key", spec: "a=" + writePEM(t, "ec", "EC PRIVATE KEY", []byte("garbage")), wantErr: "failed to parse"},
+		{name: "not PEM", spec: "a=" + writePEM(t, "x", "CERTIFICATE", []byte("garbage")), wantErr: "unsupported PEM type"},
+		{name: "duplicate ID", spec: "a=" + writePrivateKey(t, "ed", edKey) + ",a=" + writePrivateKey(t, "ed", edKey), wantErr: "duplicate"},
+		{name: "missing ID", spec: writePrivateKey(t, "ed", edKey), wantErr: "expected kid=path"},
+		{name: "missing file", spec: "a=" + filepath.Join(t.TempDir(), "missing.pem"), wantErr: "failed to read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := LoadKeySet(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+
+			if got := set.Key("a").Method.Alg(); got != tt.wantAlg {
+				t.Errorf("got algorithm %s, want %s", got, tt.wantAlg)
+			}
+			token, err := set.Sign(jwt.MapClaims{"sub": "1"})
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if claims, err := set.Verify(token); err != nil || claims["sub"] != "1" {
+				t.Errorf("Verify: got %v, %v", claims, err)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey := newECDSAKey(t, elliptic.P256())
+	newKey := newRSAKey(t, 2048)
+
+	old, err := LoadKeySet("old=" + writePrivateKey(t, "old", oldKey))
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	// The new key signs the tokens, while the old one still verifies the tokens it signed.
+	rotated, err := LoadKeySet("new=" + writePrivateKey(t, "new", newKey) + ",old=" + writePublicKey(t, "old", &oldKey.PublicKey))
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	// Once retired, the old key no longer verifies anything.
+	retired, err := LoadKeySet("new=" + writePrivateKey(t, "new", newKey))
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+
+	sign := func(set *KeySet) string {
+		t.Helper()
+		token, err := set.Sign(jwt.MapClaims{"sub": "1"})
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return token
+	}
+	oldToken, newToken := sign(old), sign(rotated)
+
+	// A token claiming the kid of an asymmetric key but signed with HS256 and the public key as secret
+	// must not verify.
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	confused.Header["kid"] = "old"
+	pub, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	confusedToken, err := confused.SignedString(pub)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		set   *KeySet
+		token string
+		want  bool
+	}{
+		{name: "old token before rotation", set: old, token: oldToken, want: true},
+		{name: "old token after rotation", set: rotated, token: oldToken, want: true},
+		{name: "new token after rotation", set: rotated, token: newToken, want: true},
+		{name: "new token before rotation", set: old, token: newToken, want: false},
+		{name: "old token after retirement", set: retired, token: oldToken, want: false},
+		{name: "new token after retirement", set: retired, token: newToken, want: true},
+		{name: "algorithm confusion", set: rotated, token: confusedToken, want: false},
+		{name: "HMAC token", set: rotated, token: sign(NewHMACKeySet("secret")), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.set.Verify(tt.token); (err == nil) != tt.want {
+				t.Errorf("got error %v, want valid %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHMACKeySet(t *testing.T) {
+	set := NewHMACKeySet("secret")
+	token, err := set.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	if _, err := set.Verify(token); err != nil {
+		t.Errorf("verifying with the same secret: got error %v", err)
+	}
+	if _, err := NewHMACKeySet("other secret").Verify(token); err == nil {
+		t.Errorf("verifying with another secret: got no error")
+	}
+	if keys := set.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("got JWKS %v, want no keys", keys)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	ecKey := newECDSAKey(t, elliptic.P384())
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	set, err := LoadKeySet("rsa=" + writePrivateKey(t, "rsa", rsaKey) + ",ec=" + writePublicKey(t, "ec", &ecKey.PublicKey) + ",ed=" + writePrivateKey(t, "ed", edKey))
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+
+	keys := set.JWKS().Keys
+	if len(keys) != 3 {
+		t.Fatalf("got %d keys, want 3", len(keys))
+	}
+	decode := func(s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", s, err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		got  JWK
+		want JWK
+		ok   bool
+	}{
+		{
+			name: "RSA",
+			got:  keys[0],
+			want: JWK{KeyType: "RSA", KeyID: "rsa", Use: "sig", Algorithm: "RS256", N: keys[0].N, E: "AQAB"},
+			ok:   string(decode(keys[0].N)) == string(rsaKey.N.Bytes()),
+		},
+		{
+			name: "ECDSA",
+			got:  keys[1],
+			want: JWK{KeyType: "EC", KeyID: "ec", Use: "sig", Algorithm: "ES384", Curve: "P-384", X: keys[1].X, Y: keys[1].Y},
+			ok:   len(decode(keys[1].X)) == 48 && len(decode(keys[1].Y)) == 48 && string(decode(keys[1].X)) == string(ecKey.X.FillBytes(make([]byte, 48))),
+		},
+		{
+			name: "Ed25519",
+			got:  keys[2],
+			want: JWK{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: keys[2].X},
+			ok:   string(decode(keys[2].X)) == string(edPublic),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want || !tt.ok {
+				t.Errorf("got %+v, want %+v with the coordinates of the key", tt.got, tt.want)
+			}
+		})
+	}
+}
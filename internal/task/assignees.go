@@ -0,0 +1,92 @@
+package task
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// assigneeData holds the ID of the user to assign a task to.
+type assigneeData struct {
+	UserID *uint `json:"user_id"`
+}
+
+// AssignTaskHandler handles assigning a task the authenticated user may edit to a user who can see it:
+// the user who created it for a personal task, or a member of its workspace. It replaces the previous
+// assignee, if any.
+func AssignTaskHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+
+	var ad assigneeData
+	if err := ctx.ShouldBindJSON(&ad); err != nil || ad.UserID == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Check if the assignee can see the task
+	decision, err := app.Authorizer.AuthorizeTask(*ad.UserID, task, authz.ActionViewTasks)
+	if err != nil {
+		log.Printf("Warning: Failed to authorize access of user %d to task %d: %v", *ad.UserID, task.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign task"})
+		return
+	}
+	if decision != authz.DecisionAllowed {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id. The assignee must be able to see the task"})
+		return
+	}
+
+	updatedTask, err := app.TaskRepository.SetTaskAssignee(task.ID, ad.UserID)
+	if err == models.ErrUnknownUser {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id. The assignee must be able to see the task"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to assign task: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign task"})
+		return
+	}
+	if updatedTask == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Task assigned successfully",
+		"task":    updatedTask,
+	})
+}
+
+// UnassignTaskHandler handles unassigning a task the authenticated user may edit.
+func UnassignTaskHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+	if task.AssigneeID == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "The task isn't assigned to anyone"})
+		return
+	}
+
+	updatedTask, err := app.TaskRepository.SetTaskAssignee(task.ID, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to unassign task: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unassign task"})
+		return
+	}
+	if updatedTask == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Task unassigned successfully",
+		"task":    updatedTask,
+	})
+}
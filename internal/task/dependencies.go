@@ -0,0 +1,125 @@
+package task
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// dependencyData holds the details of a dependency of a task.
+type dependencyData struct {
+	BlockerID uint `json:"blocker_id"`
+}
+
+// blockedTaskMessage is the error returned when completing a task that is blocked by unfinished tasks.
+const blockedTaskMessage = "Task is blocked by tasks that aren't done yet"
+
+// GetDependenciesHandler handles retrieval of the tasks that directly block a task the authenticated user
+// may see.
+func GetDependenciesHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	blockers, err := app.TaskRepository.ListBlockers(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve task dependencies: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dependencies"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, blockers)
+}
+
+// AddDependencyHandler handles declaring that a task the authenticated user may edit is blocked by another
+// task of the same backlog. Dependencies creating a cycle are rejected.
+func AddDependencyHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+
+	var dd dependencyData
+	if err := ctx.ShouldBindJSON(&dd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Check if the blocker belongs to the same backlog as the task
+	blocker, err := app.TaskRepository.GetTaskByID(dd.BlockerID)
+	if err != nil {
+		log.Printf("Warning: Failed to get task details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		return
+	}
+	if blocker == nil || !blocker.InSameBacklog(task) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocker_id. The task doesn't exist"})
+		return
+	}
+
+	err = app.TaskRepository.AddDependency(task.ID, blocker.ID)
+	if err == models.ErrDependencyCycle {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The dependency would create a cycle"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to add task dependency: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add dependency"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Dependency added successfully"})
+}
+
+// RemoveDependencyHandler handles removing the dependency of a task the authenticated user may edit on
+// another task.
+func RemoveDependencyHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+
+	blockerID, err := strconv.ParseUint(ctx.Param("blockerID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocker ID"})
+		return
+	}
+
+	err = app.TaskRepository.RemoveDependency(task.ID, uint(blockerID))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Dependency not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to remove task dependency: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove dependency"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Dependency removed successfully"})
+}
+
+// GetCriticalPathHandler handles retrieval of the longest chain of unfinished tasks blocking a task the
+// authenticated user may see, in the order they should be done.
+func GetCriticalPathHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	graph, err := app.TaskRepository.GetUpstreamGraph(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve task dependencies: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dependencies"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, graph.CriticalPath(task.ID))
+}
@@ -5,36 +5,143 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
 	"github.com/milanvthakor/task-manager-api/internal/models"
 	"github.com/milanvthakor/task-manager-api/internal/validator"
 	"github.com/milanvthakor/task-manager-api/pkg/config"
 )
 
-// taskData holds the task details.
+// taskData holds the task details. The workspace of a task can only be set when creating it.
 type taskData struct {
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      models.TaskStatus `json:"status"`
+	Title              string              `json:"title"`
+	Description        string              `json:"description"`
+	Status             models.TaskStatus   `json:"status"`
+	Priority           models.TaskPriority `json:"priority"`
+	ProjectID          optionalID          `json:"project_id"`
+	ParentID           optionalID          `json:"parent_id"`
+	WorkspaceID        *uint               `json:"workspace_id"`
+	DueAt              optionalTime        `json:"due_at"`
+	StartAt            optionalTime        `json:"start_at"`
+	Recurrence         optionalString      `json:"recurrence"`
+	RecurrenceTimezone *string             `json:"recurrence_timezone"`
 }
 
-// GetTasksHandler handles retrieval of a list of tasks associated with the authenticated user.
+// invalidPriorityMessage is the error returned when a task priority is unknown.
+const invalidPriorityMessage = `Invalid priority. It can have one of the following values: "none", "low", "medium", "high", "urgent"`
+
+// invalidScheduleMessage is the error returned when a task starts after it's due.
+const invalidScheduleMessage = "Invalid schedule. The start date must not be after the due date"
+
+// workspaceProjectMessage is the error returned when putting a task of a workspace in a project.
+const workspaceProjectMessage = "Invalid project_id. The tasks of a workspace don't belong to projects"
+
+// GetTasksHandler handles retrieval of a page of the personal tasks of the authenticated user, or of the
+// tasks of the workspace given by the "workspace_id" query parameter. The "assignee" and "created_by"
+// query parameters list the tasks assigned to or created by someone, e.g. "me", across every workspace
+// of the user unless one is given.
 func GetTasksHandler(ctx *gin.Context, app *config.Application) {
 	userID := ctx.MustGet("userID").(uint)
 
+	// Parse the filtering, sorting and pagination options
+	opts, err := parseTaskListOptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyWorkspaceFilter(ctx, app, opts) {
+		return
+	}
+
 	// Retrieve tasks associated with the user from the database
-	tasks, err := app.TaskRepository.ListTasksByUserID(userID)
+	page, err := app.TaskRepository.ListTasks(userID, *opts)
+	if err == models.ErrInvalidCursor {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to retrieve tasks: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, tasks)
+	ctx.JSON(http.StatusOK, page)
 }
 
-// CreateTaskHandler handles the creation of a new task.
+// GetTasksDueTodayHandler handles retrieval of a page of the authenticated user's tasks due today,
+// in the timezone given by the "tz" query parameter, or else in the user's timezone. The tasks of a
+// workspace are listed instead when the "workspace_id" query parameter is given.
+func GetTasksDueTodayHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	// Parse the filtering and pagination options
+	opts, err := parseTaskListOptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyWorkspaceFilter(ctx, app, opts) {
+		return
+	}
+	timezone, ok := userTimezone(ctx, app)
+	if !ok {
+		return
+	}
+	loc, err := parseLocation(ctx, timezone)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Restrict the list to the tasks due between the start of today and the start of tomorrow
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	startOfNextDay := startOfDay.AddDate(0, 0, 1)
+	opts.DueAfter = &startOfDay
+	opts.DueBefore = &startOfNextDay
+	opts.Sort = "due_at"
+
+	// Retrieve tasks associated with the user from the database
+	page, err := app.TaskRepository.ListTasks(userID, *opts)
+	if err == models.ErrInvalidCursor {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetNextTasksHandler handles retrieval of the authenticated user's personal tasks that should be done
+// next, ordered by priority and then by due date.
+func GetNextTasksHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	limit, err := parseLimit(ctx, defaultNextTasksLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Retrieve tasks associated with the user from the database
+	tasks, err := app.TaskRepository.ListNextTasks(userID, limit)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// CreateTaskHandler handles the creation of a new task, which is personal unless a workspace is given.
+// Creating a task in a workspace requires a role allowed to edit its tasks.
 func CreateTaskHandler(ctx *gin.Context, app *config.Application) {
 	userID := ctx.MustGet("userID").(uint)
 
@@ -54,14 +161,54 @@ func CreateTaskHandler(ctx *gin.Context, app *config.Application) {
 		return
 	}
 
+	if validator.IsBlank(string(td.Priority)) {
+		td.Priority = models.TaskPriorityNone
+	}
+	if !validator.IsValidTaskPriority(td.Priority) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidPriorityMessage})
+		return
+	}
+	if !validator.IsValidTaskSchedule(td.StartAt.Value, td.DueAt.Value) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidScheduleMessage})
+		return
+	}
+
 	// Store task details in the database.
 	task := &models.Task{
 		Title:       td.Title,
 		Description: td.Description,
 		Status:      td.Status,
+		Priority:    td.Priority,
+		ParentID:    td.ParentID.Value,
+		WorkspaceID: td.WorkspaceID,
 		UserID:      userID,
+		DueAt:       td.DueAt.Value,
+		StartAt:     td.StartAt.Value,
+	}
+	if task.WorkspaceID != nil {
+		if td.ProjectID.Value != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": workspaceProjectMessage})
+			return
+		}
+		if !authorizeWorkspace(ctx, app, *task.WorkspaceID, authz.ActionEditTasks) {
+			return
+		}
+	} else {
+		// Personal tasks without a project go to the Inbox.
+		projectID, ok := resolveProject(ctx, app, userID, td.ProjectID.Value)
+		if !ok {
+			return
+		}
+
+		task.ProjectID = &projectID
+	}
+	if !applyRecurrence(ctx, app, task, &td) {
+		return
 	}
 	newTask, err := app.TaskRepository.CreateTask(task)
+	if writeParentError(ctx, err) {
+		return
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to create task: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
@@ -74,8 +221,39 @@ func CreateTaskHandler(ctx *gin.Context, app *config.Application) {
 	})
 }
 
-// GetTaskByIDHandler handles the retrieval of a task by ID only if it's associated with the authenticated user.
+// GetTaskByIDHandler handles the retrieval of a task by ID only if the authenticated user may see it.
+// The labels of the task and the progress of its subtasks are embedded in the response.
 func GetTaskByIDHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	// Retrieve the labels attached to the task
+	labels, err := app.LabelRepository.ListLabelsByTaskID(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get task labels from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		return
+	}
+	task.Labels = labels
+
+	// Compute the progress of the subtasks of the task
+	progress, err := app.TaskRepository.GetTaskProgress(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get task progress from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		return
+	}
+	task.Progress = progress
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// getAuthorizedTask retrieves the task identified in the URL. If the authenticated user may not do the
+// action with it, or it can't be retrieved, it writes the error response and returns nil. Tasks the user
+// can't see are reported as not found.
+func getAuthorizedTask(ctx *gin.Context, app *config.Application, action authz.Action) *models.Task {
 	userID := ctx.MustGet("userID").(uint)
 	taskID := ctx.MustGet("taskID").(uint)
 
@@ -84,25 +262,100 @@ func GetTaskByIDHandler(ctx *gin.Context, app *config.Application) {
 	if err != nil {
 		log.Printf("Warning: Failed to get task details from the database: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
-		return
+		return nil
+	}
+	if task == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil
 	}
 
-	// Check if the task is associated with the authenticated user
-	if task == nil || task.UserID != userID {
+	// Check if the authenticated user may do the action with the task
+	decision, err := app.Authorizer.AuthorizeTask(userID, task, action)
+	if err != nil {
+		log.Printf("Warning: Failed to authorize access to task %d: %v", task.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		return nil
+	}
+	switch decision {
+	case authz.DecisionHidden:
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
+		return nil
+	case authz.DecisionForbidden:
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return nil
 	}
 
-	ctx.JSON(http.StatusOK, task)
+	return task
 }
 
-// DeleteTaskByIDHandler handles the deletion of a task by ID only if it's associated with the authenticated user.
-func DeleteTaskByIDHandler(ctx *gin.Context, app *config.Application) {
+// authorizeWorkspace checks if the authenticated user may do the action in a workspace. If not, or if it
+// can't be checked, it writes the error response and returns false.
+func authorizeWorkspace(ctx *gin.Context, app *config.Application, workspaceID uint, action authz.Action) bool {
 	userID := ctx.MustGet("userID").(uint)
-	taskID := ctx.MustGet("taskID").(uint)
+
+	_, decision, err := app.Authorizer.AuthorizeWorkspace(userID, workspaceID, action)
+	if err != nil {
+		log.Printf("Warning: Failed to authorize access to workspace %d: %v", workspaceID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspace"})
+		return false
+	}
+	switch decision {
+	case authz.DecisionHidden:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace_id. The workspace doesn't exist"})
+		return false
+	case authz.DecisionForbidden:
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return false
+	}
+
+	return true
+}
+
+// applyWorkspaceFilter restricts the task list to the workspace given by the "workspace_id" query
+// parameter, if any, which the authenticated user must be a member of. If not, it writes the error
+// response and returns false. Without a workspace, filtering by assignee or creator lists the tasks of
+// every workspace of the user along with their personal tasks.
+func applyWorkspaceFilter(ctx *gin.Context, app *config.Application, opts *models.TaskListOptions) bool {
+	workspaceID, err := parseWorkspaceID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if workspaceID == nil {
+		opts.AllBacklogs = opts.AssigneeID != nil || opts.Unassigned || opts.CreatorID != nil
+		return true
+	}
+	if !authorizeWorkspace(ctx, app, *workspaceID, authz.ActionViewTasks) {
+		return false
+	}
+
+	opts.WorkspaceID = workspaceID
+	return true
+}
+
+// userTimezone returns the timezone of the authenticated user. If the user can't be retrieved, it
+// writes the error response and returns false.
+func userTimezone(ctx *gin.Context, app *config.Application) (string, bool) {
+	userID := ctx.MustGet("userID").(uint)
+	user, err := app.UserRepository.GetUserByID(userID)
+	if err != nil || user == nil {
+		log.Printf("Warning: Failed to retrieve user %d: %v", userID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return "", false
+	}
+
+	return user.Timezone, true
+}
+
+// DeleteTaskByIDHandler handles the deletion of a task by ID only if the authenticated user may edit it.
+func DeleteTaskByIDHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
 
 	// Delete the task from the database
-	err := app.TaskRepository.DeleteTask(uint(taskID), userID)
+	err := app.TaskRepository.DeleteTask(task.ID)
 	if err == sql.ErrNoRows {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
@@ -116,22 +369,12 @@ func DeleteTaskByIDHandler(ctx *gin.Context, app *config.Application) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
 
-// UpdateTaskByIDHandler handles the updating of a task by ID only if it's associated with the authentication user.
+// UpdateTaskByIDHandler handles the updating of a task by ID only if the authenticated user may edit it.
 func UpdateTaskByIDHandler(ctx *gin.Context, app *config.Application) {
 	userID := ctx.MustGet("userID").(uint)
-	taskID := ctx.MustGet("taskID").(uint)
 
-	// Retrieve the task from the database
-	task, err := app.TaskRepository.GetTaskByID(uint(taskID))
-	if err != nil {
-		log.Printf("Warning: Failed to get task details from the database: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
-		return
-	}
-
-	// Check if the task is associated with the authenticated user
-	if task == nil || task.UserID != userID {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
 		return
 	}
 
@@ -143,6 +386,7 @@ func UpdateTaskByIDHandler(ctx *gin.Context, app *config.Application) {
 	}
 
 	// Update only provided fields
+	completing := false
 	if !validator.IsBlank(td.Title) {
 		task.Title = td.Title
 	}
@@ -151,25 +395,83 @@ func UpdateTaskByIDHandler(ctx *gin.Context, app *config.Application) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": `Invalid status. It can have one of the following values: "todo", "in progress", "done"`})
 			return
 		}
+		if td.Status == models.TaskStatusDone && task.Status != models.TaskStatusDone && task.Blocked {
+			ctx.JSON(http.StatusConflict, gin.H{"error": blockedTaskMessage})
+			return
+		}
 
+		completing = td.Status == models.TaskStatusDone && task.Status != models.TaskStatusDone
 		task.Status = td.Status
 	}
 	if !validator.IsBlank(td.Description) {
 		task.Description = td.Description
 	}
+	if !validator.IsBlank(string(td.Priority)) {
+		if !validator.IsValidTaskPriority(td.Priority) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidPriorityMessage})
+			return
+		}
+
+		task.Priority = td.Priority
+	}
+	td.DueAt.apply(&task.DueAt)
+	td.StartAt.apply(&task.StartAt)
+	if !validator.IsValidTaskSchedule(task.StartAt, task.DueAt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidScheduleMessage})
+		return
+	}
+	if td.ProjectID.Set && task.WorkspaceID != nil {
+		if td.ProjectID.Value != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": workspaceProjectMessage})
+			return
+		}
+	} else if td.ProjectID.Set {
+		// Moving a task out of its project puts it back in the Inbox.
+		projectID, ok := resolveProject(ctx, app, userID, td.ProjectID.Value)
+		if !ok {
+			return
+		}
 
-	// Update the task in the database
-	updatedTask, err := app.TaskRepository.UpdateTask(task)
+		task.ProjectID = &projectID
+	}
+	if td.ParentID.Set {
+		task.ParentID = td.ParentID.Value
+	}
+	if !applyRecurrence(ctx, app, task, &td) {
+		return
+	}
+
+	// Update the task in the database. Completing an occurrence of a recurring task moves the series to
+	// the next occurrence.
+	var updatedTask, nextTask *models.Task
+	var err error
+	if completing {
+		updatedTask, nextTask, err = completeTask(app, task)
+	} else {
+		updatedTask, err = app.TaskRepository.UpdateTask(task)
+	}
+	if writeParentError(ctx, err) {
+		return
+	}
+	if err == models.ErrTaskAlreadyDone {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The task has already been marked as done"})
+		return
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to update task: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Task updated successfully",
 		"task":    updatedTask,
-	})
+	}
+	if nextTask != nil {
+		response["next_task"] = nextTask
+	}
+
+	ctx.JSON(http.StatusOK, response)
 }
 
 // updateResult represents the result of each task update operation.
@@ -177,18 +479,31 @@ type updateResult struct {
 	ID      uint   `json:"id"`
 	Message string `json:"message,omitempty"`
 	Error   string `json:"error,omitempty"`
+	// NextTaskID is the ID of the next occurrence created when the task is recurring.
+	NextTaskID *uint `json:"next_task_id,omitempty"`
+	// BlockedSubtaskIDs are the IDs of the subtasks left unchanged by the cascade as they are blocked.
+	BlockedSubtaskIDs []uint `json:"blocked_subtask_ids,omitempty"`
 }
 
-// MarkTasksDoneHandler allows users to mark multiple tasks as "done".
+// MarkTasksDoneHandler allows users to mark multiple tasks they may edit as "done". Their subtasks are marked as
+// done as well when the "cascade" query parameter, or the CascadeDoneToSubtasks setting by default, is true.
+// The next occurrence of each recurring task is created.
 func MarkTasksDoneHandler(ctx *gin.Context, app *config.Application) {
 	userID := ctx.MustGet("userID").(uint)
 
-	// Parse task IDs from the request body
+	cascade, err := parseBool(ctx, "cascade", app.Config.CascadeDoneToSubtasks)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse task IDs from the request body, ignoring the duplicates
 	var taskIDs []uint
 	if err := ctx.ShouldBindJSON(&taskIDs); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID(s)"})
 		return
 	}
+	taskIDs = uniqueIDs(taskIDs)
 
 	// Create a channel to receive task update results
 	updateResultChan := make(chan *updateResult, len(taskIDs))
@@ -210,21 +525,58 @@ func MarkTasksDoneHandler(ctx *gin.Context, app *config.Application) {
 				return
 			}
 
-			// Check if the task is associated with the authenticated user
-			if task == nil || task.UserID != userID {
+			if task == nil {
 				updateResultChan <- &updateResult{ID: taskID, Error: "Task not found"}
 				return
 			}
 
-			// Update the task status to "done" and save it to the database
-			task.Status = models.TaskStatusDone
-			if _, err := app.TaskRepository.UpdateTask(task); err != nil {
-				log.Printf("Warning: Failed to update task: %v", err)
-				updateResultChan <- &updateResult{ID: taskID, Error: "Failed to update task"}
+			// Check if the authenticated user may edit the task
+			decision, err := app.Authorizer.AuthorizeTask(userID, task, authz.ActionEditTasks)
+			if err != nil {
+				log.Printf("Warning: Failed to authorize access to task %d: %v", task.ID, err)
+				updateResultChan <- &updateResult{ID: taskID, Error: "Failed to retrieve task"}
 				return
 			}
+			if decision == authz.DecisionHidden {
+				updateResultChan <- &updateResult{ID: taskID, Error: "Task not found"}
+				return
+			}
+			if decision == authz.DecisionForbidden {
+				updateResultChan <- &updateResult{ID: taskID, Error: authz.ForbiddenMessage}
+				return
+			}
+			if task.Status != models.TaskStatusDone && task.Blocked {
+				updateResultChan <- &updateResult{ID: taskID, Error: blockedTaskMessage}
+				return
+			}
+
+			// Update the task status to "done" and save it to the database, unless it's already done
+			var nextTask *models.Task
+			if task.Status != models.TaskStatusDone {
+				_, nextTask, err = completeTask(app, task)
+				if err != nil && err != models.ErrTaskAlreadyDone {
+					log.Printf("Warning: Failed to update task: %v", err)
+					updateResultChan <- &updateResult{ID: taskID, Error: "Failed to update task"}
+					return
+				}
+			}
+			result := &updateResult{ID: taskID, Message: "Task marked as done successfully"}
+			if cascade {
+				blockedIDs, err := markSubtasksDone(app, task.ID)
+				if err != nil {
+					log.Printf("Warning: Failed to mark subtasks as done: %v", err)
+					updateResultChan <- &updateResult{ID: taskID, Error: "Failed to update subtasks"}
+					return
+				}
+				if len(blockedIDs) > 0 {
+					result.BlockedSubtaskIDs = blockedIDs
+				}
+			}
+			if nextTask != nil {
+				result.NextTaskID = &nextTask.ID
+			}
 
-			updateResultChan <- &updateResult{ID: taskID, Message: "Task marked as done successfully"}
+			updateResultChan <- result
 		}(taskID)
 	}
 
@@ -0,0 +1,396 @@
+package task
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/apitest"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/utils"
+)
+
+// newTestServer creates a test server with the task routes.
+func newTestServer(t *testing.T) *apitest.Server {
+	t.Helper()
+
+	s := apitest.NewServer(t)
+	app := s.App
+	tasks := s.Router.Group("/api/tasks", apitest.Authenticate)
+	tasks.GET("/", utils.InjectApp(app, GetTasksHandler))
+	tasks.GET("/due-today", utils.InjectApp(app, GetTasksDueTodayHandler))
+	tasks.POST("/", utils.InjectApp(app, CreateTaskHandler))
+	tasks.PATCH("/mark-done", utils.InjectApp(app, MarkTasksDoneHandler))
+	tasks.GET("/:id", ExtractTaskIDMiddleware, utils.InjectApp(app, GetTaskByIDHandler))
+	tasks.PUT("/:id", ExtractTaskIDMiddleware, utils.InjectApp(app, UpdateTaskByIDHandler))
+	tasks.DELETE("/:id", ExtractTaskIDMiddleware, utils.InjectApp(app, DeleteTaskByIDHandler))
+	tasks.POST("/:id/dependencies", ExtractTaskIDMiddleware, utils.InjectApp(app, AddDependencyHandler))
+	tasks.GET("/:id/occurrences", ExtractTaskIDMiddleware, utils.InjectApp(app, GetOccurrencesHandler))
+
+	return s
+}
+
+// createTask creates a task as a user through the API.
+func createTask(t *testing.T, s *apitest.Server, userID uint, task gin.H) *models.Task {
+	t.Helper()
+
+	if _, ok := task["status"]; !ok {
+		task["status"] = models.TaskStatusTodo
+	}
+	var res struct {
+		Task  *models.Task `json:"task"`
+		Error string       `json:"error"`
+	}
+	if code := s.Do(http.MethodPost, "/api/tasks/", apitest.AsUser(userID), task, &res); code != http.StatusCreated {
+		t.Fatalf("creating task %v: got status %d, %q", task, code, res.Error)
+	}
+
+	return res.Task
+}
+
+func TestGetTasksPagination(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.NewUser("alice@example.com")
+	otherID := s.NewUser("bob@example.com")
+	for _, title := range []string{"c", "a", "e", "b", "d"} {
+		createTask(t, s, userID, gin.H{"title": title})
+	}
+	createTask(t, s, otherID, gin.H{"title": "f"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "newest first", query: "limit=2", want: []string{"d", "b", "e", "a", "c"}},
+		{name: "by title", query: "limit=2&sort=title", want: []string{"a", "b", "c", "d", "e"}},
+		{name: "by title descending", query: "limit=3&sort=-title", want: []string{"e", "d", "c", "b", "a"}},
+		{name: "filtered", query: "limit=1&sort=title&q=a", want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			path := "/api/tasks/?" + tt.query
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatalf("pagination doesn't end, got %v", got)
+				}
+
+				var page models.TaskPage
+				if code := s.Do(http.MethodGet, path, apitest.AsUser(userID), nil, &page); code != http.StatusOK {
+					t.Fatalf("GET %s: got status %d", path, code)
+				}
+				for _, task := range page.Tasks {
+					got = append(got, task.Title)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				path = "/api/tasks/?" + tt.query + "&cursor=" + page.NextCursor
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTasksInvalidOptions(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.NewUser("alice@example.com")
+	for _, title := range []string{"a", "b"} {
+		createTask(t, s, userID, gin.H{"title": title})
+	}
+	var page models.TaskPage
+	s.Do(http.MethodGet, "/api/tasks/?limit=1&sort=title", apitest.AsUser(userID), nil, &page)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "zero limit", query: "limit=0"},
+		{name: "unknown sort", query: "sort=color"},
+		{name: "garbage cursor", query: "cursor=garbage"},
+		{name: "cursor of another sort", query: "sort=-title&cursor=" + page.NextCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := s.Do(http.MethodGet, "/api/tasks/?"+tt.query, apitest.AsUser(userID), nil, nil); code != http.StatusBadRequest {
+				t.Errorf("got status %d, want %d", code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestCompleteRecurringTask(t *testing.T) {
+	due := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		complete func(s *apitest.Server, userID, taskID uint) int
+	}{
+		{
+			name: "update",
+			complete: func(s *apitest.Server, userID, taskID uint) int {
+				return s.Do(http.MethodPut, "/api/tasks/"+strconv.Itoa(int(taskID)), apitest.AsUser(userID), gin.H{"status": "done"}, nil)
+			},
+		},
+		{
+			name: "mark done with duplicate IDs",
+			complete: func(s *apitest.Server, userID, taskID uint) int {
+				return s.Do(http.MethodPatch, "/api/tasks/mark-done", apitest.AsUser(userID), []uint{taskID, taskID, taskID}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			userID := s.NewUser("alice@example.com")
+			task := createTask(t, s, userID, gin.H{"title": "Weekly review", "due_at": due, "recurrence": "FREQ=WEEKLY"})
+
+			if code := tt.complete(s, userID, task.ID); code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", code, http.StatusOK)
+			}
+			// Completing it again doesn't create another occurrence.
+			tt.complete(s, userID, task.ID)
+
+			var page models.TaskPage
+			s.Do(http.MethodGet, "/api/tasks/?sort=created_at", apitest.AsUser(userID), nil, &page)
+			if len(page.Tasks) != 2 {
+				t.Fatalf("got %d tasks, want the completed task and its next occurrence", len(page.Tasks))
+			}
+			completed, next := page.Tasks[0], page.Tasks[1]
+			if completed.Status != models.TaskStatusDone || completed.Recurrence != nil {
+				t.Errorf("completed task has status %q and recurrence %v, want done without recurrence", completed.Status, completed.Recurrence)
+			}
+			if next.Status != models.TaskStatusTodo || next.Recurrence == nil || next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 7)) {
+				t.Errorf("got next occurrence %+v, want a recurring task due a week later", next)
+			}
+		})
+	}
+}
+
+func TestGetTasksDueTodayTimezone(t *testing.T) {
+	tests := []struct {
+		name         string
+		userTimezone string
+		query        string
+		timezone     string
+	}{
+		{name: "user's timezone", userTimezone: "Pacific/Kiritimati", timezone: "Pacific/Kiritimati"},
+		{name: "requested timezone", userTimezone: "Pacific/Kiritimati", query: "?tz=Pacific/Pago_Pago", timezone: "Pacific/Pago_Pago"},
+		{name: "UTC", userTimezone: "UTC", timezone: "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			userID := s.NewUser("alice@example.com")
+			if err := s.App.UserRepository.UpdateUserProfile(&models.User{ID: userID, Timezone: tt.userTimezone, Locale: "en"}); err != nil {
+				t.Fatalf("UpdateUserProfile: %v", err)
+			}
+
+			// Only the task due just after the start of today, in the timezone, is due today.
+			loc, err := time.LoadLocation(tt.timezone)
+			if err != nil {
+				t.Fatalf("LoadLocation: %v", err)
+			}
+			now := time.Now().In(loc)
+			startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+			createTask(t, s, userID, gin.H{"title": "yesterday", "due_at": startOfDay.Add(-time.Minute)})
+			today := createTask(t, s, userID, gin.H{"title": "today", "due_at": startOfDay.Add(time.Minute)})
+			createTask(t, s, userID, gin.H{"title": "tomorrow", "due_at": startOfDay.AddDate(0, 0, 1).Add(time.Minute)})
+
+			var page models.TaskPage
+			if code := s.Do(http.MethodGet, "/api/tasks/due-today"+tt.query, apitest.AsUser(userID), nil, &page); code != http.StatusOK {
+				t.Fatalf("got status %d", code)
+			}
+			if len(page.Tasks) != 1 || page.Tasks[0].ID != today.ID {
+				t.Errorf("got tasks %+v, want only %q", page.Tasks, today.Title)
+			}
+		})
+	}
+}
+
+func TestRecurrenceTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	// Clocks go back in Berlin on 2026-10-25.
+	tests := []struct {
+		name         string
+		userTimezone string
+		task         gin.H
+		want         []time.Time
+	}{
+		{
+			name:         "weekday in the user's timezone",
+			userTimezone: "Europe/Berlin",
+			task:         gin.H{"due_at": "2026-10-19T00:30:00+02:00", "recurrence": "FREQ=WEEKLY;BYDAY=MO"},
+			want:         []time.Time{time.Date(2026, 10, 26, 0, 30, 0, 0, berlin), time.Date(2026, 11, 2, 0, 30, 0, 0, berlin)},
+		},
+		{
+			name:         "requested timezone",
+			userTimezone: "UTC",
+			task:         gin.H{"due_at": "2026-10-22T09:00:00+02:00", "recurrence": "FREQ=WEEKLY", "recurrence_timezone": "Europe/Berlin"},
+			want:         []time.Time{time.Date(2026, 10, 29, 9, 0, 0, 0, berlin), time.Date(2026, 11, 5, 9, 0, 0, 0, berlin)},
+		},
+		{
+			name:         "UTC",
+			userTimezone: "UTC",
+			task:         gin.H{"due_at": "2026-10-22T09:00:00+02:00", "recurrence": "FREQ=WEEKLY"},
+			want:         []time.Time{time.Date(2026, 10, 29, 7, 0, 0, 0, time.UTC), time.Date(2026, 11, 5, 7, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			userID := s.NewUser("alice@example.com")
+			if err := s.App.UserRepository.UpdateUserProfile(&models.User{ID: userID, Timezone: tt.userTimezone, Locale: "en"}); err != nil {
+				t.Fatalf("UpdateUserProfile: %v", err)
+			}
+			tt.task["title"] = "Weekly review"
+			task := createTask(t, s, userID, tt.task)
+			path := "/api/tasks/" + strconv.Itoa(int(task.ID))
+
+			var preview occurrencesResponse
+			if code := s.Do(http.MethodGet, path+"/occurrences?limit=2", apitest.AsUser(userID), nil, &preview); code != http.StatusOK {
+				t.Fatalf("previewing: got status %d", code)
+			}
+			if len(preview.Occurrences) != len(tt.want) {
+				t.Fatalf("got occurrences %v, want %v", preview.Occurrences, tt.want)
+			}
+			for i := range tt.want {
+				if !preview.Occurrences[i].Equal(tt.want[i]) {
+					t.Errorf("got occurrences %v, want %v", preview.Occurrences, tt.want)
+					break
+				}
+			}
+
+			// The next occurrence is due at the first date of the preview.
+			if code := s.Do(http.MethodPut, path, apitest.AsUser(userID), gin.H{"status": "done"}, nil); code != http.StatusOK {
+				t.Fatalf("completing: got status %d", code)
+			}
+			var page models.TaskPage
+			s.Do(http.MethodGet, "/api/tasks/?sort=created_at", apitest.AsUser(userID), nil, &page)
+			if len(page.Tasks) != 2 || page.Tasks[1].DueAt == nil || !page.Tasks[1].DueAt.Equal(tt.want[0]) {
+				t.Errorf("got tasks %+v, want a next occurrence due at %v", page.Tasks, tt.want[0])
+			}
+		})
+	}
+}
+
+func TestInvalidRecurrenceTimezone(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.NewUser("alice@example.com")
+
+	tests := []struct {
+		name string
+		task gin.H
+	}{
+		{name: "unknown", task: gin.H{"title": "a", "status": "todo", "due_at": "2026-10-19T09:00:00Z", "recurrence": "FREQ=DAILY", "recurrence_timezone": "Europe/Atlantis"}},
+		{name: "without recurrence", task: gin.H{"title": "a", "status": "todo", "due_at": "2026-10-19T09:00:00Z", "recurrence_timezone": "Europe/Berlin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := s.Do(http.MethodPost, "/api/tasks/", apitest.AsUser(userID), tt.task, nil); code != http.StatusBadRequest {
+				t.Errorf("got status %d, want %d", code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestCompleteBlockedTask(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.NewUser("alice@example.com")
+	blocker := createTask(t, s, userID, gin.H{"title": "Design"})
+	task := createTask(t, s, userID, gin.H{"title": "Build"})
+	path := "/api/tasks/" + strconv.Itoa(int(task.ID))
+	if code := s.Do(http.MethodPost, path+"/dependencies", apitest.AsUser(userID), gin.H{"blocker_id": blocker.ID}, nil); code != http.StatusCreated {
+		t.Fatalf("adding dependency: got status %d", code)
+	}
+
+	if code := s.Do(http.MethodPut, path, apitest.AsUser(userID), gin.H{"status": "done"}, nil); code != http.StatusConflict {
+		t.Errorf("update: got status %d, want %d", code, http.StatusConflict)
+	}
+	var results []updateResult
+	s.Do(http.MethodPatch, "/api/tasks/mark-done", apitest.AsUser(userID), []uint{task.ID}, &results)
+	if len(results) != 1 || results[0].Error != blockedTaskMessage {
+		t.Errorf("mark done: got %+v, want the task reported as blocked", results)
+	}
+
+	// Completing the blocker unblocks the task.
+	if code := s.Do(http.MethodPut, "/api/tasks/"+strconv.Itoa(int(blocker.ID)), apitest.AsUser(userID), gin.H{"status": "done"}, nil); code != http.StatusOK {
+		t.Fatalf("completing blocker: got status %d", code)
+	}
+	if code := s.Do(http.MethodPut, path, apitest.AsUser(userID), gin.H{"status": "done"}, nil); code != http.StatusOK {
+		t.Errorf("update once unblocked: got status %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestTaskAuthorizationRoles(t *testing.T) {
+	s := newTestServer(t)
+	workspaces := s.App.WorkspaceRepository
+
+	ownerID := s.NewUser("owner@example.com")
+	workspace, err := workspaces.CreateWorkspace(&models.Workspace{Name: "Team"}, ownerID)
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	users := map[string]uint{"owner": ownerID, "outsider": s.NewUser("outsider@example.com")}
+	for _, role := range []models.WorkspaceRole{models.WorkspaceRoleAdmin, models.WorkspaceRoleMember, models.WorkspaceRoleViewer} {
+		email := string(role) + "@example.com"
+		users[string(role)] = s.NewUser(email)
+		invite, err := workspaces.CreateWorkspaceInvite(&models.WorkspaceInvite{WorkspaceID: workspace.ID, Email: email, Role: role, TokenHash: email, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("CreateWorkspaceInvite: %v", err)
+		}
+		if err := workspaces.AcceptWorkspaceInvite(invite.ID, users[string(role)]); err != nil {
+			t.Fatalf("AcceptWorkspaceInvite: %v", err)
+		}
+	}
+	task := createTask(t, s, ownerID, gin.H{"title": "Shared", "workspace_id": workspace.ID})
+	personal := createTask(t, s, ownerID, gin.H{"title": "Personal"})
+
+	tests := []struct {
+		user     string
+		taskID   uint
+		wantView int
+		wantEdit int
+	}{
+		{user: "owner", taskID: task.ID, wantView: http.StatusOK, wantEdit: http.StatusOK},
+		{user: "admin", taskID: task.ID, wantView: http.StatusOK, wantEdit: http.StatusOK},
+		{user: "member", taskID: task.ID, wantView: http.StatusOK, wantEdit: http.StatusOK},
+		{user: "viewer", taskID: task.ID, wantView: http.StatusOK, wantEdit: http.StatusForbidden},
+		{user: "outsider", taskID: task.ID, wantView: http.StatusNotFound, wantEdit: http.StatusNotFound},
+		{user: "member", taskID: personal.ID, wantView: http.StatusNotFound, wantEdit: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user+"/"+strconv.Itoa(int(tt.taskID)), func(t *testing.T) {
+			path := "/api/tasks/" + strconv.Itoa(int(tt.taskID))
+			if code := s.Do(http.MethodGet, path, apitest.AsUser(users[tt.user]), nil, nil); code != tt.wantView {
+				t.Errorf("view: got status %d, want %d", code, tt.wantView)
+			}
+			if code := s.Do(http.MethodPut, path, apitest.AsUser(users[tt.user]), gin.H{"description": "Edited by " + tt.user}, nil); code != tt.wantEdit {
+				t.Errorf("edit: got status %d, want %d", code, tt.wantEdit)
+			}
+		})
+	}
+
+	// Viewers can't create tasks in the workspace either, and outsiders don't even see it.
+	for user, want := range map[string]int{"viewer": http.StatusForbidden, "outsider": http.StatusBadRequest, "member": http.StatusCreated} {
+		body := gin.H{"title": "New", "status": "todo", "workspace_id": workspace.ID}
+		if code := s.Do(http.MethodPost, "/api/tasks/", apitest.AsUser(users[user]), body, nil); code != want {
+			t.Errorf("%s creating a task: got status %d, want %d", user, code, want)
+		}
+	}
+}
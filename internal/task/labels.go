@@ -0,0 +1,71 @@
+package task
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// AttachLabelHandler handles attaching a label of the authenticated user to a personal task they may
+// edit.
+func AttachLabelHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+	labelID := ctx.MustGet("labelID").(uint)
+
+	// Check if the label is associated with the authenticated user
+	label, err := app.LabelRepository.GetLabelByID(labelID)
+	if err != nil {
+		log.Printf("Warning: Failed to get label details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve label"})
+		return
+	}
+	if label == nil || label.UserID != userID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		return
+	}
+
+	// Labels are personal, unlike the tasks of a workspace shared by its members
+	if task.WorkspaceID != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Personal labels can't be attached to workspace tasks"})
+		return
+	}
+
+	if err := app.LabelRepository.AttachLabel(task.ID, label.ID); err != nil {
+		log.Printf("Warning: Failed to attach label: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach label"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Label attached successfully"})
+}
+
+// DetachLabelHandler handles detaching a label from a task the authenticated user may edit.
+func DetachLabelHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+	labelID := ctx.MustGet("labelID").(uint)
+
+	err := app.LabelRepository.DetachLabel(task.ID, labelID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not attached to the task"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to detach label: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detach label"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Label detached successfully"})
+}
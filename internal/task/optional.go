@@ -0,0 +1,52 @@
+package task
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// optionalTime holds a time that may be omitted, set, or explicitly cleared with null in a JSON body.
+type optionalTime struct {
+	// Set reports whether the field was present in the body.
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It's only called when the field is present.
+func (o *optionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// apply replaces the destination with the value if the field was present in the body.
+func (o optionalTime) apply(dst **time.Time) {
+	if o.Set {
+		*dst = o.Value
+	}
+}
+
+// optionalID holds a record ID that may be omitted, set, or explicitly cleared with null in a JSON body.
+type optionalID struct {
+	// Set reports whether the field was present in the body.
+	Set   bool
+	Value *uint
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It's only called when the field is present.
+func (o *optionalID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// optionalString holds a string that may be omitted, set, or explicitly cleared with null in a JSON body.
+type optionalString struct {
+	// Set reports whether the field was present in the body.
+	Set   bool
+	Value *string
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It's only called when the field is present.
+func (o *optionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
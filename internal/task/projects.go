@@ -0,0 +1,80 @@
+package task
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// GetProjectTasksHandler handles retrieval of a page of the tasks of a project that belongs to the
+// authenticated user. It accepts the same query parameters as GetTasksHandler.
+func GetProjectTasksHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+	projectID := ctx.MustGet("projectID").(uint)
+
+	// Parse the filtering, sorting and pagination options
+	opts, err := parseTaskListOptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check if the project is associated with the authenticated user
+	project, err := app.ProjectRepository.GetProjectByID(projectID)
+	if err != nil {
+		log.Printf("Warning: Failed to get project details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+		return
+	}
+	if project == nil || project.UserID != userID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	opts.ProjectID = &project.ID
+
+	// Retrieve the tasks of the project from the database
+	page, err := app.TaskRepository.ListTasks(userID, *opts)
+	if err == models.ErrInvalidCursor {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// resolveProject returns the ID of the project a task of the user should belong to: the requested
+// project, or the user's Inbox when it's null. If the project doesn't belong to the user, or can't be
+// retrieved, it writes the error response and returns false.
+func resolveProject(ctx *gin.Context, app *config.Application, userID uint, projectID *uint) (uint, bool) {
+	if projectID == nil {
+		inbox, err := models.InboxProject(app.ProjectRepository, userID)
+		if err != nil {
+			log.Printf("Warning: Failed to get the Inbox project: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+			return 0, false
+		}
+
+		return inbox.ID, true
+	}
+
+	project, err := app.ProjectRepository.GetProjectByID(*projectID)
+	if err != nil {
+		log.Printf("Warning: Failed to get project details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+		return 0, false
+	}
+	if project == nil || project.UserID != userID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project_id. The project doesn't exist"})
+		return 0, false
+	}
+
+	return project.ID, true
+}
@@ -0,0 +1,206 @@
+package task
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+)
+
+const (
+	// defaultTaskListLimit is the number of tasks returned per page when no limit is given.
+	defaultTaskListLimit = 50
+	// maxTaskListLimit is the maximum number of tasks that can be requested per page.
+	maxTaskListLimit = 100
+	// defaultNextTasksLimit is the number of tasks returned by the "next" view when no limit is given.
+	defaultNextTasksLimit = 10
+)
+
+// parseTaskListOptions parses the filtering, sorting and pagination options from the query string.
+func parseTaskListOptions(ctx *gin.Context) (*models.TaskListOptions, error) {
+	opts := &models.TaskListOptions{
+		Status: models.TaskStatus(ctx.Query("status")),
+		Query:  ctx.Query("q"),
+		Sort:   ctx.DefaultQuery("sort", "-created_at"),
+		Cursor: ctx.Query("cursor"),
+	}
+
+	limit, err := parseLimit(ctx, defaultTaskListLimit)
+	if err != nil {
+		return nil, err
+	}
+	opts.Limit = limit
+
+	if opts.Status != "" && !validator.IsValidTaskStatus(opts.Status) {
+		return nil, errors.New(`Invalid status. It can have one of the following values: "todo", "in progress", "done"`)
+	}
+	if priorities := ctx.Query("priority"); priorities != "" {
+		for _, priority := range strings.Split(priorities, ",") {
+			priority := models.TaskPriority(strings.TrimSpace(priority))
+			if !validator.IsValidTaskPriority(priority) {
+				return nil, errors.New(invalidPriorityMessage)
+			}
+			opts.Priorities = append(opts.Priorities, priority)
+		}
+	}
+	for _, label := range ctx.QueryArray("label") {
+		label = strings.TrimSpace(label)
+		if !validator.IsBlank(label) && !containsString(opts.Labels, label) {
+			opts.Labels = append(opts.Labels, label)
+		}
+	}
+	switch ctx.DefaultQuery("label_match", "any") {
+	case "any":
+	case "all":
+		opts.MatchAllLabels = true
+	default:
+		return nil, errors.New(`Invalid label_match. It can be either "any" or "all"`)
+	}
+	if !validator.IsValidTaskSort(opts.Sort) {
+		return nil, errors.New(`Invalid sort. It can be one of "created_at", "updated_at", "title", "due_at" or "priority", prefixed with "-" for descending order`)
+	}
+	for param, dst := range map[string]**time.Time{"due_before": &opts.DueBefore, "due_after": &opts.DueAfter} {
+		if value := ctx.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, errors.New("Invalid " + param + ". It must be an RFC 3339 date-time")
+			}
+			*dst = &t
+		}
+	}
+	overdue, err := parseBool(ctx, "overdue", false)
+	if err != nil {
+		return nil, err
+	}
+	opts.Overdue = overdue
+
+	userID := ctx.MustGet("userID").(uint)
+	assigneeID, unassigned, err := parseUserFilter(ctx, "assignee", userID, true)
+	if err != nil {
+		return nil, err
+	}
+	opts.AssigneeID = assigneeID
+	opts.Unassigned = unassigned
+	creatorID, _, err := parseUserFilter(ctx, "created_by", userID, false)
+	if err != nil {
+		return nil, err
+	}
+	opts.CreatorID = creatorID
+
+	return opts, nil
+}
+
+// parseUserFilter parses a query parameter referring to a user: "me" for the authenticated user, a user
+// ID, or "none" when allowed. The returned ID is nil when the parameter is absent or "none".
+func parseUserFilter(ctx *gin.Context, param string, userID uint, allowNone bool) (*uint, bool, error) {
+	value := ctx.Query(param)
+	switch {
+	case value == "":
+		return nil, false, nil
+	case value == "me":
+		return &userID, false, nil
+	case value == "none" && allowNone:
+		return nil, true, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		if allowNone {
+			return nil, false, errors.New("Invalid " + param + `. It can be "me", "none" or the ID of a user`)
+		}
+		return nil, false, errors.New("Invalid " + param + `. It can be "me" or the ID of a user`)
+	}
+
+	filterID := uint(id)
+	return &filterID, false, nil
+}
+
+// parseWorkspaceID parses the "workspace_id" query parameter, which is nil when it's absent.
+func parseWorkspaceID(ctx *gin.Context) (*uint, error) {
+	idStr := ctx.Query("workspace_id")
+	if idStr == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return nil, errors.New("Invalid workspace_id")
+	}
+
+	workspaceID := uint(id)
+	return &workspaceID, nil
+}
+
+// parseLimit parses the "limit" query parameter, returning the default value when it's absent.
+func parseLimit(ctx *gin.Context, defaultLimit int) (int, error) {
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxTaskListLimit {
+		return 0, errors.New("Invalid limit. It must be between 1 and " + strconv.Itoa(maxTaskListLimit))
+	}
+
+	return limit, nil
+}
+
+// parseLocation parses the timezone from the "tz" query parameter, defaulting to the given timezone.
+func parseLocation(ctx *gin.Context, defaultTimezone string) (*time.Location, error) {
+	tz := ctx.Query("tz")
+	if tz == "" {
+		tz = defaultTimezone
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.New("Invalid timezone. It must be an IANA timezone name, e.g. Europe/Berlin")
+	}
+
+	return loc, nil
+}
+
+// parseBool parses a boolean query parameter, falling back to the default value when it's absent.
+func parseBool(ctx *gin.Context, param string, defaultValue bool) (bool, error) {
+	valueStr := ctx.Query(param)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return false, errors.New("Invalid " + param + ". It must be either true or false")
+	}
+
+	return value, nil
+}
+
+// containsString checks if the string is one of the values.
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uniqueIDs returns the IDs without their duplicates, in the order of their first occurrence.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,261 @@
+package task
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/recurrence"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// defaultOccurrencesLimit is the number of occurrences previewed when no limit is requested.
+const defaultOccurrencesLimit = 5
+
+// missingDueDateMessage is the error returned when a recurring task has no due date.
+const missingDueDateMessage = "Invalid recurrence. A recurring task must have a due date"
+
+// notRecurringMessage is the error returned when a series operation targets a task that doesn't recur.
+const notRecurringMessage = "Task is not recurring"
+
+// occurrencesResponse holds the upcoming occurrences of a recurring task.
+type occurrencesResponse struct {
+	Recurrence  string      `json:"recurrence"`
+	Occurrences []time.Time `json:"occurrences"`
+}
+
+// invalidRecurrenceTimezoneMessage is the error returned when the timezone of a series is unknown.
+const invalidRecurrenceTimezoneMessage = "Invalid recurrence timezone. It must be an IANA timezone name, e.g. Europe/Berlin"
+
+// applyRecurrence updates the recurrence of a task from the request body. The rule is stored in its
+// canonical form and anchored at the due date of the task, which is required, along with the timezone
+// the series is expanded in: the requested one, or else the timezone of the authenticated user. If the
+// rule or the timezone is invalid, it writes the error response and returns false.
+func applyRecurrence(ctx *gin.Context, app *config.Application, task *models.Task, td *taskData) bool {
+	if td.RecurrenceTimezone != nil && (!td.Recurrence.Set || td.Recurrence.Value == nil) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurrence timezone. It can only be set along with the recurrence"})
+		return false
+	}
+
+	if td.Recurrence.Set {
+		task.Recurrence, task.RecurrenceAnchor, task.RecurrenceTimezone = nil, nil, nil
+		if td.Recurrence.Value != nil {
+			rule, err := recurrence.Parse(*td.Recurrence.Value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurrence. " + err.Error()})
+				return false
+			}
+			timezone, ok := recurrenceTimezone(ctx, app, td.RecurrenceTimezone)
+			if !ok {
+				return false
+			}
+
+			canonical := rule.String()
+			task.Recurrence, task.RecurrenceAnchor, task.RecurrenceTimezone = &canonical, task.DueAt, &timezone
+		}
+	}
+
+	if task.Recurrence != nil && task.DueAt == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": missingDueDateMessage})
+		return false
+	}
+
+	return true
+}
+
+// recurrenceTimezone returns the requested timezone of a series, or else the timezone of the
+// authenticated user. If the requested timezone is invalid, or the user can't be retrieved, it writes
+// the error response and returns false.
+func recurrenceTimezone(ctx *gin.Context, app *config.Application, requested *string) (string, bool) {
+	if requested != nil {
+		if !validator.IsValidTimezone(*requested) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidRecurrenceTimezoneMessage})
+			return "", false
+		}
+		return *requested, true
+	}
+
+	return userTimezone(ctx, app)
+}
+
+// recurrenceLocation returns the location the series of a recurring task is expanded in. Series started
+// before their timezone was stored, or whose timezone is no longer known, are expanded in UTC.
+func recurrenceLocation(task *models.Task) *time.Location {
+	if task.RecurrenceTimezone == nil {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(*task.RecurrenceTimezone)
+	if err != nil {
+		log.Printf("Warning: Failed to load the recurrence timezone of task %d: %v", task.ID, err)
+		return time.UTC
+	}
+
+	return loc
+}
+
+// taskRule parses the recurrence rule of a task, which is nil if the task doesn't recur.
+func taskRule(task *models.Task) (*recurrence.Rule, error) {
+	if task.Recurrence == nil || task.RecurrenceAnchor == nil || task.DueAt == nil {
+		return nil, nil
+	}
+
+	return recurrence.Parse(*task.Recurrence)
+}
+
+// nextOccurrence returns the occurrence following a recurring task, due at the next date of its series
+// and starting at the same offset from it, or nil if the series ends with the task.
+func nextOccurrence(task *models.Task, rule *recurrence.Rule) *models.Task {
+	loc := recurrenceLocation(task)
+	dueAt, _, ok := rule.Next(task.RecurrenceAnchor.In(loc), task.DueAt.In(loc))
+	if !ok {
+		return nil
+	}
+	dueAt = dueAt.UTC()
+
+	next := &models.Task{
+		Title:              task.Title,
+		Description:        task.Description,
+		Status:             models.TaskStatusTodo,
+		Priority:           task.Priority,
+		ProjectID:          task.ProjectID,
+		ParentID:           task.ParentID,
+		WorkspaceID:        task.WorkspaceID,
+		AssigneeID:         task.AssigneeID,
+		UserID:             task.UserID,
+		DueAt:              &dueAt,
+		Recurrence:         task.Recurrence,
+		RecurrenceAnchor:   task.RecurrenceAnchor,
+		RecurrenceTimezone: task.RecurrenceTimezone,
+	}
+	if task.StartAt != nil {
+		startAt := dueAt.Add(task.StartAt.Sub(*task.DueAt))
+		next.StartAt = &startAt
+	}
+
+	return next
+}
+
+// completeTask marks a task as done, which ends the series on a recurring task, and creates the next
+// occurrence of the series along with the same labels, if any, at once. It returns
+// models.ErrTaskAlreadyDone when the task is already done, e.g. by a concurrent request.
+func completeTask(app *config.Application, task *models.Task) (*models.Task, *models.Task, error) {
+	rule, err := taskRule(task)
+	if err != nil {
+		log.Printf("Warning: Failed to parse the recurrence of task %d: %v", task.ID, err)
+	}
+
+	var next *models.Task
+	if rule != nil {
+		next = nextOccurrence(task, rule)
+	}
+	task.Recurrence, task.RecurrenceAnchor, task.RecurrenceTimezone = nil, nil, nil
+	task.Status = models.TaskStatusDone
+
+	return app.TaskRepository.CompleteTask(task, next)
+}
+
+// getRecurringTask retrieves the task identified in the URL along with its recurrence rule. If the
+// authenticated user may not do the action with the task, or it doesn't recur, it writes the error
+// response and returns nil.
+func getRecurringTask(ctx *gin.Context, app *config.Application, action authz.Action) (*models.Task, *recurrence.Rule) {
+	task := getAuthorizedTask(ctx, app, action)
+	if task == nil {
+		return nil, nil
+	}
+
+	rule, err := taskRule(task)
+	if err != nil {
+		log.Printf("Warning: Failed to parse the recurrence of task %d: %v", task.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recurrence"})
+		return nil, nil
+	}
+	if rule == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": notRecurringMessage})
+		return nil, nil
+	}
+
+	return task, rule
+}
+
+// GetOccurrencesHandler handles the preview of the upcoming occurrences of a recurring task the
+// authenticated user may see, i.e. the due dates of the occurrences following it.
+func GetOccurrencesHandler(ctx *gin.Context, app *config.Application) {
+	limit, err := parseLimit(ctx, defaultOccurrencesLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, rule := getRecurringTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	loc := recurrenceLocation(task)
+	occurrences := rule.Occurrences(task.RecurrenceAnchor.In(loc), task.DueAt.In(loc), limit)
+	for i := range occurrences {
+		occurrences[i] = occurrences[i].UTC()
+	}
+
+	ctx.JSON(http.StatusOK, occurrencesResponse{
+		Recurrence:  *task.Recurrence,
+		Occurrences: occurrences,
+	})
+}
+
+// SkipOccurrenceHandler handles skipping the current occurrence of a recurring task the authenticated
+// user may edit, which moves the task to the next date of its series.
+func SkipOccurrenceHandler(ctx *gin.Context, app *config.Application) {
+	task, rule := getRecurringTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+
+	next := nextOccurrence(task, rule)
+	if next == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The series has no more occurrences"})
+		return
+	}
+	task.DueAt, task.StartAt = next.DueAt, next.StartAt
+
+	// Update the task in the database
+	updatedTask, err := app.TaskRepository.UpdateTask(task)
+	if err != nil {
+		log.Printf("Warning: Failed to update task: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Occurrence skipped successfully",
+		"task":    updatedTask,
+	})
+}
+
+// EndRecurrenceHandler handles ending the series of a recurring task the authenticated user may edit. The
+// task is kept as the last occurrence.
+func EndRecurrenceHandler(ctx *gin.Context, app *config.Application) {
+	task, _ := getRecurringTask(ctx, app, authz.ActionEditTasks)
+	if task == nil {
+		return
+	}
+	task.Recurrence, task.RecurrenceAnchor, task.RecurrenceTimezone = nil, nil, nil
+
+	// Update the task in the database
+	updatedTask, err := app.TaskRepository.UpdateTask(task)
+	if err != nil {
+		log.Printf("Warning: Failed to update task: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Series ended successfully",
+		"task":    updatedTask,
+	})
+}
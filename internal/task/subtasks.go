@@ -0,0 +1,105 @@
+package task
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// GetSubtasksHandler handles retrieval of the direct subtasks of a task the authenticated user may see.
+func GetSubtasksHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	subtasks, err := app.TaskRepository.ListSubtasks(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve subtasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subtasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subtasks)
+}
+
+// GetTaskTreeHandler handles retrieval of a task the authenticated user may see along with its subtasks at
+// every depth, nested under their parent along with their progress.
+func GetTaskTreeHandler(ctx *gin.Context, app *config.Application) {
+	task := getAuthorizedTask(ctx, app, authz.ActionViewTasks)
+	if task == nil {
+		return
+	}
+
+	descendants, err := app.TaskRepository.ListDescendantTasks(task.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve subtasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subtasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.BuildTaskTree(task, descendants))
+}
+
+// writeParentError writes the error response matching an error returned when setting the parent
+// of a task, and reports whether it was one.
+func writeParentError(ctx *gin.Context, err error) bool {
+	switch err {
+	case models.ErrInvalidParent:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id. The parent task doesn't exist"})
+	case models.ErrTaskCycle:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id. A task can't be a subtask of itself or of its subtasks"})
+	default:
+		return false
+	}
+
+	return true
+}
+
+// markSubtasksDone marks the subtasks of a task at every depth as done, creating the next occurrence
+// of the recurring ones. Like tasks marked as done directly, the subtasks blocked by tasks that aren't
+// done yet are left unchanged, and their IDs are returned. Subtasks blocked by other subtasks are
+// marked as done once those are.
+func markSubtasksDone(app *config.Application, taskID uint) ([]uint, error) {
+	descendants, err := app.TaskRepository.ListDescendantTasks(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := descendants
+	for {
+		var blocked []models.Task
+		for i := range pending {
+			// Retrieve the subtask again, as completing the previous ones may have unblocked it.
+			subtask, err := app.TaskRepository.GetTaskByID(pending[i].ID)
+			if err != nil {
+				return nil, err
+			}
+			if subtask == nil || subtask.Status == models.TaskStatusDone {
+				continue
+			}
+			if subtask.Blocked {
+				blocked = append(blocked, *subtask)
+				continue
+			}
+
+			if _, _, err := completeTask(app, subtask); err != nil && err != models.ErrTaskAlreadyDone {
+				return nil, err
+			}
+		}
+
+		// Stop once a pass doesn't unblock any subtask.
+		if len(blocked) == len(pending) {
+			blockedIDs := make([]uint, 0, len(blocked))
+			for _, subtask := range blocked {
+				blockedIDs = append(blockedIDs, subtask.ID)
+			}
+			return blockedIDs, nil
+		}
+		pending = blocked
+	}
+}
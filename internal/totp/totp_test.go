@@ -0,0 +1,94 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors of RFC 6238, "12345678901234567890", base32-encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// The test vectors of RFC 6238, Appendix B, truncated to 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d: got %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Errorf("got no error for an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current", code: "050471", skew: 1, wantStep: 37037037, wantOK: true},
+		{name: "with spaces", code: "050 471", skew: 1, wantStep: 37037037, wantOK: true},
+		{name: "previous step", code: "081804", skew: 1, wantStep: 37037036, wantOK: true},
+		{name: "previous step without skew", code: "081804", skew: 0},
+		{name: "wrong", code: "123456", skew: 1},
+		{name: "too short", code: "05047", skew: 1},
+		{name: "too long", code: "0504710", skew: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || (ok && step != tt.wantStep) {
+				t.Errorf("got %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("got secret %q decoding to %d bytes, %v, want 20 bytes", secret, len(key), err)
+	}
+	if other, _ := GenerateSecret(); other == secret {
+		t.Errorf("got the same secret twice")
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Task Manager", "alice@example.com", rfcSecret))
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Task Manager:alice@example.com" {
+		t.Errorf("got %q, want an otpauth://totp/ URI labeled with the issuer and account", u)
+	}
+	query := u.Query()
+	if query.Get("secret") != rfcSecret || query.Get("issuer") != "Task Manager" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("got parameters %v", query)
+	}
+}
@@ -3,6 +3,9 @@ package validator
 import (
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/milanvthakor/task-manager-api/internal/models"
 )
@@ -10,16 +13,17 @@ import (
 // emailRegex is a regular expression for validating email
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
+// colorRegex is a regular expression for validating hex colors
+var colorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// localeRegex is a regular expression for validating BCP 47 language tags, e.g. "en" or "pt-BR"
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
 // IsValidEmail checks if an email is valid.
 func IsValidEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// IsValidPassword checks if a password is valid.
-func IsValidPassword(password string) bool {
-	return len(password) >= 8 && len(password) <= 12
-}
-
 // IsBlank checks if a string is empty.
 func IsBlank(s string) bool {
 	s = strings.TrimSpace(s)
@@ -35,3 +39,83 @@ func IsValidTaskStatus(status models.TaskStatus) bool {
 
 	return false
 }
+
+// IsValidTaskPriority checks if a priority is valid.
+func IsValidTaskPriority(priority models.TaskPriority) bool {
+	switch priority {
+	case models.TaskPriorityNone, models.TaskPriorityLow, models.TaskPriorityMedium, models.TaskPriorityHigh, models.TaskPriorityUrgent:
+		return true
+	}
+
+	return false
+}
+
+// IsValidTaskSort checks if a sort option names a sortable task field, optionally prefixed with "-".
+func IsValidTaskSort(sort string) bool {
+	return models.IsTaskSortField(strings.TrimPrefix(sort, "-"))
+}
+
+// IsValidTaskSchedule checks that a task doesn't start after it's due. Either date may be unset.
+func IsValidTaskSchedule(startAt, dueAt *time.Time) bool {
+	return startAt == nil || dueAt == nil || !startAt.After(*dueAt)
+}
+
+// IsValidLabelName checks if a label name is valid.
+func IsValidLabelName(name string) bool {
+	return !IsBlank(name) && utf8.RuneCountInString(name) <= 50
+}
+
+// IsValidColor checks if a color is a valid hex color, e.g. "#1f77b4".
+func IsValidColor(color string) bool {
+	return colorRegex.MatchString(color)
+}
+
+// IsValidProjectName checks if a project name is valid.
+func IsValidProjectName(name string) bool {
+	return !IsBlank(name) && utf8.RuneCountInString(name) <= 100
+}
+
+// IsValidDisplayName checks if a display name is valid. It may be empty.
+func IsValidDisplayName(name string) bool {
+	return utf8.RuneCountInString(name) <= 100 && !hasControlChars(name)
+}
+
+// IsValidTimezone checks if a timezone is a known IANA timezone name, e.g. "Europe/Berlin".
+func IsValidTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// IsValidLocale checks if a locale is a valid BCP 47 language tag, e.g. "en" or "pt-BR".
+func IsValidLocale(locale string) bool {
+	return len(locale) <= 35 && localeRegex.MatchString(locale)
+}
+
+// IsValidUserRole checks if a user role is valid.
+func IsValidUserRole(role models.UserRole) bool {
+	switch role {
+	case models.UserRoleUser, models.UserRoleAdmin:
+		return true
+	}
+
+	return false
+}
+
+// IsValidWorkspaceName checks if a workspace name is valid.
+func IsValidWorkspaceName(name string) bool {
+	return !IsBlank(name) && utf8.RuneCountInString(name) <= 100 && !hasControlChars(name)
+}
+
+// IsValidWorkspaceRole checks if a workspace role is valid.
+func IsValidWorkspaceRole(role models.WorkspaceRole) bool {
+	return role.Rank() > 0
+}
+
+// hasControlChars checks if a string contains control characters, such as line breaks, which the names
+// shown in emails mustn't contain.
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}
@@ -0,0 +1,41 @@
+package validator
+
+import "testing"
+
+func TestIsValidWorkspaceName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Team", want: true},
+		{name: "Équipe produit", want: true},
+		{name: "", want: false},
+		{name: "   ", want: false},
+		{name: "team\r\nBcc: victim@example.com", want: false},
+		{name: "team\nX-Injected: yes", want: false},
+		{name: "team\tops", want: false},
+		{name: "team\x00", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsValidWorkspaceName(tt.name); got != tt.want {
+			t.Errorf("IsValidWorkspaceName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidDisplayName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Alice", want: true},
+		{name: "", want: true},
+		{name: "Alice\r\nBcc: victim@example.com", want: false},
+		{name: "Alice\u0085", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsValidDisplayName(tt.name); got != tt.want {
+			t.Errorf("IsValidDisplayName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
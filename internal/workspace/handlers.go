@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// workspaceData holds the workspace details.
+type workspaceData struct {
+	Name string `json:"name"`
+}
+
+// invalidNameMessage is the error returned when a workspace name is blank, too long or contains control
+// characters.
+const invalidNameMessage = "Invalid name. It must not be empty nor longer than 100 characters, nor contain line breaks or other control characters"
+
+// GetWorkspacesHandler handles retrieval of the workspaces the authenticated user is a member of, along
+// with their role in each.
+func GetWorkspacesHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	memberships, err := app.WorkspaceRepository.ListWorkspaceMemberships(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve workspaces: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspaces"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, memberships)
+}
+
+// CreateWorkspaceHandler handles the creation of a new workspace, which the authenticated user owns.
+func CreateWorkspaceHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	var wd workspaceData
+	if err := ctx.ShouldBindJSON(&wd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	wd.Name = strings.TrimSpace(wd.Name)
+	if !validator.IsValidWorkspaceName(wd.Name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+		return
+	}
+
+	// Store workspace details in the database.
+	newWorkspace, err := app.WorkspaceRepository.CreateWorkspace(&models.Workspace{Name: wd.Name}, userID)
+	if err != nil {
+		log.Printf("Warning: Failed to create workspace: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create workspace"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":   "Workspace created successfully",
+		"workspace": models.WorkspaceMembership{Workspace: *newWorkspace, Role: models.WorkspaceRoleOwner},
+	})
+}
+
+// GetWorkspaceByIDHandler handles the retrieval of a workspace by ID only if the authenticated user is a
+// member of it, along with their role.
+func GetWorkspaceByIDHandler(ctx *gin.Context, app *config.Application) {
+	workspace, member := getAuthorizedWorkspace(ctx, app, authz.ActionViewTasks)
+	if workspace == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WorkspaceMembership{Workspace: *workspace, Role: member.Role})
+}
+
+// UpdateWorkspaceByIDHandler handles the renaming of a workspace by ID, which requires the owner or admin
+// role.
+func UpdateWorkspaceByIDHandler(ctx *gin.Context, app *config.Application) {
+	workspace, member := getAuthorizedWorkspace(ctx, app, authz.ActionManageWorkspace)
+	if workspace == nil {
+		return
+	}
+
+	var wd workspaceData
+	if err := ctx.ShouldBindJSON(&wd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	wd.Name = strings.TrimSpace(wd.Name)
+	if !validator.IsValidWorkspaceName(wd.Name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidNameMessage})
+		return
+	}
+	workspace.Name = wd.Name
+
+	// Update the workspace in the database
+	updatedWorkspace, err := app.WorkspaceRepository.UpdateWorkspace(workspace)
+	if err != nil {
+		log.Printf("Warning: Failed to update workspace: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workspace"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":   "Workspace updated successfully",
+		"workspace": models.WorkspaceMembership{Workspace: *updatedWorkspace, Role: member.Role},
+	})
+}
+
+// DeleteWorkspaceByIDHandler handles the deletion of a workspace by ID along with its tasks, which
+// requires the owner role.
+func DeleteWorkspaceByIDHandler(ctx *gin.Context, app *config.Application) {
+	workspace, _ := getAuthorizedWorkspace(ctx, app, authz.ActionDeleteWorkspace)
+	if workspace == nil {
+		return
+	}
+
+	if err := app.WorkspaceRepository.DeleteWorkspace(workspace.ID); err != nil {
+		log.Printf("Warning: Failed to delete workspace from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete workspace"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+}
+
+// getAuthorizedWorkspace retrieves the workspace identified in the URL along with the membership of the
+// authenticated user. If they may not do the action in it, or it can't be retrieved, it writes the error
+// response and returns nil. Workspaces the user isn't a member of are reported as not found.
+func getAuthorizedWorkspace(ctx *gin.Context, app *config.Application, action authz.Action) (*models.Workspace, *models.WorkspaceMember) {
+	userID := ctx.MustGet("userID").(uint)
+	workspaceID := ctx.MustGet("workspaceID").(uint)
+
+	member, decision, err := app.Authorizer.AuthorizeWorkspace(userID, workspaceID, action)
+	if err != nil {
+		log.Printf("Warning: Failed to authorize access to workspace %d: %v", workspaceID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspace"})
+		return nil, nil
+	}
+	switch decision {
+	case authz.DecisionHidden:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return nil, nil
+	case authz.DecisionForbidden:
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return nil, nil
+	}
+
+	workspace, err := app.WorkspaceRepository.GetWorkspaceByID(workspaceID)
+	if err != nil {
+		log.Printf("Warning: Failed to get workspace details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspace"})
+		return nil, nil
+	}
+	if workspace == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return nil, nil
+	}
+
+	return workspace, member
+}
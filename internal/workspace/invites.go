@@ -0,0 +1,249 @@
+package workspace
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/auth"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// inviteData holds the email to invite to a workspace along with the role they'll get.
+type inviteData struct {
+	Email string               `json:"email"`
+	Role  models.WorkspaceRole `json:"role"`
+}
+
+// acceptInviteData holds the token of the invite to accept.
+type acceptInviteData struct {
+	Token string `json:"token"`
+}
+
+// invalidInviteTokenMessage is the error returned when an invite token is unknown, expired or already used.
+const invalidInviteTokenMessage = "Invalid or expired invite token"
+
+// GetInvitesHandler handles retrieval of the pending invites of a workspace, which requires the owner or
+// admin role.
+func GetInvitesHandler(ctx *gin.Context, app *config.Application) {
+	workspace, _ := getAuthorizedWorkspace(ctx, app, authz.ActionManageMembers)
+	if workspace == nil {
+		return
+	}
+
+	invites, err := app.WorkspaceRepository.ListWorkspaceInvites(workspace.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve workspace invites: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invites"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invites)
+}
+
+// CreateInviteHandler handles inviting someone to a workspace by email, with a role ranking below the one
+// of the authenticated user, "member" by default. A link to accept the invite is sent to the email, and
+// replaces any invite previously sent to it.
+func CreateInviteHandler(ctx *gin.Context, app *config.Application) {
+	workspace, actor := getAuthorizedWorkspace(ctx, app, authz.ActionManageMembers)
+	if workspace == nil {
+		return
+	}
+
+	var id inviteData
+	if err := ctx.ShouldBindJSON(&id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	// Validate inputs.
+	id.Email = strings.TrimSpace(id.Email)
+	if !validator.IsValidEmail(id.Email) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+	if id.Role == "" {
+		id.Role = models.WorkspaceRoleMember
+	}
+	if !validator.IsValidWorkspaceRole(id.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidRoleMessage})
+		return
+	}
+	if id.Role == models.WorkspaceRoleOwner {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. The ownership can only be transferred to a member"})
+		return
+	}
+	if !authz.CanAssignRole(actor, id.Role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return
+	}
+
+	// Check if the email already belongs to a member.
+	user, err := app.UserRepository.GetUserByEmail(id.Email)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the invite"})
+		return
+	}
+	if user != nil {
+		member, err := app.WorkspaceRepository.GetWorkspaceMember(workspace.ID, user.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to get workspace member details from the database: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the invite"})
+			return
+		}
+		if member != nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "The user is already a member of the workspace"})
+			return
+		}
+	}
+
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		log.Printf("Warning: Failed to generate invite token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the invite"})
+		return
+	}
+	invite, err := app.WorkspaceRepository.CreateWorkspaceInvite(&models.WorkspaceInvite{
+		WorkspaceID: workspace.ID,
+		Email:       id.Email,
+		Role:        id.Role,
+		TokenHash:   auth.HashToken(token),
+		ExpiresAt:   time.Now().Add(app.Config.WorkspaceInviteTTL),
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to create workspace invite: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the invite"})
+		return
+	}
+
+	msg := inviteMessage(app.Config.AppURL, invite.Email, workspace.Name, actor.Email, token, invite.ExpiresAt)
+	go func() {
+		if err := app.Mailer.Send(msg); err != nil {
+			log.Printf("Warning: Failed to send the invite %d to workspace %d: %v", invite.ID, workspace.ID, err)
+		}
+	}()
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Invite sent successfully",
+		"invite":  invite,
+	})
+}
+
+// DeleteInviteHandler handles the cancellation of a pending invite to a workspace, which requires the
+// owner or admin role.
+func DeleteInviteHandler(ctx *gin.Context, app *config.Application) {
+	workspace, _ := getAuthorizedWorkspace(ctx, app, authz.ActionManageMembers)
+	if workspace == nil {
+		return
+	}
+	inviteID := ctx.MustGet("inviteID").(uint)
+
+	invite, err := app.WorkspaceRepository.GetWorkspaceInviteByID(inviteID)
+	if err != nil {
+		log.Printf("Warning: Failed to get workspace invite details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel the invite"})
+		return
+	}
+	if invite == nil || invite.WorkspaceID != workspace.ID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+
+	err = app.WorkspaceRepository.DeleteWorkspaceInvite(invite.ID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to delete workspace invite: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel the invite"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invite cancelled successfully"})
+}
+
+// AcceptInviteHandler handles the acceptance of an invite to a workspace with the token sent by email. The
+// invite must have been sent to the email of the authenticated user, who joins the workspace with the role
+// of the invite.
+func AcceptInviteHandler(ctx *gin.Context, app *config.Application) {
+	userID := ctx.MustGet("userID").(uint)
+
+	var ad acceptInviteData
+	if err := ctx.ShouldBindJSON(&ad); err != nil || ad.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+
+	invite, err := app.WorkspaceRepository.GetWorkspaceInviteByHash(auth.HashToken(ad.Token))
+	if err != nil {
+		log.Printf("Warning: Failed to get workspace invite from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept the invite"})
+		return
+	}
+	if invite == nil || time.Now().After(invite.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidInviteTokenMessage})
+		return
+	}
+
+	user, err := app.UserRepository.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to get user details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept the invite"})
+		return
+	}
+	if user == nil || !strings.EqualFold(user.Email, invite.Email) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "The invite was sent to another email"})
+		return
+	}
+
+	err = app.WorkspaceRepository.AcceptWorkspaceInvite(invite.ID, userID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidInviteTokenMessage})
+		return
+	}
+	if err == models.ErrAlreadyMember {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "You are already a member of the workspace"})
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to accept workspace invite: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept the invite"})
+		return
+	}
+
+	workspace, err := app.WorkspaceRepository.GetWorkspaceByID(invite.WorkspaceID)
+	if err != nil || workspace == nil {
+		log.Printf("Warning: Failed to get workspace details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspace"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":   "Invite accepted successfully",
+		"workspace": models.WorkspaceMembership{Workspace: *workspace, Role: invite.Role},
+	})
+}
+
+// inviteMessage builds the email inviting someone to join a workspace.
+func inviteMessage(appURL, email, workspaceName, inviterEmail, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimSuffix(appURL, "/") + "/accept-invite?token=" + url.QueryEscape(token)
+
+	return mail.Message{
+		To:      email,
+		Subject: "You're invited to join " + workspaceName,
+		Body: inviterEmail + " invited you to join the " + workspaceName + " workspace on Task Manager, to share its tasks.\n\n" +
+			"To join it, log in or sign up with this email, then open the following link before " + expiresAt.UTC().Format(time.RFC1123) + ":\n\n" +
+			link + "\n\n" +
+			"If you don't want to join it, you can ignore this email.",
+	}
+}
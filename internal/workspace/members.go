@@ -0,0 +1,139 @@
+package workspace
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/validator"
+	"github.com/milanvthakor/task-manager-api/pkg/config"
+)
+
+// memberRoleData holds the role to give a member.
+type memberRoleData struct {
+	Role models.WorkspaceRole `json:"role"`
+}
+
+// invalidRoleMessage is the error returned for an unknown workspace role.
+const invalidRoleMessage = `Invalid role. It can have one of the following values: "owner", "admin", "member", "viewer"`
+
+// GetMembersHandler handles retrieval of the members of a workspace the authenticated user is a member of.
+func GetMembersHandler(ctx *gin.Context, app *config.Application) {
+	workspace, _ := getAuthorizedWorkspace(ctx, app, authz.ActionViewTasks)
+	if workspace == nil {
+		return
+	}
+
+	members, err := app.WorkspaceRepository.ListWorkspaceMembers(workspace.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to retrieve workspace members: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+// UpdateMemberRoleHandler handles the change of the role of a member of a workspace. Owners and admins can
+// only manage the members ranking below them and give roles ranking below theirs. Giving the owner role
+// transfers the ownership, and the previous owner becomes an admin.
+func UpdateMemberRoleHandler(ctx *gin.Context, app *config.Application) {
+	workspace, actor := getAuthorizedWorkspace(ctx, app, authz.ActionManageMembers)
+	if workspace == nil {
+		return
+	}
+
+	var rd memberRoleData
+	if err := ctx.ShouldBindJSON(&rd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inputs"})
+		return
+	}
+	if !validator.IsValidWorkspaceRole(rd.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalidRoleMessage})
+		return
+	}
+
+	target := getMember(ctx, app, workspace.ID)
+	if target == nil {
+		return
+	}
+	if target.UserID == actor.UserID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own role"})
+		return
+	}
+	if !authz.CanManageMember(actor, target) || !authz.CanAssignRole(actor, rd.Role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return
+	}
+
+	var err error
+	if rd.Role == models.WorkspaceRoleOwner {
+		err = app.WorkspaceRepository.TransferWorkspaceOwnership(workspace.ID, actor.UserID, target.UserID)
+	} else {
+		err = app.WorkspaceRepository.UpdateWorkspaceMemberRole(workspace.ID, target.UserID, rd.Role)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to update the role of a workspace member: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the role"})
+		return
+	}
+	target.Role = rd.Role
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"member":  target,
+	})
+}
+
+// RemoveMemberHandler handles the removal of a member from a workspace, which requires a role ranking
+// above theirs. Every member but the owner can leave the workspace by removing themselves. The tasks the
+// member created in the workspace are handed over to its owner.
+func RemoveMemberHandler(ctx *gin.Context, app *config.Application) {
+	workspace, actor := getAuthorizedWorkspace(ctx, app, authz.ActionViewTasks)
+	if workspace == nil {
+		return
+	}
+
+	target := getMember(ctx, app, workspace.ID)
+	if target == nil {
+		return
+	}
+	if target.UserID == actor.UserID {
+		if actor.Role == models.WorkspaceRoleOwner {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "The owner can't leave the workspace. Transfer the ownership first"})
+			return
+		}
+	} else if !authz.CanManageMember(actor, target) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": authz.ForbiddenMessage})
+		return
+	}
+
+	if err := app.WorkspaceRepository.RemoveWorkspaceMember(workspace.ID, target.UserID); err != nil {
+		log.Printf("Warning: Failed to remove workspace member: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove the member"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// getMember retrieves the member of the workspace identified in the URL. If they aren't a member, or can't
+// be retrieved, it writes the error response and returns nil.
+func getMember(ctx *gin.Context, app *config.Application, workspaceID uint) *models.WorkspaceMember {
+	memberUserID := ctx.MustGet("memberUserID").(uint)
+
+	member, err := app.WorkspaceRepository.GetWorkspaceMember(workspaceID, memberUserID)
+	if err != nil {
+		log.Printf("Warning: Failed to get workspace member details from the database: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the member"})
+		return nil
+	}
+	if member == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return nil
+	}
+
+	return member
+}
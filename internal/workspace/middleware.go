@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractWorkspaceIDMiddleware extract the workspace ID from URL parameters.
+func ExtractWorkspaceIDMiddleware(ctx *gin.Context) {
+	workspaceIDStr := ctx.Param("workspaceID")
+	workspaceID, err := strconv.ParseUint(workspaceIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	// Store the workspace ID in the context
+	ctx.Set("workspaceID", uint(workspaceID))
+	ctx.Next()
+}
+
+// ExtractMemberIDMiddleware extract the user ID of a workspace member from URL parameters.
+func ExtractMemberIDMiddleware(ctx *gin.Context) {
+	memberIDStr := ctx.Param("userID")
+	memberID, err := strconv.ParseUint(memberIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Store the user ID in the context. "userID" already holds the ID of the authenticated user.
+	ctx.Set("memberUserID", uint(memberID))
+	ctx.Next()
+}
+
+// ExtractInviteIDMiddleware extract the invite ID from URL parameters.
+func ExtractInviteIDMiddleware(ctx *gin.Context) {
+	inviteIDStr := ctx.Param("inviteID")
+	inviteID, err := strconv.ParseUint(inviteIDStr, 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	// Store the invite ID in the context
+	ctx.Set("inviteID", uint(inviteID))
+	ctx.Next()
+}
@@ -1,10 +1,29 @@
 package config
 
-import "github.com/milanvthakor/task-manager-api/internal/models"
+import (
+	"github.com/milanvthakor/task-manager-api/internal/authz"
+	"github.com/milanvthakor/task-manager-api/internal/mail"
+	"github.com/milanvthakor/task-manager-api/internal/models"
+	"github.com/milanvthakor/task-manager-api/internal/password"
+	"github.com/milanvthakor/task-manager-api/internal/signing"
+)
 
 // Application holds application-wide dependencies.
 type Application struct {
-	Config         *Config
-	UserRepository *models.UserRepository
-	TaskRepository *models.TaskRepository
+	Config               *Config
+	Keys                 *signing.KeySet
+	UserRepository       models.UserStore
+	TaskRepository       models.TaskStore
+	LabelRepository      models.LabelStore
+	ProjectRepository    models.ProjectStore
+	TokenRepository      models.TokenStore
+	MFARepository        models.MFAStore
+	AuditRepository      models.AuditStore
+	LoginThrottles       models.LoginThrottleStore
+	DataExportRepository models.DataExportStore
+	WorkspaceRepository  models.WorkspaceStore
+	Authorizer           *authz.Authorizer
+	Mailer               mail.Mailer
+	PasswordPolicy       password.Policy
+	PasswordHasher       *password.Hasher
 }